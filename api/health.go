@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/919Umesh/gold_go/pkg/health"
+)
+
+func (r *Router) setupHealth() {
+	maxPriceAge := time.Duration(r.cfg.Pricing.MaxAgeMinutes) * time.Minute
+
+	checker := health.NewChecker(time.Duration(r.cfg.Server.HealthCacheSeconds)*time.Second,
+		health.Check{Name: "database", Timeout: time.Second, Run: func(ctx context.Context) (string, error) {
+			sqlDB, err := r.db.DB()
+			if err != nil {
+				return "", err
+			}
+			return "", sqlDB.PingContext(ctx)
+		}},
+		health.Check{Name: "redis", Timeout: time.Second, Run: func(ctx context.Context) (string, error) {
+			return "", r.redisClient.Ping(ctx)
+		}},
+		health.Check{Name: "gold_price", Timeout: 2 * time.Second, Run: func(ctx context.Context) (string, error) {
+			_, updatedAt, err := r.services.Gold.GetCurrentPrice(ctx)
+			if err != nil {
+				return "", err
+			}
+			age := time.Since(updatedAt).Round(time.Second)
+			if age > maxPriceAge {
+				return "", fmt.Errorf("latest price is %s old, limit %s", age, maxPriceAge)
+			}
+			return fmt.Sprintf("updated %s ago", age), nil
+		}},
+		health.Check{Name: "queue", Timeout: time.Second, Run: func(ctx context.Context) (string, error) {
+			stats, err := r.services.Queue.Stats(ctx)
+			if err != nil {
+				return "", err
+			}
+			detail := fmt.Sprintf("%d ready, %d delayed, %d in flight, %d dead", stats.Ready, stats.Delayed, stats.InFlight, stats.Dead)
+			if stats.Ready > int64(r.cfg.Jobs.BacklogLimit) {
+				return detail, fmt.Errorf("backlog of %d exceeds %d", stats.Ready, r.cfg.Jobs.BacklogLimit)
+			}
+			return detail, nil
+		}},
+	)
+
+	// livez only proves the process is serving; restarting it will not fix
+	// a dependency outage, so those belong to readyz. /health is kept for
+	// existing liveness probes.
+	livez := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+	}
+	r.engine.GET("/livez", livez)
+	r.engine.GET("/health", livez)
+
+	readyz := func(c *gin.Context) {
+		if !r.ready.Load() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting down"})
+			return
+		}
+		report := checker.Check(c.Request.Context())
+		code := http.StatusOK
+		if !report.OK() {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, report)
+	}
+	r.engine.GET("/readyz", readyz)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/919Umesh/gold_go/pkg/metrics"
+	"github.com/919Umesh/gold_go/pkg/middleware"
+	"github.com/919Umesh/gold_go/pkg/queue"
+)
+
+// setupMetrics must run before any route is registered: gin only applies
+// middleware to routes added after Use. /metrics is served on its own
+// listener, never on the public one.
+func (r *Router) setupMetrics() {
+	r.engine.Use(middleware.Metrics())
+
+	sqlDB, err := r.db.DB()
+	if err == nil {
+		err = metrics.RegisterDB("gold", sqlDB)
+	}
+	if err != nil {
+		slog.Error("api: register database pool metrics failed", "error", err)
+	}
+	if err := metrics.Registry.Register(&queueCollector{queue: r.services.Queue}); err != nil {
+		slog.Error("api: register job queue metrics failed", "error", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	r.metrics = &http.Server{
+		Addr:              ":" + r.cfg.Server.MetricsPort,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
+
+var queueDepthDesc = prometheus.NewDesc("gold_job_queue_tasks", "Tasks in the job queue by state.", []string{"state"}, nil)
+
+// queueCollector reads the job queue's stats at scrape time, so the numbers
+// are shared by every replica rather than counted per process.
+type queueCollector struct {
+	queue *queue.Queue
+}
+
+func (c *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueDepthDesc
+}
+
+func (c *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	stats, err := c.queue.Stats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(queueDepthDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(stats.Ready), "ready")
+	ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(stats.Delayed), "delayed")
+	ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(stats.InFlight), "in_flight")
+	ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(stats.Dead), "dead")
+}
@@ -2,71 +2,128 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
 	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/aml"
+	"github.com/919Umesh/gold_go/internal/audit"
 	"github.com/919Umesh/gold_go/internal/auth"
+	"github.com/919Umesh/gold_go/internal/custody"
 	"github.com/919Umesh/gold_go/internal/gold"
+	"github.com/919Umesh/gold_go/internal/kyc"
+	"github.com/919Umesh/gold_go/internal/limits"
+	"github.com/919Umesh/gold_go/internal/otp"
+	"github.com/919Umesh/gold_go/internal/rbac"
+	"github.com/919Umesh/gold_go/internal/reconciliation"
+	"github.com/919Umesh/gold_go/internal/reports"
+	"github.com/919Umesh/gold_go/internal/scheduler"
+	"github.com/919Umesh/gold_go/internal/users"
 	"github.com/919Umesh/gold_go/internal/wallet"
+	"github.com/919Umesh/gold_go/internal/webhook"
+	"github.com/919Umesh/gold_go/pkg/clock"
 	"github.com/919Umesh/gold_go/pkg/middleware"
+	"github.com/919Umesh/gold_go/pkg/notify"
+	"github.com/919Umesh/gold_go/pkg/queue"
 	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/919Umesh/gold_go/pkg/tokenstore"
 )
 
+// Services are built once by the serve command and shared between the
+// handlers and the background jobs, so both use the same caches and the
+// defaults seeded at startup.
+type Services struct {
+	Audit          audit.Service
+	RBAC           rbac.Service
+	Gold           *gold.Service
+	Webhooks       webhook.Service
+	Limits         limits.Service
+	AML            aml.Service
+	Wallet         wallet.Service
+	KYC            kyc.Service
+	Reports        reports.Service
+	Custody        custody.Service
+	Reconciliation reconciliation.Service
+	Scheduler      *scheduler.Service
+	Queue          *queue.Queue
+}
+
 type Router struct {
 	db          *gorm.DB
 	cfg         *config.Config
 	engine      *gin.Engine
+	server      *http.Server
+	metrics     *http.Server
+	ready       atomic.Bool
 	redisClient *redis.Client
+	services    Services
 }
 
-func NewRouter(db *gorm.DB, cfg *config.Config) *Router {
+func NewRouter(db *gorm.DB, cfg *config.Config, redisClient *redis.Client, services Services) (*Router, error) {
 	router := &Router{
-		db:     db,
-		cfg:    cfg,
-		engine: gin.Default(),
+		db:          db,
+		cfg:         cfg,
+		engine:      gin.New(),
+		redisClient: redisClient,
+		services:    services,
+	}
+	router.engine.Use(middleware.Tracing(), middleware.RequestID(), middleware.AccessLog(), gin.Recovery())
+	router.server = &http.Server{
+		Addr:         ":" + cfg.Server.Port,
+		Handler:      router.engine,
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeoutSeconds) * time.Second,
+		IdleTimeout:  time.Duration(cfg.Server.IdleTimeoutSeconds) * time.Second,
 	}
-
-	router.redisClient = redis.NewRedisClient(
-		cfg.RedisAddress,
-		cfg.RedisPassword,
-		cfg.RedisDB,
-	)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := router.redisClient.Ping(ctx); err != nil {
-		panic("Failed to connect to redis: " + err.Error())
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
 	}
 
 	router.setupRoutes()
-	return router
+	return router, nil
 }
 
 func (r *Router) setupRoutes() {
+	r.setupMetrics()
+
 	rateLimiter := middleware.NewRateLimiter(r.redisClient)
 	cacheMiddleware := middleware.NewCacheMiddleware(r.redisClient)
+	denylist := tokenstore.NewDenylist(r.redisClient)
+	mailer := notify.NewEmailSender(r.cfg)
+	otpService := otp.NewService(r.redisClient, mailer, notify.NewSMSSender(r.cfg), r.cfg)
+	loginThrottle := auth.NewLoginThrottle(r.redisClient, mailer, r.cfg)
+	authService := auth.NewService(auth.NewRepository(r.db), r.cfg, denylist, clock.System{}, otpService, loginThrottle, r.services.Audit)
+	authHandler := auth.NewHandler(authService)
+	kycHandler := kyc.NewHandler(r.services.KYC)
+	limitsHandler := limits.NewHandler(r.services.Limits)
+	amlHandler := aml.NewHandler(r.services.AML)
+	walletHandler := wallet.NewHandler(r.services.Wallet, r.services.Gold, r.cfg, clock.System{})
+	webhookHandler := webhook.NewHandler(r.services.Webhooks)
 
-	r.engine.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "healthy"})
-	})
+	r.setupHealth()
 
 	v1 := r.engine.Group("/api/v1")
 	{
 
 		public := v1.Group("")
 		{
-			authRepo := auth.NewRepository(r.db)
-			authService := auth.NewService(authRepo, r.cfg.JWTSecret)
-			authHandler := auth.NewHandler(authService)
-
 			public.POST("/auth/register", rateLimiter.RateLimit(), authHandler.Register)
 			public.POST("/auth/login", rateLimiter.RateLimit(), authHandler.Login)
+			public.POST("/auth/login/2fa", rateLimiter.RateLimit(), authHandler.LoginMFA)
+			public.POST("/auth/refresh", rateLimiter.RateLimit(), authHandler.Refresh)
+			public.POST("/auth/password/forgot", rateLimiter.RateLimit(), authHandler.ForgotPassword)
+			public.POST("/auth/password/reset", rateLimiter.RateLimit(), authHandler.ResetPassword)
 
-			goldService := gold.NewService(r.db, r.cfg)
-			goldHandler := gold.NewHandler(goldService)
+			goldHandler := gold.NewHandler(r.services.Gold)
 
 			public.GET("/gold/price", rateLimiter.RateLimit(), cacheMiddleware.Cache(1*time.Minute), goldHandler.GetCurrentPrice)
 			public.GET("/gold/history", rateLimiter.RateLimit(), cacheMiddleware.Cache(1*time.Minute), goldHandler.GetPriceHistory)
@@ -74,40 +131,167 @@ func (r *Router) setupRoutes() {
 		}
 
 		protected := v1.Group("")
-		protected.Use(middleware.JWTAuth(r.cfg))
+		protected.Use(middleware.JWTAuth(r.cfg, denylist))
 		{
-			authRepo := auth.NewRepository(r.db)
-			authService := auth.NewService(authRepo, r.cfg.JWTSecret)
-			authHandler := auth.NewHandler(authService)
-
 			protected.GET("/auth/profile", rateLimiter.RateLimit(), cacheMiddleware.Cache(1*time.Minute), authHandler.GetProfile)
 			protected.PUT("/auth/profile/update", rateLimiter.RateLimit(), authHandler.UpdateProfile)
+			protected.POST("/auth/logout", rateLimiter.RateLimit(), authHandler.Logout)
+			protected.POST("/auth/logout-all", rateLimiter.RateLimit(), authHandler.LogoutAll)
+			protected.POST("/auth/verify/email/send", rateLimiter.RateLimit(), authHandler.SendEmailVerification)
+			protected.POST("/auth/verify/email", rateLimiter.RateLimit(), authHandler.VerifyEmail)
+			protected.POST("/auth/verify/phone/send", rateLimiter.RateLimit(), authHandler.SendPhoneVerification)
+			protected.POST("/auth/verify/phone", rateLimiter.RateLimit(), authHandler.VerifyPhone)
+			protected.POST("/auth/step-up", rateLimiter.RateLimit(), authHandler.StepUp)
+			protected.POST("/auth/2fa/enroll", rateLimiter.RateLimit(), authHandler.EnrollTOTP)
+			protected.POST("/auth/2fa/confirm", rateLimiter.RateLimit(), authHandler.ConfirmTOTP)
+			protected.POST("/auth/2fa/disable", rateLimiter.RateLimit(), authHandler.DisableTOTP)
+
+			protected.GET("/kyc", rateLimiter.RateLimit(), kycHandler.GetStatus)
+			protected.POST("/kyc", rateLimiter.RateLimit(), kycHandler.Submit)
 
-			walletRepo := wallet.NewRepository(r.db)
-			walletService := wallet.NewService(walletRepo)
-			walletHandler := wallet.NewHandler(walletService)
+			protected.GET("/limits", rateLimiter.RateLimit(), limitsHandler.GetUsage)
 
 			protected.GET("/wallet", rateLimiter.RateLimit(), walletHandler.GetWallet)
 			protected.GET("/transaction", rateLimiter.RateLimit(), walletHandler.GetUserTransaction)
-			protected.POST("/wallet/topup", rateLimiter.RateLimit(), walletHandler.TopUp)
-			protected.POST("/wallet/buy", rateLimiter.RateLimit(), walletHandler.BuyGold)
-			protected.POST("/wallet/sell", rateLimiter.RateLimit(), walletHandler.SellGold)
+			verified := middleware.RequireVerified(authService)
+
+			protected.POST("/wallet/topup", rateLimiter.RateLimit(), verified, walletHandler.TopUp)
+			protected.POST("/wallet/buy", rateLimiter.RateLimit(), verified, walletHandler.BuyGold)
+			protected.POST("/wallet/sell", rateLimiter.RateLimit(), verified, walletHandler.SellGold)
 		}
 
 		admin := v1.Group("/admin")
-		admin.Use(middleware.JWTAuth(r.cfg))
-		admin.Use(middleware.AdminAuth(r.db))
+		admin.Use(middleware.JWTAuth(r.cfg, denylist))
 		{
-			authRepo := auth.NewRepository(r.db)
-			authService := auth.NewService(authRepo, r.cfg.JWTSecret)
-			authHandler := auth.NewHandler(authService)
+			auditHandler := audit.NewHandler(r.services.Audit)
+			rbacHandler := rbac.NewHandler(r.services.RBAC)
+
+			can := func(permission string) gin.HandlerFunc {
+				return middleware.RequirePermission(r.services.RBAC, permission)
+			}
+
+			admin.GET("/roles", rateLimiter.RateLimit(), can(rbac.PermRolesManage), rbacHandler.ListRoles)
+			admin.PUT("/roles/:name", rateLimiter.RateLimit(), can(rbac.PermRolesManage), rbacHandler.UpsertRole)
+			admin.DELETE("/roles/:name", rateLimiter.RateLimit(), can(rbac.PermRolesManage), rbacHandler.DeleteRole)
+			admin.PUT("/users/:user_id/role", rateLimiter.RateLimit(), can(rbac.PermRolesManage), rbacHandler.AssignRole)
+
+			admin.GET("/audit", rateLimiter.RateLimit(), can(rbac.PermAuditRead), auditHandler.List)
+
+			admin.GET("/kyc/queue", rateLimiter.RateLimit(), can(rbac.PermKYCReview), kycHandler.Queue)
+			admin.GET("/kyc/submissions/:id", rateLimiter.RateLimit(), can(rbac.PermKYCReview), kycHandler.GetSubmission)
+			admin.GET("/kyc/submissions/:id/documents/:document_id", rateLimiter.RateLimit(), can(rbac.PermKYCReview), kycHandler.GetDocument)
+			admin.POST("/kyc/submissions/:id/claim", rateLimiter.RateLimit(), can(rbac.PermKYCReview), kycHandler.Claim)
+			admin.POST("/kyc/submissions/:id/decision", rateLimiter.RateLimit(), can(rbac.PermKYCReview), kycHandler.Decide)
+
+			admin.GET("/limits", rateLimiter.RateLimit(), can(rbac.PermLimitsManage), limitsHandler.ListLimits)
+			admin.PUT("/limits/:tier/:operation", rateLimiter.RateLimit(), can(rbac.PermLimitsManage), limitsHandler.UpdateLimit)
+
+			admin.GET("/aml/rules", rateLimiter.RateLimit(), can(rbac.PermAMLManage), amlHandler.ListRules)
+			admin.PUT("/aml/rules/:name", rateLimiter.RateLimit(), can(rbac.PermAMLManage), amlHandler.UpdateRule)
+			admin.GET("/aml/cases", rateLimiter.RateLimit(), can(rbac.PermAMLManage), amlHandler.ListCases)
+			admin.GET("/aml/cases/:id", rateLimiter.RateLimit(), can(rbac.PermAMLManage), amlHandler.GetCase)
+			admin.POST("/aml/cases/:id/assign", rateLimiter.RateLimit(), can(rbac.PermAMLManage), amlHandler.Assign)
+			admin.POST("/aml/cases/:id/comments", rateLimiter.RateLimit(), can(rbac.PermAMLManage), amlHandler.Comment)
+			admin.POST("/aml/cases/:id/close", rateLimiter.RateLimit(), can(rbac.PermAMLManage), amlHandler.Close)
+
+			usersHandler := users.NewHandler(users.NewService(users.NewRepository(r.db), authService, r.services.RBAC, r.services.Audit))
+
+			admin.GET("/users", rateLimiter.RateLimit(), can(rbac.PermUsersManage), usersHandler.Search)
+			admin.GET("/users/:user_id", rateLimiter.RateLimit(), can(rbac.PermUsersManage), usersHandler.Get)
+			admin.POST("/users/:user_id/reveal", rateLimiter.RateLimit(), can(rbac.PermUsersManage), usersHandler.RevealPII)
+			admin.POST("/users/:user_id/logout", rateLimiter.RateLimit(), can(rbac.PermUsersManage), usersHandler.ForceLogout)
+			admin.POST("/users/:user_id/password-reset", rateLimiter.RateLimit(), can(rbac.PermUsersManage), usersHandler.TriggerPasswordReset)
+			admin.POST("/users/:user_id/deactivate", rateLimiter.RateLimit(), can(rbac.PermUsersManage), usersHandler.Deactivate)
+			admin.POST("/users/:user_id/reactivate", rateLimiter.RateLimit(), can(rbac.PermUsersManage), usersHandler.Reactivate)
+			admin.POST("/users/:user_id/unlock", rateLimiter.RateLimit(), can(rbac.PermUsersManage), authHandler.UnlockAccount)
+
+			admin.GET("/users/:user_id/wallet", rateLimiter.RateLimit(), can(rbac.PermWalletFreeze), walletHandler.GetHistory)
+			admin.POST("/users/:user_id/wallet/freeze", rateLimiter.RateLimit(), can(rbac.PermWalletFreeze), walletHandler.Freeze)
+			admin.POST("/users/:user_id/wallet/unfreeze", rateLimiter.RateLimit(), can(rbac.PermWalletFreeze), walletHandler.Unfreeze)
+			admin.POST("/users/:user_id/wallet/adjustments", rateLimiter.RateLimit(), can(rbac.PermWalletAdjust), walletHandler.RequestAdjustment)
+			admin.GET("/adjustments", rateLimiter.RateLimit(), can(rbac.PermWalletAdjust), walletHandler.ListAdjustments)
+			admin.POST("/adjustments/:id/approve", rateLimiter.RateLimit(), can(rbac.PermWalletAdjust), walletHandler.ApproveAdjustment)
+			admin.POST("/adjustments/:id/reject", rateLimiter.RateLimit(), can(rbac.PermWalletAdjust), walletHandler.RejectAdjustment)
+			admin.GET("/activity", rateLimiter.RateLimit(), can(rbac.PermAuditRead), walletHandler.Activity)
+
+			admin.POST("/partners", rateLimiter.RateLimit(), can(rbac.PermWebhooksManage), webhookHandler.CreatePartner)
+			admin.GET("/partners", rateLimiter.RateLimit(), can(rbac.PermWebhooksManage), webhookHandler.ListPartners)
+			admin.PUT("/partners/:id/users/:user_id", rateLimiter.RateLimit(), can(rbac.PermWebhooksManage), webhookHandler.AssignUser)
+			admin.DELETE("/partners/:id/users/:user_id", rateLimiter.RateLimit(), can(rbac.PermWebhooksManage), webhookHandler.RemoveUser)
+			admin.POST("/webhooks", rateLimiter.RateLimit(), can(rbac.PermWebhooksManage), webhookHandler.CreateSubscription)
+			admin.GET("/webhooks", rateLimiter.RateLimit(), can(rbac.PermWebhooksManage), webhookHandler.ListSubscriptions)
+			admin.PUT("/webhooks/:id", rateLimiter.RateLimit(), can(rbac.PermWebhooksManage), webhookHandler.UpdateSubscription)
+			admin.DELETE("/webhooks/:id", rateLimiter.RateLimit(), can(rbac.PermWebhooksManage), webhookHandler.DeleteSubscription)
+			admin.GET("/webhooks/:id/deliveries", rateLimiter.RateLimit(), can(rbac.PermWebhooksManage), webhookHandler.ListDeliveries)
+			admin.POST("/webhooks/:id/deliveries/:delivery_id/replay", rateLimiter.RateLimit(), can(rbac.PermWebhooksManage), webhookHandler.ReplayDelivery)
 
-			admin.PUT("/users/:user_id/kyc", rateLimiter.RateLimit(), authHandler.UpdateKYC)
+			reportsHandler := reports.NewHandler(r.services.Reports)
 
+			admin.GET("/reports", rateLimiter.RateLimit(), can(rbac.PermReportsRead), reportsHandler.List)
+			admin.GET("/reports/:name", rateLimiter.RateLimit(), can(rbac.PermReportsRead), reportsHandler.Get)
+
+			custodyHandler := custody.NewHandler(r.services.Custody)
+
+			admin.GET("/custody/bars", rateLimiter.RateLimit(), can(rbac.PermCustodyManage), custodyHandler.ListBars)
+			admin.POST("/custody/bars", rateLimiter.RateLimit(), can(rbac.PermCustodyManage), custodyHandler.ReceiveBar)
+			admin.POST("/custody/bars/:id/withdraw", rateLimiter.RateLimit(), can(rbac.PermCustodyManage), custodyHandler.WithdrawBar)
+			admin.POST("/custody/bars/:id/transfer", rateLimiter.RateLimit(), can(rbac.PermCustodyManage), custodyHandler.TransferBar)
+			admin.GET("/custody/movements", rateLimiter.RateLimit(), can(rbac.PermCustodyManage), custodyHandler.ListMovements)
+			admin.GET("/custody/coverage", rateLimiter.RateLimit(), can(rbac.PermCustodyManage), custodyHandler.ListChecks)
+			admin.GET("/reports/proof-of-reserves", rateLimiter.RateLimit(), can(rbac.PermReportsRead), custodyHandler.ProofOfReserves)
+
+			reconciliationHandler := reconciliation.NewHandler(r.services.Reconciliation)
+
+			admin.GET("/reconciliation/runs", rateLimiter.RateLimit(), can(rbac.PermReportsRead), reconciliationHandler.ListRuns)
+			admin.GET("/reconciliation/runs/:id", rateLimiter.RateLimit(), can(rbac.PermReportsRead), reconciliationHandler.GetRun)
+			admin.GET("/reconciliation/mismatches", rateLimiter.RateLimit(), can(rbac.PermReportsRead), reconciliationHandler.ListMismatches)
+
+			schedulerHandler := scheduler.NewHandler(r.services.Scheduler)
+
+			admin.GET("/jobs", rateLimiter.RateLimit(), can(rbac.PermJobsManage), schedulerHandler.ListJobs)
+			admin.GET("/jobs/:name/runs", rateLimiter.RateLimit(), can(rbac.PermJobsManage), schedulerHandler.ListRuns)
+			admin.POST("/jobs/:name/trigger", rateLimiter.RateLimit(), can(rbac.PermJobsManage), schedulerHandler.Trigger)
+			admin.POST("/jobs/:name/pause", rateLimiter.RateLimit(), can(rbac.PermJobsManage), schedulerHandler.Pause)
+			admin.POST("/jobs/:name/resume", rateLimiter.RateLimit(), can(rbac.PermJobsManage), schedulerHandler.Resume)
 		}
 	}
 }
 
-func (r *Router) Run(addr string) error {
-	return r.engine.Run(addr)
+func (r *Router) Server() *http.Server {
+	return r.server
+}
+
+// MetricsServer is the internal listener for Prometheus scrapes.
+func (r *Router) MetricsServer() *http.Server {
+	return r.metrics
+}
+
+// ListenAndServeMetrics serves /metrics on the internal listener until
+// Shutdown.
+func (r *Router) ListenAndServeMetrics() error {
+	if err := r.metrics.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// ListenAndServe marks the router ready and serves until Shutdown.
+func (r *Router) ListenAndServe() error {
+	r.ready.Store(true)
+	if err := r.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// SetReady(false) makes /readyz fail so load balancers stop sending
+// traffic before the server stops accepting it.
+func (r *Router) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests.
+func (r *Router) Shutdown(ctx context.Context) error {
+	r.ready.Store(false)
+	return errors.Join(r.server.Shutdown(ctx), r.metrics.Shutdown(ctx))
 }
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/internal/auth"
+	"github.com/919Umesh/gold_go/internal/otp"
+	"github.com/919Umesh/gold_go/internal/rbac"
+	"github.com/919Umesh/gold_go/internal/wallet"
+	"github.com/919Umesh/gold_go/pkg/clock"
+	"github.com/919Umesh/gold_go/pkg/notify"
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/919Umesh/gold_go/pkg/tokenstore"
+	"github.com/919Umesh/gold_go/pkg/uow"
+)
+
+// minOperatorPassword applies to passwords set from the command line, which
+// skip the API's validation.
+const minOperatorPassword = 12
+
+// createAdmin creates the first super admin. The password is read from
+// BOOTSTRAP_PASSWORD so it does not end up in shell history.
+func createAdmin(c *cli, args []string) error {
+	fs := c.flags("create-admin", "-email EMAIL -name NAME -phone PHONE (password in BOOTSTRAP_PASSWORD)")
+	fullName := fs.String("name", "", "full name of the super admin")
+	email := fs.String("email", "", "email of the super admin")
+	phone := fs.String("phone", "", "phone number of the super admin")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	password := os.Getenv("BOOTSTRAP_PASSWORD")
+	if *email == "" {
+		return usagef("-email is required")
+	}
+	if password != "" && len(password) < minOperatorPassword {
+		return usagef("BOOTSTRAP_PASSWORD must be at least %d characters; it is not needed to promote an existing account", minOperatorPassword)
+	}
+
+	_, db, err := connect()
+	if err != nil {
+		return err
+	}
+	if err := config.Migrate(db); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	service := rbac.NewService(rbac.NewRepository(db), audit.NewService(audit.NewRepository(db)))
+	user, err := service.BootstrapSuperAdmin(context.Background(), *fullName, *email, *phone, password)
+	if err != nil {
+		return err
+	}
+
+	c.print(map[string]interface{}{"id": user.ID, "email": user.Email, "role": user.Role}, func(w io.Writer) {
+		fmt.Fprintf(w, "Super admin ready: id=%d email=%s\n", user.ID, user.Email)
+	})
+	return nil
+}
+
+// resetPassword reads the new password from NEW_PASSWORD for the same reason.
+func resetPassword(c *cli, args []string) error {
+	fs := c.flags("reset-password", "-user ID -actor ADMIN_ID (password in NEW_PASSWORD)")
+	userID := fs.Uint("user", 0, "id of the user whose password is replaced")
+	actorID := fs.Uint("actor", 0, "user id of the operator, recorded in the audit log")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if err := requireID("user", *userID); err != nil {
+		return err
+	}
+	if err := requireID("actor", *actorID); err != nil {
+		return err
+	}
+
+	password := os.Getenv("NEW_PASSWORD")
+	if len(password) < minOperatorPassword {
+		return usagef("NEW_PASSWORD must be at least %d characters", minOperatorPassword)
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	redisClient := redis.NewRedisClient(cfg.Redis.Address, cfg.Redis.Password, cfg.Redis.DB)
+	mailer := notify.NewEmailSender(cfg)
+	auditService := audit.NewService(audit.NewRepository(db))
+	authService := auth.NewService(
+		auth.NewRepository(db), cfg,
+		tokenstore.NewDenylist(redisClient), clock.System{},
+		otp.NewService(redisClient, mailer, notify.NewSMSSender(cfg), cfg),
+		auth.NewLoginThrottle(redisClient, mailer, cfg),
+		auditService,
+	)
+
+	if err := authService.SetPassword(context.Background(), uint(*actorID), uint(*userID), password); err != nil {
+		return err
+	}
+
+	c.print(map[string]interface{}{"user_id": *userID, "sessions_revoked": true}, func(w io.Writer) {
+		fmt.Fprintf(w, "Password replaced for user %d; all sessions ended\n", *userID)
+	})
+	return nil
+}
+
+func freezeWallet(c *cli, args []string) error {
+	fs := c.flags("freeze-wallet", "-user ID -actor ADMIN_ID -reason TEXT [-unfreeze]")
+	userID := fs.Uint("user", 0, "id of the wallet owner")
+	actorID := fs.Uint("actor", 0, "user id of the operator, recorded in the audit log")
+	reason := fs.String("reason", "", "why the wallet is frozen or released")
+	unfreeze := fs.Bool("unfreeze", false, "release a frozen wallet instead")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if err := requireID("user", *userID); err != nil {
+		return err
+	}
+	if err := requireID("actor", *actorID); err != nil {
+		return err
+	}
+	if *reason == "" {
+		return usagef("-reason is required")
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	auditService := audit.NewService(audit.NewRepository(db))
+	walletService := wallet.NewService(
+		wallet.NewRepository(db),
+		uow.New(db, uow.Options{MaxAttempts: cfg.DB.TxMaxAttempts}),
+		nil, cfg, nil, nil, auditService,
+	)
+
+	freeze, verb := walletService.Freeze, "frozen"
+	if *unfreeze {
+		freeze, verb = walletService.Unfreeze, "unfrozen"
+	}
+	if err := freeze(context.Background(), uint(*actorID), uint(*userID), *reason); err != nil {
+		return err
+	}
+
+	c.print(map[string]interface{}{"user_id": *userID, "locked": !*unfreeze}, func(w io.Writer) {
+		fmt.Fprintf(w, "Wallet of user %d %s\n", *userID, verb)
+	})
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/919Umesh/gold_go/internal/audit"
+)
+
+// verifyAuditChain exits with exitCheckFailed when an entry was edited or
+// removed.
+func verifyAuditChain(c *cli, args []string) error {
+	fs := c.flags("verify-audit-chain", "")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	_, db, err := connect()
+	if err != nil {
+		return err
+	}
+	report, err := audit.NewService(audit.NewRepository(db)).Verify(context.Background())
+	if err != nil {
+		return err
+	}
+
+	c.print(report, func(w io.Writer) {
+		if report.Valid {
+			fmt.Fprintf(w, "Audit chain intact: %d entries verified\n", report.Entries)
+			return
+		}
+		fmt.Fprintf(w, "Audit chain broken at entry %d after %d valid entries: %s\n", report.BrokenAt, report.Entries, report.Reason)
+	})
+	if !report.Valid {
+		return errCheckFailed
+	}
+	return nil
+}
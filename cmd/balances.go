@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/internal/reconciliation"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/uow"
+)
+
+// recomputeBalances runs the reconciliation now and exits with
+// exitCheckFailed when wallets disagree with their history. With -fix it
+// closes one mismatch by posting an adjustment transaction.
+func recomputeBalances(c *cli, args []string) error {
+	fs := c.flags("recompute-balances", "[-fix MISMATCH_ID -actor ADMIN_ID]")
+	fix := fs.Uint("fix", 0, "id of the balance mismatch to resolve")
+	actorID := fs.Uint("actor", 0, "user id of the operator resolving the mismatch, recorded in the audit log")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if *fix != 0 {
+		if err := requireID("actor", *actorID); err != nil {
+			return err
+		}
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	unitOfWork := uow.New(db, uow.Options{MaxAttempts: cfg.DB.TxMaxAttempts})
+	service := reconciliation.NewService(reconciliation.NewRepository(db), unitOfWork, audit.NewService(audit.NewRepository(db)))
+
+	if *fix != 0 {
+		mismatch, transaction, err := service.Resolve(context.Background(), uint(*actorID), uint(*fix))
+		if err != nil {
+			return err
+		}
+		c.print(map[string]interface{}{"mismatch": mismatch, "transaction": transaction}, func(w io.Writer) {
+			if transaction == nil {
+				fmt.Fprintf(w, "Mismatch %d for user %d no longer exists; marked resolved without an adjustment\n", mismatch.ID, mismatch.UserID)
+				return
+			}
+			fmt.Fprintf(w, "Mismatch %d resolved: transaction %d posted %.2f NPR and %.4f g for user %d\n",
+				mismatch.ID, transaction.ID, transaction.Amount, transaction.GoldGrams, mismatch.UserID)
+		})
+		return nil
+	}
+
+	run, err := service.Run(context.Background(), time.Now())
+	if err != nil {
+		return err
+	}
+
+	mismatches, err := service.ListMismatches(context.Background(), reconciliation.MismatchFilter{RunID: run.ID, Status: models.MismatchOpen, Limit: 500})
+	if err != nil {
+		return err
+	}
+
+	c.print(map[string]interface{}{"run": run, "mismatches": mismatches}, func(w io.Writer) {
+		fmt.Fprintf(w, "Run %d: %d wallets checked, %d mismatches\n", run.ID, run.WalletsChecked, run.Mismatches)
+		for _, m := range mismatches {
+			fmt.Fprintf(w, "  #%d user %d: fiat %.2f (ledger %.2f, diff %+.2f), gold %.4f (ledger %.4f, diff %+.4f)\n",
+				m.ID, m.UserID, m.WalletFiat, m.ExpectedFiat, m.FiatDiff, m.WalletGold, m.ExpectedGold, m.GoldDiff)
+		}
+	})
+	if run.Mismatches > 0 {
+		return errCheckFailed
+	}
+	return nil
+}
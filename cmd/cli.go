@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/pkg/logging"
+	"gorm.io/gorm"
+)
+
+// Exit codes are part of the contract with runbooks; keep them stable.
+const (
+	exitOK          = 0
+	exitFailure     = 1
+	exitUsage       = 2
+	exitCheckFailed = 3
+)
+
+// errCheckFailed is returned by commands that completed but found a problem,
+// such as balance mismatches or a broken audit chain. The result has already
+// been printed.
+var errCheckFailed = errors.New("check failed")
+
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+func usagef(format string, args ...interface{}) error {
+	return &usageError{message: fmt.Sprintf(format, args...)}
+}
+
+// cli holds the flags every command shares.
+type cli struct {
+	json   bool
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (c *cli) flags(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.BoolVar(&c.json, "json", false, "print the result as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse turns flag errors into usage errors so they exit with exitUsage.
+func (c *cli) parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return &usageError{message: err.Error()}
+	}
+	return nil
+}
+
+// print writes v as JSON with --json, otherwise the text form.
+func (c *cli) print(v interface{}, text func(w io.Writer)) {
+	if c.json {
+		encoder := json.NewEncoder(c.stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(v); err != nil {
+			slog.Error("cli: encode output failed", "error", err)
+		}
+		return
+	}
+	text(c.stdout)
+}
+
+// exit reports err the same way for every command and returns the exit code.
+func (c *cli) exit(err error) int {
+	var usage *usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errCheckFailed):
+		return exitCheckFailed
+	case errors.As(err, &usage):
+		c.report(err)
+		return exitUsage
+	default:
+		c.report(err)
+		return exitFailure
+	}
+}
+
+func (c *cli) report(err error) {
+	if c.json {
+		json.NewEncoder(c.stdout).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	fmt.Fprintf(c.stderr, "Error: %v\n", err)
+}
+
+// connect loads the configuration named by CONFIG_FILE, sets up logging and
+// opens the database. Logs go to stderr, keeping stdout for the command's
+// result; failures are returned so c.exit reports them, as JSON with --json.
+func connect() (*config.Config, *gorm.DB, error) {
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid logging config: %w", err)
+	}
+	slog.SetDefault(logger)
+	db, err := config.ConnectDatabase(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, db, nil
+}
+
+func requireID(name string, value uint) error {
+	if value == 0 {
+		return usagef("-%s is required", name)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/919Umesh/gold_go/config"
+	"gopkg.in/yaml.v3"
+)
+
+// configCommand prints the effective configuration after the file, profile
+// and environment layers are applied. It prints even an invalid config, then
+// fails with the validation errors, so the output shows what to fix.
+func configCommand(c *cli, args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return usagef("config needs print")
+	}
+
+	fs := c.flags("config print", "[-redacted=false] [-file PATH]")
+	redacted := fs.Bool("redacted", true, "hide secrets; -redacted=false shows them")
+	file := fs.String("file", os.Getenv("CONFIG_FILE"), "config file to load, defaults to $CONFIG_FILE")
+	if err := c.parse(fs, args[1:]); err != nil {
+		return err
+	}
+
+	cfg, err := config.Read(*file)
+	if err != nil {
+		return err
+	}
+	invalid := cfg.Validate()
+	if *redacted {
+		cfg = cfg.Redacted()
+	}
+
+	out, err := yaml.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	var tree map[string]interface{}
+	if err := yaml.Unmarshal(out, &tree); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	c.print(tree, func(w io.Writer) {
+		w.Write(out)
+	})
+
+	if invalid != nil {
+		return fmt.Errorf("invalid configuration: %w", invalid)
+	}
+	return nil
+}
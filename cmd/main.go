@@ -1,58 +1,68 @@
 package main
 
 import (
-	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
+	"strings"
+	"text/tabwriter"
 
-	"github.com/919Umesh/gold_go/api"
-	"github.com/919Umesh/gold_go/config"
-	"github.com/919Umesh/gold_go/internal/gold"
 	"github.com/joho/godotenv"
 )
 
-func main() {
-
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
-	}
+type command struct {
+	name    string
+	summary string
+	run     func(c *cli, args []string) error
+}
 
-	cfg := config.InitConfig()
+var commands = []command{
+	{"serve", "run the API server, scheduler and job workers (default)", serve},
+	{"config", "print the effective configuration, with secrets redacted", configCommand},
+	{"migrate", "apply, revert, list or create SQL migrations", migrateCommand},
+	{"create-admin", "create or promote the first super admin", createAdmin},
+	{"reset-password", "set a user's password and end their sessions", resetPassword},
+	{"freeze-wallet", "freeze or unfreeze a user's wallet", freezeWallet},
+	{"recompute-balances", "reconcile wallets with their transactions, or fix a mismatch", recomputeBalances},
+	{"import-prices", "load historical gold prices from CSV", importPrices},
+	{"export-users", "export users with masked contact details as CSV or JSON", exportUsers},
+	{"verify-audit-chain", "re-hash the audit log and report the first broken entry", verifyAuditChain},
+}
 
-	db := config.ConnectDatabase(cfg)
+// With no command the binary serves, so existing deployments keep working.
+func main() {
+	c := &cli{stdout: os.Stdout, stderr: os.Stderr}
 
-	if err := config.AutoMigrate(db); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		printCommands(c)
+		os.Exit(exitOK)
 	}
 
-	goldService := gold.NewService(db, cfg)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	go goldService.StartPriceUpdater(ctx)
-
-	router := api.NewRouter(db, cfg)
+	if err := godotenv.Load(); err != nil {
+		slog.Info("cli: no .env file found, using environment variables")
+	}
 
-	serverAddr := ":" + cfg.ServerPort
-	go func() {
-		log.Printf("Server starting on %s", serverAddr)
-		if err := router.Run(serverAddr); err != nil {
-			log.Fatalf("Failed to start server: %v", err)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			os.Exit(c.exit(cmd.run(c, args)))
 		}
-	}()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	log.Println("Shutting down server...")
+	}
 
-	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	fmt.Fprintf(c.stderr, "unknown command %q\n\n", name)
+	printCommands(c)
+	os.Exit(exitUsage)
+}
 
-	log.Println("Server exited")
+func printCommands(c *cli) {
+	fmt.Fprintln(c.stderr, "usage: gold_go COMMAND [flags]")
+	w := tabwriter.NewWriter(c.stderr, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	w.Flush()
+	fmt.Fprintln(c.stderr, "\nEvery command accepts --json. Exit codes: 0 ok, 1 failure, 2 usage, 3 check found problems.")
 }
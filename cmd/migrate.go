@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/919Umesh/gold_go/migrations"
+	"github.com/919Umesh/gold_go/pkg/migrate"
+)
+
+// migrateCommand runs the migrations embedded in this binary; create writes
+// new files to -dir for the next build.
+func migrateCommand(c *cli, args []string) error {
+	fs := c.flags("migrate", "[-dir DIR] up | down [N] | status | create NAME")
+	dir := fs.String("dir", "migrations", "directory that create writes new migrations to")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	args = fs.Args()
+	if len(args) == 0 {
+		return usagef("migrate needs up, down, status or create")
+	}
+
+	if args[0] == "create" {
+		if len(args) != 2 {
+			return usagef("usage: migrate create NAME")
+		}
+		paths, err := migrate.Create(*dir, args[1])
+		if err != nil {
+			return err
+		}
+		c.print(map[string][]string{"files": paths}, func(w io.Writer) {
+			for _, path := range paths {
+				fmt.Fprintln(w, path)
+			}
+		})
+		return nil
+	}
+
+	list, err := migrate.Load(migrations.FS)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+	_, db, err := connect()
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	migrator := migrate.New(sqlDB, list)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		c.print(map[string]int{"applied": applied}, func(w io.Writer) {
+			fmt.Fprintf(w, "Applied %d migrations\n", applied)
+		})
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return usagef("invalid step count %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		c.print(map[string]int{"reverted": reverted}, func(w io.Writer) {
+			fmt.Fprintf(w, "Reverted %d migrations\n", reverted)
+		})
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		c.print(statuses, func(w io.Writer) {
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+			for _, status := range statuses {
+				state, appliedAt := "pending", ""
+				if status.Applied {
+					state, appliedAt = "applied", status.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				if status.Modified {
+					state = "modified"
+				}
+				if status.Missing {
+					state = "missing file"
+				}
+				fmt.Fprintf(tw, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+			}
+			tw.Flush()
+		})
+	default:
+		return usagef("unknown migrate command %q", args[0])
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/919Umesh/gold_go/internal/gold"
+	"github.com/919Umesh/gold_go/models"
+)
+
+// importPrices reads a CSV with a header row naming at least updated_at and
+// price_per_gram, plus an optional source column. updated_at is RFC 3339 or
+// a plain date.
+func importPrices(c *cli, args []string) error {
+	fs := c.flags("import-prices", "-file PRICES.csv")
+	file := fs.String("file", "", "CSV file to import, or - for stdin")
+	source := fs.String("source", "import", "source recorded for rows without one")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return usagef("-file is required")
+	}
+
+	input := io.Reader(os.Stdin)
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	prices, err := readPrices(input, *source)
+	if err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	imported, err := gold.NewService(db, cfg).ImportPrices(prices)
+	if err != nil {
+		return err
+	}
+
+	c.print(map[string]int{"imported": imported}, func(w io.Writer) {
+		fmt.Fprintf(w, "Imported %d gold prices\n", imported)
+	})
+	return nil
+}
+
+func readPrices(input io.Reader, defaultSource string) ([]models.GoldPrice, error) {
+	reader := csv.NewReader(input)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	timeColumn, okTime := columns["updated_at"]
+	priceColumn, okPrice := columns["price_per_gram"]
+	if !okTime || !okPrice {
+		return nil, errors.New("header must include updated_at and price_per_gram")
+	}
+	sourceColumn, hasSource := columns["source"]
+
+	var prices []models.GoldPrice
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return prices, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		updatedAt, err := parsePriceTime(record[timeColumn])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid updated_at %q", line, record[timeColumn])
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(record[priceColumn]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid price_per_gram %q", line, record[priceColumn])
+		}
+		rowSource := defaultSource
+		if hasSource && strings.TrimSpace(record[sourceColumn]) != "" {
+			rowSource = strings.TrimSpace(record[sourceColumn])
+		}
+
+		prices = append(prices, models.GoldPrice{PricePerGram: price, Source: rowSource, UpdatedAt: updatedAt})
+	}
+}
+
+func parsePriceTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/919Umesh/gold_go/api"
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/aml"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/internal/custody"
+	"github.com/919Umesh/gold_go/internal/gold"
+	"github.com/919Umesh/gold_go/internal/kyc"
+	"github.com/919Umesh/gold_go/internal/limits"
+	"github.com/919Umesh/gold_go/internal/rbac"
+	"github.com/919Umesh/gold_go/internal/reconciliation"
+	"github.com/919Umesh/gold_go/internal/reports"
+	"github.com/919Umesh/gold_go/internal/scheduler"
+	"github.com/919Umesh/gold_go/internal/wallet"
+	"github.com/919Umesh/gold_go/internal/webhook"
+	"github.com/919Umesh/gold_go/pkg/blobstore"
+	"github.com/919Umesh/gold_go/pkg/lifecycle"
+	"github.com/919Umesh/gold_go/pkg/notify"
+	"github.com/919Umesh/gold_go/pkg/queue"
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/919Umesh/gold_go/pkg/tracing"
+	"github.com/919Umesh/gold_go/pkg/uow"
+)
+
+func serve(c *cli, args []string) error {
+	fs := c.flags("serve", "")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: "gold_go",
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+
+	if err := config.Migrate(db); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	auditService := audit.NewService(audit.NewRepository(db))
+	unitOfWork := uow.New(db, uow.Options{MaxAttempts: cfg.DB.TxMaxAttempts})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rbacService := rbac.NewService(rbac.NewRepository(db), auditService)
+	if err := rbacService.EnsureDefaultRoles(ctx); err != nil {
+		return fmt.Errorf("failed to create default roles: %w", err)
+	}
+
+	goldService := gold.NewService(db, cfg)
+
+	webhookService := webhook.NewService(webhook.NewRepository(db), cfg)
+	dispatchCtx, stopDispatcher := context.WithCancel(ctx)
+	defer stopDispatcher()
+	dispatcherDone := make(chan struct{})
+	go func() {
+		webhookService.StartDispatcher(dispatchCtx)
+		close(dispatcherDone)
+	}()
+
+	redisClient := redis.NewRedisClient(cfg.Redis.Address, cfg.Redis.Password, cfg.Redis.DB)
+
+	limitsService := limits.NewService(limits.NewRepository(db), redisClient, auditService, cfg)
+	if err := limitsService.EnsureDefaults(ctx); err != nil {
+		return fmt.Errorf("failed to create default limits: %w", err)
+	}
+
+	jobQueue := newJobQueue(cfg, redisClient)
+
+	amlService := aml.NewService(aml.NewRepository(db), jobQueue, auditService, cfg)
+	if err := amlService.EnsureDefaultRules(ctx); err != nil {
+		return fmt.Errorf("failed to create default aml rules: %w", err)
+	}
+	jobQueue.Register(aml.JobEvaluate, amlService.Evaluate)
+	jobQueue.Start()
+
+	walletService := wallet.NewService(wallet.NewRepository(db), unitOfWork, goldService, cfg,
+		wallet.Publishers{webhookService, amlService}, limitsService, auditService)
+
+	jobScheduler := scheduler.NewService(scheduler.NewRepository(db), scheduler.NewRedisLocker(redisClient))
+	if err := jobScheduler.Register(ctx, scheduler.Job{
+		Name:       "gold-price-update",
+		Schedule:   "*/10 * * * *",
+		CatchUp:    scheduler.CatchUpOnce,
+		Timeout:    time.Minute,
+		RunOnStart: true,
+		Run:        goldService.UpdatePrice,
+	}); err != nil {
+		return fmt.Errorf("failed to register scheduled jobs: %w", err)
+	}
+	blobStore, err := blobstore.NewLocalStore(cfg.KYC.BlobStoreDir)
+	if err != nil {
+		return fmt.Errorf("failed to open blob store: %w", err)
+	}
+	kycService := kyc.NewService(kyc.NewRepository(db), unitOfWork, blobStore, auditService, cfg)
+	if err := jobScheduler.Register(ctx, scheduler.Job{
+		Name:     "kyc-document-retention",
+		Schedule: "30 2 * * *",
+		CatchUp:  scheduler.CatchUpOnce,
+		Timeout:  30 * time.Minute,
+		Run:      kycService.PurgeExpiredDocuments,
+	}); err != nil {
+		return fmt.Errorf("failed to register scheduled jobs: %w", err)
+	}
+	reportService := reports.NewService(reports.NewRepository(db), cfg)
+	if err := jobScheduler.Register(ctx, scheduler.Job{
+		Name:     "daily-report-snapshot",
+		Schedule: "CRON_TZ=" + reportService.Location().String() + " 15 0 * * *",
+		CatchUp:  scheduler.CatchUpOnce,
+		Timeout:  30 * time.Minute,
+		Run:      reportService.BuildSnapshots,
+	}); err != nil {
+		return fmt.Errorf("failed to register scheduled jobs: %w", err)
+	}
+	custodyService := custody.NewService(custody.NewRepository(db), auditService, notify.NewEmailSender(cfg), cfg)
+	if err := jobScheduler.Register(ctx, scheduler.Job{
+		Name:     "custody-coverage-check",
+		Schedule: "5 * * * *",
+		CatchUp:  scheduler.CatchUpOnce,
+		Timeout:  5 * time.Minute,
+		Run:      custodyService.CheckCoverage,
+	}); err != nil {
+		return fmt.Errorf("failed to register scheduled jobs: %w", err)
+	}
+	reconciliationService := reconciliation.NewService(reconciliation.NewRepository(db), unitOfWork, auditService)
+	if err := jobScheduler.Register(ctx, scheduler.Job{
+		Name:     "balance-reconciliation",
+		Schedule: "0 3 * * *",
+		CatchUp:  scheduler.CatchUpOnce,
+		Timeout:  30 * time.Minute,
+		Run: func(ctx context.Context, scheduledFor time.Time) error {
+			_, err := reconciliationService.Run(ctx, scheduledFor)
+			return err
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to register scheduled jobs: %w", err)
+	}
+	go jobScheduler.Start(ctx)
+
+	router, err := api.NewRouter(db, cfg, redisClient, api.Services{
+		Audit:          auditService,
+		RBAC:           rbacService,
+		Gold:           goldService,
+		Webhooks:       webhookService,
+		Limits:         limitsService,
+		AML:            amlService,
+		Wallet:         walletService,
+		KYC:            kycService,
+		Reports:        reportService,
+		Custody:        custodyService,
+		Reconciliation: reconciliationService,
+		Scheduler:      jobScheduler,
+		Queue:          jobQueue,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Stopped in this order: traffic first, then whatever requests may
+	// still hand work to, then the connections everything shares.
+	shutdown := lifecycle.New()
+	shutdown.OnStop("readiness", func(ctx context.Context) error {
+		router.SetReady(false)
+		select {
+		case <-time.After(time.Duration(cfg.Server.ShutdownDrainSeconds) * time.Second):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	shutdown.OnStop("http server", router.Shutdown)
+	shutdown.OnStop("scheduler", jobScheduler.Stop)
+	shutdown.OnStop("webhook dispatcher", func(ctx context.Context) error {
+		stopDispatcher()
+		select {
+		case <-dispatcherDone:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	shutdown.OnStop("job queue", jobQueue.Stop)
+	shutdown.OnStop("redis", func(context.Context) error {
+		return redisClient.Close()
+	})
+	shutdown.OnStop("database", func(context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.Close()
+	})
+	shutdown.OnStop("tracing", shutdownTracing)
+
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("serve: server starting", "addr", router.Server().Addr)
+		serverErr <- router.ListenAndServe()
+	}()
+	go func() {
+		slog.Info("serve: metrics listening", "addr", router.MetricsServer().Addr)
+		if err := router.ListenAndServeMetrics(); err != nil {
+			serverErr <- fmt.Errorf("metrics listener: %w", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	var serveErr error
+	select {
+	case <-quit:
+	case serveErr = <-serverErr:
+		slog.Error("serve: server stopped unexpectedly", "error", serveErr)
+	}
+
+	slog.Info("serve: shutting down")
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeoutSeconds)*time.Second)
+	defer cancelShutdown()
+	err = shutdown.Shutdown(shutdownCtx)
+
+	slog.Info("serve: server exited")
+	return errors.Join(serveErr, err)
+}
+
+func newJobQueue(cfg *config.Config, redisClient *redis.Client) *queue.Queue {
+	var backend queue.Backend
+	if cfg.Jobs.Backend == "memory" {
+		backend = queue.NewMemoryBackend()
+	} else {
+		backend = queue.NewRedisBackend(redisClient, "jobs")
+	}
+
+	return queue.New(backend, queue.Options{
+		Workers:            cfg.Jobs.Workers,
+		Visibility:         time.Duration(cfg.Jobs.VisibilitySeconds) * time.Second,
+		DefaultMaxAttempts: cfg.Jobs.MaxAttempts,
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/internal/users"
+)
+
+// exportUsers pages through the admin user search, so contact details come
+// out masked exactly as they appear in the admin API.
+func exportUsers(c *cli, args []string) error {
+	fs := c.flags("export-users", "[-kyc-status S] [-role R] [-status active|deactivated]")
+	kycStatus := fs.String("kyc-status", "", "only users with this KYC status")
+	role := fs.String("role", "", "only users with this role")
+	status := fs.String("status", "", "only users in this account state")
+	if err := c.parse(fs, args); err != nil {
+		return err
+	}
+
+	_, db, err := connect()
+	if err != nil {
+		return err
+	}
+	service := users.NewService(users.NewRepository(db), nil, nil, audit.NewService(audit.NewRepository(db)))
+
+	filter := users.SearchFilter{KYCStatus: *kycStatus, Role: *role, Status: *status, PageSize: 100}
+	var all []users.Summary
+	for filter.Page = 1; ; filter.Page++ {
+		result, err := service.Search(context.Background(), filter)
+		if err != nil {
+			return err
+		}
+		all = append(all, result.Users...)
+		if len(result.Users) < result.PageSize {
+			break
+		}
+	}
+	if all == nil {
+		all = []users.Summary{}
+	}
+
+	var writeErr error
+	c.print(all, func(w io.Writer) {
+		writeErr = writeUsersCSV(w, all)
+	})
+	return writeErr
+}
+
+func writeUsersCSV(w io.Writer, rows []users.Summary) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "full_name", "email", "phone", "kyc_status", "role", "email_verified", "phone_verified", "locked_until", "deactivated_at", "fiat_balance", "gold_grams", "created_at"})
+	for _, user := range rows {
+		writer.Write([]string{
+			strconv.FormatUint(uint64(user.ID), 10),
+			user.FullName,
+			user.Email,
+			user.Phone,
+			user.KYCStatus,
+			user.Role,
+			strconv.FormatBool(user.EmailVerified),
+			strconv.FormatBool(user.PhoneVerified),
+			formatOptionalTime(user.LockedUntil),
+			formatOptionalTime(user.DeactivatedAt),
+			strconv.FormatFloat(user.FiatBalance, 'f', 2, 64),
+			strconv.FormatFloat(user.GoldGrams, 'f', 4, 64),
+			user.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %w", err)
+	}
+	return nil
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -1,65 +1,160 @@
 package config
 
-import (
-	"os"
-	"strconv"
-	"sync"
-)
-
+// Config is loaded in layers: the defaults in the struct tags, then the file
+// named by CONFIG_FILE and its per-environment profile, then environment
+// variables. Fields tagged secret can also be read from the file named by
+// <ENV>_FILE, for example JWT_SECRET_FILE.
 type Config struct {
-	DBHost        string
-	DBUser        string
-	DBPassword    string
-	DBName        string
-	DBPort        string
-	ServerPort    string
-	JWTSecret     string
-	GoldProvider  string
-	WorkerCount   int
-	QueueSize     int
-	RedisAddress  string
-	RedisPassword string
-	RedisDB       int
-}
-
-var (
-	configInstance *Config
-	configOnce     sync.Once
-)
-
-func InitConfig() *Config {
-	configOnce.Do(func() {
-		configInstance = &Config{
-			DBHost:        getEnv("DB_HOST", "localhost"),
-			DBUser:        getEnv("DB_USER", "postgres"),
-			DBPassword:    getEnv("DB_PASSWORD", "postgres"),
-			DBName:        getEnv("DB_NAME", "gold_invest"),
-			DBPort:        getEnv("DB_PORT", "5432"),
-			ServerPort:    getEnv("PORT", "8080"),
-			JWTSecret:     getEnv("JWT_SECRET", "supersecretjwt"),
-			GoldProvider:  getEnv("GOLD_PROVIDER_URL", "http://localhost:9000"),
-			WorkerCount:   getEnvAsInt("WORKER_COUNT", 5),
-			QueueSize:     getEnvAsInt("QUEUE_SIZE", 100),
-			RedisAddress:  getEnv("REDIS_ADDRESS", "localhost:6379"),
-			RedisPassword: getEnv("REDIS_PASSWORD", ""),
-			RedisDB:       getEnvAsInt("REDIS_DB", 0),
-		}
-	})
-	return configInstance
-}
-
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
+	Environment string `yaml:"environment" toml:"environment" env:"APP_ENV" default:"development"`
+
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	DB       DBConfig       `yaml:"db" toml:"db"`
+	Redis    RedisConfig    `yaml:"redis" toml:"redis"`
+	Auth     AuthConfig     `yaml:"auth" toml:"auth"`
+	OTP      OTPConfig      `yaml:"otp" toml:"otp"`
+	Notify   NotifyConfig   `yaml:"notify" toml:"notify"`
+	Pricing  PricingConfig  `yaml:"pricing" toml:"pricing"`
+	Limits   LimitsConfig   `yaml:"limits" toml:"limits"`
+	Fees     FeesConfig     `yaml:"fees" toml:"fees"`
+	Jobs     JobsConfig     `yaml:"jobs" toml:"jobs"`
+	Webhooks WebhooksConfig `yaml:"webhooks" toml:"webhooks"`
+	KYC      KYCConfig      `yaml:"kyc" toml:"kyc"`
+	AML      AMLConfig      `yaml:"aml" toml:"aml"`
+	Reports  ReportsConfig  `yaml:"reports" toml:"reports"`
+	Custody  CustodyConfig  `yaml:"custody" toml:"custody"`
+	Log      LogConfig      `yaml:"log" toml:"log"`
+	Tracing  TracingConfig  `yaml:"tracing" toml:"tracing"`
+}
+
+type ServerConfig struct {
+	Port                   string `yaml:"port" toml:"port" env:"PORT" default:"8080"`
+	MetricsPort            string `yaml:"metrics_port" toml:"metrics_port" env:"METRICS_PORT" default:"9090"`
+	ReadTimeoutSeconds     int    `yaml:"read_timeout_seconds" toml:"read_timeout_seconds" env:"HTTP_READ_TIMEOUT_SECONDS" default:"15"`
+	WriteTimeoutSeconds    int    `yaml:"write_timeout_seconds" toml:"write_timeout_seconds" env:"HTTP_WRITE_TIMEOUT_SECONDS" default:"30"`
+	IdleTimeoutSeconds     int    `yaml:"idle_timeout_seconds" toml:"idle_timeout_seconds" env:"HTTP_IDLE_TIMEOUT_SECONDS" default:"60"`
+	ShutdownDrainSeconds   int    `yaml:"shutdown_drain_seconds" toml:"shutdown_drain_seconds" env:"SHUTDOWN_DRAIN_SECONDS" default:"5"`
+	ShutdownTimeoutSeconds int    `yaml:"shutdown_timeout_seconds" toml:"shutdown_timeout_seconds" env:"SHUTDOWN_TIMEOUT_SECONDS" default:"30"`
+	HealthCacheSeconds     int    `yaml:"health_cache_seconds" toml:"health_cache_seconds" env:"HEALTH_CACHE_SECONDS" default:"2"`
+}
+
+type DBConfig struct {
+	Host            string `yaml:"host" toml:"host" env:"DB_HOST" default:"localhost"`
+	Port            string `yaml:"port" toml:"port" env:"DB_PORT" default:"5432"`
+	User            string `yaml:"user" toml:"user" env:"DB_USER" default:"postgres"`
+	Password        string `yaml:"password" toml:"password" env:"DB_PASSWORD" default:"postgres" secret:"true"`
+	Name            string `yaml:"name" toml:"name" env:"DB_NAME" default:"gold_invest"`
+	TxMaxAttempts   int    `yaml:"tx_max_attempts" toml:"tx_max_attempts" env:"TX_MAX_ATTEMPTS" default:"3"`
+	SlowQueryMillis int    `yaml:"slow_query_ms" toml:"slow_query_ms" env:"SLOW_QUERY_MS" default:"200"`
+}
+
+type RedisConfig struct {
+	Address  string `yaml:"address" toml:"address" env:"REDIS_ADDRESS" default:"localhost:6379"`
+	Password string `yaml:"password" toml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	DB       int    `yaml:"db" toml:"db" env:"REDIS_DB" default:"0"`
+}
+
+// AuthConfig covers tokens, two-factor and login throttling. Outside
+// production the TOTP key and the OTP secret fall back to the JWT secret when
+// unset.
+type AuthConfig struct {
+	JWTSecret                 string  `yaml:"jwt_secret" toml:"jwt_secret" env:"JWT_SECRET" default:"supersecretjwt" secret:"true"`
+	AccessTokenTTLMinutes     int     `yaml:"access_token_ttl_minutes" toml:"access_token_ttl_minutes" env:"ACCESS_TOKEN_TTL_MINUTES" default:"15"`
+	RefreshTokenTTLHours      int     `yaml:"refresh_token_ttl_hours" toml:"refresh_token_ttl_hours" env:"REFRESH_TOKEN_TTL_HOURS" default:"720"`
+	TOTPIssuer                string  `yaml:"totp_issuer" toml:"totp_issuer" env:"TOTP_ISSUER" default:"Gold Savings"`
+	TOTPEncryptionKey         string  `yaml:"totp_encryption_key" toml:"totp_encryption_key" env:"TOTP_ENCRYPTION_KEY" secret:"true"`
+	StepUpMaxAgeMinutes       int     `yaml:"step_up_max_age_minutes" toml:"step_up_max_age_minutes" env:"STEP_UP_MAX_AGE_MINUTES" default:"5"`
+	LargeSellThreshold        float64 `yaml:"large_sell_threshold" toml:"large_sell_threshold" env:"LARGE_SELL_THRESHOLD" default:"100000"`
+	LoginMaxFailures          int     `yaml:"login_max_failures" toml:"login_max_failures" env:"LOGIN_MAX_FAILURES" default:"10"`
+	LoginDelayAfterFailures   int     `yaml:"login_delay_after_failures" toml:"login_delay_after_failures" env:"LOGIN_DELAY_AFTER_FAILURES" default:"3"`
+	LoginFailureWindowMinutes int     `yaml:"login_failure_window_minutes" toml:"login_failure_window_minutes" env:"LOGIN_FAILURE_WINDOW_MINUTES" default:"15"`
+	LoginLockoutMinutes       int     `yaml:"login_lockout_minutes" toml:"login_lockout_minutes" env:"LOGIN_LOCKOUT_MINUTES" default:"15"`
+}
+
+type OTPConfig struct {
+	Secret          string `yaml:"secret" toml:"secret" env:"OTP_SECRET" secret:"true"`
+	TTLMinutes      int    `yaml:"ttl_minutes" toml:"ttl_minutes" env:"OTP_TTL_MINUTES" default:"10"`
+	MaxAttempts     int    `yaml:"max_attempts" toml:"max_attempts" env:"OTP_MAX_ATTEMPTS" default:"5"`
+	MaxSendsPerHour int    `yaml:"max_sends_per_hour" toml:"max_sends_per_hour" env:"OTP_MAX_SENDS_PER_HOUR" default:"5"`
+}
+
+type NotifyConfig struct {
+	SMTPHost     string `yaml:"smtp_host" toml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" toml:"smtp_port" env:"SMTP_PORT" default:"587"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	SMTPFrom     string `yaml:"smtp_from" toml:"smtp_from" env:"SMTP_FROM" default:"no-reply@localhost"`
+	LogFile      string `yaml:"log_file" toml:"log_file" env:"NOTIFY_LOG_FILE"`
+}
+
+type PricingConfig struct {
+	Source        string `yaml:"source" toml:"source" env:"GOLD_PRICE_SOURCE" default:"mock"`
+	ProviderURL   string `yaml:"provider_url" toml:"provider_url" env:"GOLD_PROVIDER_URL" default:"http://localhost:9000"`
+	MaxAgeMinutes int    `yaml:"max_age_minutes" toml:"max_age_minutes" env:"GOLD_PRICE_MAX_AGE_MINUTES" default:"30"`
+	// QuoteTolerancePercent is how far the server price may have moved
+	// against the user from the price they were shown before a trade is
+	// refused. Replicas and the response cache can each be a minute behind.
+	QuoteTolerancePercent float64 `yaml:"quote_tolerance_percent" toml:"quote_tolerance_percent" env:"QUOTE_TOLERANCE_PERCENT" default:"0.5"`
+}
+
+// LimitsConfig holds the day boundary and the withdrawal caps seeded for
+// verified users; other tiers cannot withdraw. Seeded limits are only
+// inserted when missing, and are edited through the admin API afterwards.
+type LimitsConfig struct {
+	Timezone                  string  `yaml:"timezone" toml:"timezone" env:"LIMITS_TIMEZONE" default:"Asia/Kathmandu"`
+	WithdrawPerTransactionNPR float64 `yaml:"withdraw_per_transaction_npr" toml:"withdraw_per_transaction_npr" env:"WITHDRAW_PER_TRANSACTION_NPR" default:"200000"`
+	WithdrawDailyNPR          float64 `yaml:"withdraw_daily_npr" toml:"withdraw_daily_npr" env:"WITHDRAW_DAILY_NPR" default:"500000"`
+	WithdrawMonthlyNPR        float64 `yaml:"withdraw_monthly_npr" toml:"withdraw_monthly_npr" env:"WITHDRAW_MONTHLY_NPR" default:"2000000"`
+}
+
+// FeesConfig is the share of a trade's value the platform keeps, in percent.
+// A buy costs the gold's value plus the fee; a sell pays out the value less
+// the fee.
+type FeesConfig struct {
+	BuyPercent  float64 `yaml:"buy_percent" toml:"buy_percent" env:"BUY_FEE_PERCENT" default:"0"`
+	SellPercent float64 `yaml:"sell_percent" toml:"sell_percent" env:"SELL_FEE_PERCENT" default:"0"`
+}
+
+type JobsConfig struct {
+	Backend           string `yaml:"backend" toml:"backend" env:"QUEUE_BACKEND" default:"redis"`
+	Workers           int    `yaml:"workers" toml:"workers" env:"WORKER_COUNT" default:"5"`
+	QueueSize         int    `yaml:"queue_size" toml:"queue_size" env:"QUEUE_SIZE" default:"100"`
+	VisibilitySeconds int    `yaml:"visibility_seconds" toml:"visibility_seconds" env:"QUEUE_VISIBILITY_SECONDS" default:"300"`
+	MaxAttempts       int    `yaml:"max_attempts" toml:"max_attempts" env:"QUEUE_MAX_ATTEMPTS" default:"5"`
+	BacklogLimit      int    `yaml:"backlog_limit" toml:"backlog_limit" env:"QUEUE_BACKLOG_LIMIT" default:"1000"`
+}
+
+type WebhooksConfig struct {
+	MaxAttempts    int `yaml:"max_attempts" toml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" default:"8"`
+	TimeoutSeconds int `yaml:"timeout_seconds" toml:"timeout_seconds" env:"WEBHOOK_TIMEOUT_SECONDS" default:"10"`
+}
+
+type KYCConfig struct {
+	BlobStoreDir          string `yaml:"blob_store_dir" toml:"blob_store_dir" env:"BLOB_STORE_DIR" default:"./data/blobs"`
+	MaxDocumentMB         int    `yaml:"max_document_mb" toml:"max_document_mb" env:"KYC_MAX_DOCUMENT_MB" default:"5"`
+	RejectedRetentionDays int    `yaml:"rejected_retention_days" toml:"rejected_retention_days" env:"KYC_REJECTED_RETENTION_DAYS" default:"90"`
+	VerifiedRetentionDays int    `yaml:"verified_retention_days" toml:"verified_retention_days" env:"KYC_VERIFIED_RETENTION_DAYS" default:"1825"`
+}
+
+type AMLConfig struct {
+	AutoFreezeSeverity int `yaml:"auto_freeze_severity" toml:"auto_freeze_severity" env:"AML_AUTO_FREEZE_SEVERITY" default:"0"`
+}
+
+type ReportsConfig struct {
+	Timezone              string `yaml:"timezone" toml:"timezone" env:"REPORT_TIMEZONE" default:"Asia/Kathmandu"`
+	ActiveSaverWindowDays int    `yaml:"active_saver_window_days" toml:"active_saver_window_days" env:"ACTIVE_SAVER_WINDOW_DAYS" default:"30"`
+}
+
+type CustodyConfig struct {
+	CoverageThreshold float64 `yaml:"coverage_threshold" toml:"coverage_threshold" env:"CUSTODY_COVERAGE_THRESHOLD" default:"1.0"`
+	AlertEmail        string  `yaml:"alert_email" toml:"alert_email" env:"CUSTODY_ALERT_EMAIL"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL" default:"info"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" default:"json"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" toml:"exporter" env:"TRACE_EXPORTER" default:"none"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACE_SAMPLE_RATIO" default:"1.0"`
 }
@@ -1,18 +1,23 @@
 package config
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
-	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/migrations"
+	"github.com/919Umesh/gold_go/pkg/migrate"
+	"github.com/919Umesh/gold_go/pkg/tracing"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
 var (
 	dbInstance *gorm.DB
+	dbErr      error
 	dbOnce     sync.Once
 )
 
@@ -20,40 +25,64 @@ type DB struct {
 	*gorm.DB
 }
 
-func ConnectDatabase(cfg *Config) *gorm.DB {
+func ConnectDatabase(cfg *Config) (*gorm.DB, error) {
 	dbOnce.Do(func() {
-		dsn := fmt.Sprintf(
-			"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-			cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort,
-		)
-
-		var err error
-		dbInstance, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-			PrepareStmt: true,
-		})
-		if err != nil {
-			log.Fatalf("Failed to connect to database: %v", err)
-		}
-
-		sqlDB, err := dbInstance.DB()
-		if err != nil {
-			log.Fatalf("Failed to get database instance: %v", err)
-		}
-
-		sqlDB.SetMaxIdleConns(10)
-		sqlDB.SetMaxOpenConns(100)
-		sqlDB.SetConnMaxLifetime(time.Hour)
-
-		log.Println("Database connected successfully")
+		dbInstance, dbErr = openDatabase(cfg)
 	})
-	return dbInstance
+	return dbInstance, dbErr
 }
 
-func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&models.User{},
-		&models.Wallet{},
-		&models.Transaction{},
-		&models.GoldPrice{},
+func openDatabase(cfg *Config) (*gorm.DB, error) {
+	dsn := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		cfg.DB.Host, cfg.DB.User, cfg.DB.Password, cfg.DB.Name, cfg.DB.Port,
 	)
+
+	// Queries are logged without their arguments, which carry emails,
+	// phone numbers and balances.
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		PrepareStmt: true,
+		Logger: logger.NewSlogLogger(slog.Default(), logger.Config{
+			SlowThreshold:             time.Duration(cfg.DB.SlowQueryMillis) * time.Millisecond,
+			LogLevel:                  logger.Warn,
+			IgnoreRecordNotFoundError: true,
+			ParameterizedQueries:      true,
+		}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+
+	slog.Info("config: database connected")
+	return db, nil
+}
+
+// Migrate applies the pending SQL migrations embedded in the binary.
+func Migrate(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	list, err := migrate.Load(migrations.FS)
+	if err != nil {
+		return err
+	}
+	applied, err := migrate.New(sqlDB, list).Up(context.Background())
+	if applied > 0 {
+		slog.Info("config: migrations applied", "count", applied)
+	}
+	return err
 }
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const redactedValue = "[REDACTED]"
+
+// Load reads the configuration and validates it. The returned config is
+// non-nil whenever the sources could be read, even if validation failed, so
+// callers can still show what was loaded.
+func Load(path string) (*Config, error) {
+	cfg, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	invalid := cfg.Validate()
+
+	// Validation has refused these fallbacks in production.
+	if cfg.Auth.TOTPEncryptionKey == "" {
+		cfg.Auth.TOTPEncryptionKey = cfg.Auth.JWTSecret
+	}
+	if cfg.OTP.Secret == "" {
+		cfg.OTP.Secret = cfg.Auth.JWTSecret
+	}
+	return cfg, invalid
+}
+
+// Read applies the defaults, the file at path (if any) and its profile, and
+// the environment, without validating the result.
+func Read(path string) (*Config, error) {
+	cfg := &Config{}
+	if err := walk(reflect.ValueOf(cfg).Elem(), setDefault); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		if err := decodeFile(path, cfg, true); err != nil {
+			return nil, err
+		}
+		env := os.Getenv("APP_ENV")
+		if env == "" {
+			env = cfg.Environment
+		}
+		if err := decodeFile(profilePath(path, env), cfg, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := walk(reflect.ValueOf(cfg).Elem(), setFromEnv); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Redacted returns a copy with every secret that is set replaced, for
+// printing the effective configuration.
+func (c *Config) Redacted() *Config {
+	out := *c
+	walk(reflect.ValueOf(&out).Elem(), func(field reflect.StructField, value reflect.Value) error {
+		if field.Tag.Get("secret") == "true" && value.String() != "" {
+			value.SetString(redactedValue)
+		}
+		return nil
+	})
+	return &out
+}
+
+// profilePath returns config.production.yaml for config.yaml.
+func profilePath(path, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// decodeFile merges the file at path into cfg. Unknown keys are rejected so
+// a typo does not silently fall back to a default.
+func decodeFile(path string, cfg *Config, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(cfg); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("%s: unsupported config format, want .yaml, .yml or .toml", path)
+	}
+	return nil
+}
+
+// walk calls fn for every leaf field of v, descending into the sections.
+func walk(v reflect.Value, fn func(reflect.StructField, reflect.Value) error) error {
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if value.Kind() == reflect.Struct {
+			if err := walk(value, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(field, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setDefault(field reflect.StructField, value reflect.Value) error {
+	def, ok := field.Tag.Lookup("default")
+	if !ok {
+		return nil
+	}
+	if err := setValue(value, def); err != nil {
+		return fmt.Errorf("default for %s: %w", field.Name, err)
+	}
+	return nil
+}
+
+// setFromEnv applies the field's environment variable. Secrets may instead
+// name a file in <ENV>_FILE, as mounted by Docker or Kubernetes secrets.
+func setFromEnv(field reflect.StructField, value reflect.Value) error {
+	key := field.Tag.Get("env")
+	if key == "" {
+		return nil
+	}
+
+	raw := os.Getenv(key)
+	if file := os.Getenv(key + "_FILE"); file != "" && field.Tag.Get("secret") == "true" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("%s_FILE: %w", key, err)
+		}
+		raw = strings.TrimRight(string(data), "\r\n")
+	}
+	if raw == "" {
+		return nil
+	}
+	if err := setValue(value, raw); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+func setValue(value reflect.Value, raw string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		value.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		value.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field kind %s", value.Kind())
+	}
+	return nil
+}
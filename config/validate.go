@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Defaults that are fine on a laptop but must never reach production.
+const (
+	defaultJWTSecret  = "supersecretjwt"
+	defaultDBPassword = "postgres"
+	minSecretLength   = 32
+)
+
+// Validate reports every problem at once, so a broken deployment is fixed in
+// one round instead of one restart per mistake.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	oneOf := func(name, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		fail("%s: %q is not one of %s", name, value, strings.Join(allowed, ", "))
+	}
+	positive := func(name string, value int) {
+		if value <= 0 {
+			fail("%s: must be positive, got %d", name, value)
+		}
+	}
+	timezone := func(name, value string) {
+		if _, err := time.LoadLocation(value); err != nil {
+			fail("%s: unknown timezone %q", name, value)
+		}
+	}
+
+	oneOf("environment", c.Environment, "development", "staging", "production")
+	if c.Auth.JWTSecret == "" {
+		fail("auth.jwt_secret: must be set")
+	}
+	if c.Environment == "production" {
+		if c.Auth.JWTSecret == defaultJWTSecret {
+			fail("auth.jwt_secret: the default secret is not allowed in production")
+		} else if c.Auth.JWTSecret != "" && len(c.Auth.JWTSecret) < minSecretLength {
+			fail("auth.jwt_secret: must be at least %d characters in production", minSecretLength)
+		}
+		// A leaked JWT secret must not also decrypt TOTP seeds or forge
+		// one-time codes.
+		distinctSecret := func(name, value string) {
+			if value == "" {
+				fail("%s: must be set in production", name)
+			} else if value == c.Auth.JWTSecret {
+				fail("%s: must differ from auth.jwt_secret in production", name)
+			}
+		}
+		distinctSecret("auth.totp_encryption_key", c.Auth.TOTPEncryptionKey)
+		distinctSecret("otp.secret", c.OTP.Secret)
+		if c.DB.Password == defaultDBPassword {
+			fail("db.password: the default password is not allowed in production")
+		}
+		if c.Notify.SMTPHost == "" {
+			fail("notify.smtp_host: must be set in production; the log sender would drop every email")
+		}
+	}
+
+	if c.Server.Port == "" {
+		fail("server.port: must be set")
+	}
+	if c.Server.MetricsPort == "" {
+		fail("server.metrics_port: must be set")
+	} else if c.Server.MetricsPort == c.Server.Port {
+		fail("server.metrics_port: must differ from server.port so metrics stay off the public listener")
+	}
+	positive("server.read_timeout_seconds", c.Server.ReadTimeoutSeconds)
+	positive("server.write_timeout_seconds", c.Server.WriteTimeoutSeconds)
+	positive("server.idle_timeout_seconds", c.Server.IdleTimeoutSeconds)
+	positive("server.shutdown_timeout_seconds", c.Server.ShutdownTimeoutSeconds)
+	positive("db.tx_max_attempts", c.DB.TxMaxAttempts)
+	positive("auth.access_token_ttl_minutes", c.Auth.AccessTokenTTLMinutes)
+	positive("auth.refresh_token_ttl_hours", c.Auth.RefreshTokenTTLHours)
+	positive("otp.ttl_minutes", c.OTP.TTLMinutes)
+	positive("otp.max_attempts", c.OTP.MaxAttempts)
+
+	oneOf("pricing.source", c.Pricing.Source, "mock", "http")
+	positive("pricing.max_age_minutes", c.Pricing.MaxAgeMinutes)
+	if c.Pricing.QuoteTolerancePercent < 0 || c.Pricing.QuoteTolerancePercent >= 100 {
+		fail("pricing.quote_tolerance_percent: must be at least 0 and below 100, got %g", c.Pricing.QuoteTolerancePercent)
+	}
+	timezone("limits.timezone", c.Limits.Timezone)
+	withdrawCap := func(name string, value float64) {
+		if value < 0 {
+			fail("%s: cannot be negative, got %g", name, value)
+		}
+	}
+	withdrawCap("limits.withdraw_per_transaction_npr", c.Limits.WithdrawPerTransactionNPR)
+	withdrawCap("limits.withdraw_daily_npr", c.Limits.WithdrawDailyNPR)
+	withdrawCap("limits.withdraw_monthly_npr", c.Limits.WithdrawMonthlyNPR)
+	percent := func(name string, value float64) {
+		if value < 0 || value >= 100 {
+			fail("%s: must be at least 0 and below 100, got %g", name, value)
+		}
+	}
+	percent("fees.buy_percent", c.Fees.BuyPercent)
+	percent("fees.sell_percent", c.Fees.SellPercent)
+
+	oneOf("jobs.backend", c.Jobs.Backend, "redis", "memory")
+	positive("jobs.workers", c.Jobs.Workers)
+	positive("jobs.queue_size", c.Jobs.QueueSize)
+	positive("jobs.visibility_seconds", c.Jobs.VisibilitySeconds)
+	positive("jobs.max_attempts", c.Jobs.MaxAttempts)
+	positive("webhooks.max_attempts", c.Webhooks.MaxAttempts)
+	positive("webhooks.timeout_seconds", c.Webhooks.TimeoutSeconds)
+	positive("kyc.max_document_mb", c.KYC.MaxDocumentMB)
+
+	timezone("reports.timezone", c.Reports.Timezone)
+	if c.Custody.CoverageThreshold <= 0 {
+		fail("custody.coverage_threshold: must be positive, got %g", c.Custody.CoverageThreshold)
+	}
+
+	oneOf("log.level", strings.ToLower(c.Log.Level), "debug", "info", "warn", "error")
+	oneOf("log.format", strings.ToLower(c.Log.Format), "json", "text")
+	oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "otlp")
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		fail("tracing.sample_ratio: must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+	}
+
+	return errors.Join(errs...)
+}
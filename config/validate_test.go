@@ -0,0 +1,116 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// productionConfig returns the defaults with every production requirement
+// met, so each test can break exactly one.
+func productionConfig(t *testing.T) *Config {
+	t.Helper()
+	t.Setenv("APP_ENV", "production")
+	cfg, err := Read("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Auth.JWTSecret = strings.Repeat("j", minSecretLength)
+	cfg.Auth.TOTPEncryptionKey = strings.Repeat("t", minSecretLength)
+	cfg.OTP.Secret = strings.Repeat("o", minSecretLength)
+	cfg.DB.Password = "a-real-password"
+	cfg.Notify.SMTPHost = "smtp.example.com"
+	return cfg
+}
+
+func TestValidateAcceptsCompleteProductionConfig(t *testing.T) {
+	if err := productionConfig(t).Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateRequiresSMTPInProduction(t *testing.T) {
+	cfg := productionConfig(t)
+	cfg.Notify.SMTPHost = ""
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "notify.smtp_host") {
+		t.Fatalf("err = %v, want a notify.smtp_host error", err)
+	}
+
+	cfg.Environment = "development"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("development without SMTP: %v", err)
+	}
+}
+
+func TestValidateRequiresSeparateSecretsInProduction(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		set  func(*Config, string)
+	}{
+		{"auth.totp_encryption_key", func(c *Config, v string) { c.Auth.TOTPEncryptionKey = v }},
+		{"otp.secret", func(c *Config, v string) { c.OTP.Secret = v }},
+	} {
+		cfg := productionConfig(t)
+		tc.set(cfg, "")
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tc.name+": must be set") {
+			t.Fatalf("%s unset: err = %v", tc.name, err)
+		}
+
+		tc.set(cfg, cfg.Auth.JWTSecret)
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tc.name+": must differ from auth.jwt_secret") {
+			t.Fatalf("%s equal to the JWT secret: err = %v", tc.name, err)
+		}
+
+		cfg.Environment = "development"
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("%s shared in development: %v", tc.name, err)
+		}
+	}
+}
+
+func TestLoadFallsBackToTheJWTSecretOutsideProduction(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("JWT_SECRET", "dev-secret")
+	t.Setenv("TOTP_ENCRYPTION_KEY", "")
+	t.Setenv("OTP_SECRET", "")
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Auth.TOTPEncryptionKey != "dev-secret" || cfg.OTP.Secret != "dev-secret" {
+		t.Fatalf("totp key %q, otp secret %q, want both to fall back to the JWT secret", cfg.Auth.TOTPEncryptionKey, cfg.OTP.Secret)
+	}
+
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("JWT_SECRET", strings.Repeat("j", minSecretLength))
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "auth.totp_encryption_key: must be set") {
+		t.Fatalf("production without a TOTP key: err = %v", err)
+	}
+}
+
+func TestValidateRejectsFeesOutOfRange(t *testing.T) {
+	cfg := productionConfig(t)
+	cfg.Fees.BuyPercent = -1
+	cfg.Fees.SellPercent = 100
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "fees.buy_percent") || !strings.Contains(err.Error(), "fees.sell_percent") {
+		t.Fatalf("err = %v, want fees.buy_percent and fees.sell_percent errors", err)
+	}
+
+	cfg.Fees.BuyPercent, cfg.Fees.SellPercent = 0, 2.5
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateRejectsNegativeWithdrawCaps(t *testing.T) {
+	cfg := productionConfig(t)
+	cfg.Limits.WithdrawDailyNPR = -1
+
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "limits.withdraw_daily_npr") {
+		t.Fatalf("err = %v, want a limits.withdraw_daily_npr error", err)
+	}
+}
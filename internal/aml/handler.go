@@ -0,0 +1,191 @@
+package aml
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+type UpdateRuleRequest struct {
+	Enabled          *bool              `json:"enabled"`
+	Severity         *int               `json:"severity" binding:"omitempty,min=1,max=100"`
+	TransactionTypes []string           `json:"transaction_types" binding:"omitempty,dive,oneof=topup buy sell refund adjustment"`
+	Params           map[string]float64 `json:"params"`
+}
+
+type AssignRequest struct {
+	AssigneeID uint `json:"assignee_id" binding:"required"`
+}
+
+type CommentRequest struct {
+	Body string `json:"body" binding:"required,min=1,max=5000"`
+}
+
+type CloseRequest struct {
+	Resolution string `json:"resolution" binding:"required,oneof=false_positive no_action reported account_closed"`
+	Note       string `json:"note" binding:"max=5000"`
+}
+
+func (h *Handler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch aml rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+func (h *Handler) UpdateRule(c *gin.Context) {
+	var req UpdateRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.service.UpdateRule(c.Request.Context(), c.GetUint("user_id"), c.Param("name"), RuleUpdate{
+		Enabled:          req.Enabled,
+		Severity:         req.Severity,
+		TransactionTypes: req.TransactionTypes,
+		Params:           req.Params,
+	})
+	if err != nil {
+		writeError(c, err, "aml rule update failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "aml rule updated",
+		"rule":    rule,
+	})
+}
+
+func (h *Handler) ListCases(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	assigneeID, _ := strconv.ParseUint(c.Query("assignee_id"), 10, 32)
+	userID, _ := strconv.ParseUint(c.Query("user_id"), 10, 32)
+
+	cases, err := h.service.ListCases(c.Request.Context(), CaseFilter{
+		Status:     c.Query("status"),
+		AssigneeID: uint(assigneeID),
+		UserID:     uint(userID),
+		Limit:      limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch aml cases"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cases": cases})
+}
+
+func (h *Handler) GetCase(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	amlCase, err := h.service.GetCase(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, err, "failed to fetch aml case")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"case": amlCase})
+}
+
+func (h *Handler) Assign(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req AssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Assign(c.Request.Context(), c.GetUint("user_id"), id, req.AssigneeID); err != nil {
+		writeError(c, err, "aml case assignment failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "aml case assigned"})
+}
+
+func (h *Handler) Comment(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment, err := h.service.Comment(c.Request.Context(), c.GetUint("user_id"), id, req.Body)
+	if err != nil {
+		writeError(c, err, "failed to add comment")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"comment": comment})
+}
+
+func (h *Handler) Close(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req CloseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Close(c.Request.Context(), c.GetUint("user_id"), id, req.Resolution, req.Note); err != nil {
+		writeError(c, err, "aml case close failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "aml case closed"})
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid case id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrRuleNotFound), errors.Is(err, ErrCaseNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCaseClosed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
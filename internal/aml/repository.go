@@ -0,0 +1,210 @@
+package aml
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CaseFilter struct {
+	Status     string
+	AssigneeID uint
+	UserID     uint
+	Limit      int
+}
+
+type Repository interface {
+	ListRules(ctx context.Context) ([]models.AMLRule, error)
+	FindRule(ctx context.Context, name string) (*models.AMLRule, error)
+	SaveRule(ctx context.Context, rule *models.AMLRule) error
+	CreateMissingRules(ctx context.Context, rules []models.AMLRule) error
+
+	FindTransaction(ctx context.Context, id uint) (*models.Transaction, error)
+	TransactionsBetween(ctx context.Context, userID uint, from, to time.Time, excludeID uint) ([]models.Transaction, error)
+
+	HasAlerts(ctx context.Context, transactionID uint) (bool, error)
+	RecordAlerts(ctx context.Context, userID uint, alerts []models.AMLAlert, freezeAt int) (*models.AMLCase, bool, error)
+	FindCase(ctx context.Context, id uint) (*models.AMLCase, error)
+	ListCases(ctx context.Context, filter CaseFilter) ([]models.AMLCase, error)
+	UpdateCase(ctx context.Context, id uint, updates map[string]interface{}) (bool, error)
+	AddComment(ctx context.Context, comment *models.AMLCaseComment) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) ListRules(ctx context.Context) ([]models.AMLRule, error) {
+	var rules []models.AMLRule
+	err := r.db.WithContext(ctx).Order("name").Find(&rules).Error
+	return rules, err
+}
+
+func (r *repository) FindRule(ctx context.Context, name string) (*models.AMLRule, error) {
+	var rule models.AMLRule
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *repository) SaveRule(ctx context.Context, rule *models.AMLRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+func (r *repository) CreateMissingRules(ctx context.Context, rules []models.AMLRule) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&rules).Error
+}
+
+func (r *repository) FindTransaction(ctx context.Context, id uint) (*models.Transaction, error) {
+	var transaction models.Transaction
+	if err := r.db.WithContext(ctx).First(&transaction, id).Error; err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+func (r *repository) TransactionsBetween(ctx context.Context, userID uint, from, to time.Time, excludeID uint) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.WithContext(ctx).Where("user_id = ? AND created_at >= ? AND created_at <= ? AND id <> ? AND status = ?",
+		userID, from, to, excludeID, models.TransactionStatusSuccess).
+		Order("created_at").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *repository) HasAlerts(ctx context.Context, transactionID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.AMLAlert{}).Where("transaction_id = ?", transactionID).Count(&count).Error
+	return count > 0, err
+}
+
+// RecordAlerts attaches alerts to the user's open case, creating one if
+// needed, and recomputes its severity. Alerts already recorded for the same
+// rule and transaction are skipped, so a retried evaluation is harmless.
+// The severity is the sum of the highest score of each distinct rule, capped
+// at 100, so one rule firing again and again does not escalate a case.
+// When freezeAt is positive and the case reaches it, the wallet is frozen
+// and the case commented in the same transaction; the second result reports
+// whether this call froze it.
+func (r *repository) RecordAlerts(ctx context.Context, userID uint, alerts []models.AMLAlert, freezeAt int) (*models.AMLCase, bool, error) {
+	var amlCase models.AMLCase
+	frozen := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialise case creation per user.
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(727002, ?)", userID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("user_id = ? AND status <> ?", userID, models.AMLCaseClosed).
+			Order("id desc").
+			First(&amlCase).Error
+		if err == gorm.ErrRecordNotFound {
+			amlCase = models.AMLCase{UserID: userID, Status: models.AMLCaseOpen}
+			err = tx.Create(&amlCase).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		for i := range alerts {
+			alerts[i].CaseID = amlCase.ID
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&alerts).Error; err != nil {
+			return err
+		}
+
+		var severity int
+		if err := tx.Raw(`SELECT COALESCE(LEAST(SUM(max_severity), 100), 0) FROM (
+			SELECT MAX(severity) AS max_severity FROM aml_alerts WHERE case_id = ? GROUP BY rule_name
+		) per_rule`, amlCase.ID).Scan(&severity).Error; err != nil {
+			return err
+		}
+
+		amlCase.Severity = severity
+		if err := tx.Model(&amlCase).Update("severity", severity).Error; err != nil {
+			return err
+		}
+
+		if freezeAt <= 0 || severity < freezeAt {
+			return nil
+		}
+		if frozen, err = freezeWallet(tx, userID, freezeReason(&amlCase)); err != nil || !frozen {
+			return err
+		}
+		return tx.Create(&models.AMLCaseComment{
+			CaseID: amlCase.ID,
+			Body:   fmt.Sprintf("Wallet frozen automatically at severity %d.", severity),
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &amlCase, frozen, nil
+}
+
+func (r *repository) FindCase(ctx context.Context, id uint) (*models.AMLCase, error) {
+	var amlCase models.AMLCase
+	err := r.db.WithContext(ctx).Preload("Alerts", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Comments", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&amlCase, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &amlCase, nil
+}
+
+func (r *repository) ListCases(ctx context.Context, filter CaseFilter) ([]models.AMLCase, error) {
+	query := r.db.WithContext(ctx).Model(&models.AMLCase{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.AssigneeID != 0 {
+		query = query.Where("assignee_id = ?", filter.AssigneeID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	var cases []models.AMLCase
+	err := query.Order("severity desc, id").Limit(filter.Limit).Find(&cases).Error
+	return cases, err
+}
+
+// UpdateCase only changes cases that are not closed yet.
+func (r *repository) UpdateCase(ctx context.Context, id uint, updates map[string]interface{}) (bool, error) {
+	updates["updated_at"] = time.Now()
+	result := r.db.WithContext(ctx).Model(&models.AMLCase{}).
+		Where("id = ? AND status <> ?", id, models.AMLCaseClosed).
+		Updates(updates)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *repository) AddComment(ctx context.Context, comment *models.AMLCaseComment) error {
+	return r.db.WithContext(ctx).Create(comment).Error
+}
+
+// freezeWallet locks the user's wallet and records why, reporting false if
+// it was already locked.
+func freezeWallet(tx *gorm.DB, userID uint, reason string) (bool, error) {
+	result := tx.Model(&models.Wallet{}).
+		Where("user_id = ? AND locked = ?", userID, false).
+		Update("locked", true)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	err := tx.Create(&models.WalletEvent{
+		UserID: userID,
+		Type:   models.WalletEventFreeze,
+		Reason: reason,
+	}).Error
+	return err == nil, err
+}
@@ -0,0 +1,187 @@
+package aml
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+)
+
+var ruleTypes = map[string][]string{
+	models.AMLRuleThreshold:   {"min_amount", "min_grams"},
+	models.AMLRuleVelocity:    {"window_seconds", "max_count", "max_amount"},
+	models.AMLRuleStructuring: {"threshold", "band_pct", "min_count", "window_seconds"},
+	models.AMLRuleRoundTrip:   {"window_seconds", "min_ratio"},
+}
+
+var defaultRules = []models.AMLRule{
+	{
+		Name:        "large_transaction",
+		Type:        models.AMLRuleThreshold,
+		Description: "Single transaction at or above the reporting threshold",
+		Enabled:     true,
+		Severity:    40,
+		Params:      map[string]float64{"min_amount": 1000000},
+	},
+	{
+		Name:        "high_velocity",
+		Type:        models.AMLRuleVelocity,
+		Description: "Unusually many or large transactions within an hour",
+		Enabled:     true,
+		Severity:    30,
+		Params:      map[string]float64{"window_seconds": 3600, "max_count": 10, "max_amount": 2000000},
+	},
+	{
+		Name:        "structuring",
+		Type:        models.AMLRuleStructuring,
+		Description: "Repeated transactions just below the reporting threshold",
+		Enabled:     true,
+		Severity:    60,
+		Params:      map[string]float64{"threshold": 1000000, "band_pct": 0.1, "min_count": 3, "window_seconds": 86400},
+	},
+	{
+		Name:             "buy_sell_round_trip",
+		Type:             models.AMLRuleRoundTrip,
+		Description:      "Gold bought and sold again in similar quantity within a day",
+		Enabled:          true,
+		Severity:         50,
+		TransactionTypes: []string{string(models.TransactionTypeBuy), string(models.TransactionTypeSell)},
+		Params:           map[string]float64{"window_seconds": 86400, "min_ratio": 0.9},
+	},
+}
+
+// evaluate reports whether rule fires for tx. history holds the user's
+// earlier transactions inside the longest rule window, oldest first, and
+// does not include tx.
+func evaluate(rule models.AMLRule, tx models.Transaction, history []models.Transaction) (bool, map[string]interface{}) {
+	if !appliesTo(rule, tx) {
+		return false, nil
+	}
+	p := rule.Params
+
+	switch rule.Type {
+	case models.AMLRuleThreshold:
+		if p["min_amount"] > 0 && tx.Amount >= p["min_amount"] {
+			return true, map[string]interface{}{"amount": tx.Amount, "min_amount": p["min_amount"]}
+		}
+		if p["min_grams"] > 0 && tx.GoldGrams >= p["min_grams"] {
+			return true, map[string]interface{}{"grams": tx.GoldGrams, "min_grams": p["min_grams"]}
+		}
+
+	case models.AMLRuleVelocity:
+		window := inWindow(rule, history, tx.CreatedAt, p["window_seconds"])
+		count := len(window) + 1
+		total := tx.Amount
+		for _, earlier := range window {
+			total += earlier.Amount
+		}
+		if (p["max_count"] > 0 && float64(count) > p["max_count"]) || (p["max_amount"] > 0 && total > p["max_amount"]) {
+			return true, map[string]interface{}{"count": count, "total_amount": total, "window_seconds": p["window_seconds"]}
+		}
+
+	case models.AMLRuleStructuring:
+		low := p["threshold"] * (1 - p["band_pct"])
+		inBand := func(amount float64) bool { return amount >= low && amount < p["threshold"] }
+		if !inBand(tx.Amount) {
+			return false, nil
+		}
+		count := 1
+		total := tx.Amount
+		for _, earlier := range inWindow(rule, history, tx.CreatedAt, p["window_seconds"]) {
+			if inBand(earlier.Amount) {
+				count++
+				total += earlier.Amount
+			}
+		}
+		if float64(count) >= p["min_count"] {
+			return true, map[string]interface{}{"count": count, "total_amount": total, "threshold": p["threshold"]}
+		}
+
+	case models.AMLRuleRoundTrip:
+		opposite := models.TransactionTypeSell
+		if tx.Type == models.TransactionTypeSell {
+			opposite = models.TransactionTypeBuy
+		} else if tx.Type != models.TransactionTypeBuy {
+			return false, nil
+		}
+		for _, earlier := range inWindow(rule, history, tx.CreatedAt, p["window_seconds"]) {
+			if earlier.Type != opposite || earlier.GoldGrams <= 0 || tx.GoldGrams <= 0 {
+				continue
+			}
+			ratio := math.Min(earlier.GoldGrams, tx.GoldGrams) / math.Max(earlier.GoldGrams, tx.GoldGrams)
+			if ratio >= p["min_ratio"] {
+				return true, map[string]interface{}{
+					"opposite_transaction_id": earlier.ID,
+					"grams":                   tx.GoldGrams,
+					"opposite_grams":          earlier.GoldGrams,
+					"seconds_apart":           int(tx.CreatedAt.Sub(earlier.CreatedAt).Seconds()),
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+func appliesTo(rule models.AMLRule, tx models.Transaction) bool {
+	if len(rule.TransactionTypes) == 0 {
+		return true
+	}
+	for _, t := range rule.TransactionTypes {
+		if t == string(tx.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+func inWindow(rule models.AMLRule, history []models.Transaction, at time.Time, seconds float64) []models.Transaction {
+	since := at.Add(-time.Duration(seconds) * time.Second)
+	var window []models.Transaction
+	for _, earlier := range history {
+		if earlier.CreatedAt.Before(since) || earlier.CreatedAt.After(at) || !appliesTo(rule, earlier) {
+			continue
+		}
+		window = append(window, earlier)
+	}
+	return window
+}
+
+func validateRule(rule models.AMLRule) error {
+	allowed, ok := ruleTypes[rule.Type]
+	if !ok {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidRule, rule.Type)
+	}
+	if rule.Severity < 1 || rule.Severity > 100 {
+		return fmt.Errorf("%w: severity must be between 1 and 100", ErrInvalidRule)
+	}
+	for key, value := range rule.Params {
+		if !contains(allowed, key) {
+			return fmt.Errorf("%w: unknown param %q for %s rules", ErrInvalidRule, key, rule.Type)
+		}
+		if value < 0 {
+			return fmt.Errorf("%w: param %q cannot be negative", ErrInvalidRule, key)
+		}
+	}
+	return nil
+}
+
+// maxWindow is the longest look-back any enabled rule needs.
+func maxWindow(rules []models.AMLRule) time.Duration {
+	var longest float64
+	for _, rule := range rules {
+		if rule.Params["window_seconds"] > longest {
+			longest = rule.Params["window_seconds"]
+		}
+	}
+	return time.Duration(longest) * time.Second
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
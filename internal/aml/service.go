@@ -0,0 +1,295 @@
+package aml
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/queue"
+	"gorm.io/gorm"
+)
+
+// JobEvaluate is the queue job type that runs the rules for one transaction.
+const JobEvaluate = "aml.evaluate"
+
+var (
+	ErrRuleNotFound = errors.New("aml rule not found")
+	ErrCaseNotFound = errors.New("aml case not found")
+	ErrCaseClosed   = errors.New("aml case is closed")
+	ErrInvalidRule  = errors.New("invalid aml rule")
+)
+
+var Resolutions = []string{"false_positive", "no_action", "reported", "account_closed"}
+
+type evaluatePayload struct {
+	TransactionID uint `json:"transaction_id"`
+}
+
+type RuleUpdate struct {
+	Enabled          *bool
+	Severity         *int
+	TransactionTypes []string
+	Params           map[string]float64
+}
+
+type Service interface {
+	EnsureDefaultRules(ctx context.Context) error
+	Publish(ctx context.Context, eventType string, data interface{}) error
+	Evaluate(ctx context.Context, payload json.RawMessage) error
+
+	ListRules(ctx context.Context) ([]models.AMLRule, error)
+	UpdateRule(ctx context.Context, actorID uint, name string, update RuleUpdate) (*models.AMLRule, error)
+
+	ListCases(ctx context.Context, filter CaseFilter) ([]models.AMLCase, error)
+	GetCase(ctx context.Context, id uint) (*models.AMLCase, error)
+	Assign(ctx context.Context, actorID, caseID, assigneeID uint) error
+	Comment(ctx context.Context, actorID, caseID uint, body string) (*models.AMLCaseComment, error)
+	Close(ctx context.Context, actorID, caseID uint, resolution, note string) error
+}
+
+type service struct {
+	repo               Repository
+	queue              *queue.Queue
+	audit              audit.Service
+	autoFreezeSeverity int
+}
+
+func NewService(repo Repository, jobQueue *queue.Queue, auditService audit.Service, cfg *config.Config) Service {
+	return &service{
+		repo:               repo,
+		queue:              jobQueue,
+		audit:              auditService,
+		autoFreezeSeverity: cfg.AML.AutoFreezeSeverity,
+	}
+}
+
+func (s *service) EnsureDefaultRules(ctx context.Context) error {
+	return s.repo.CreateMissingRules(ctx, defaultRules)
+}
+
+// Publish queues every completed wallet transaction for monitoring. It is
+// wired into the wallet as an event publisher, next to webhooks.
+func (s *service) Publish(ctx context.Context, eventType string, data interface{}) error {
+	transaction, ok := data.(*models.Transaction)
+	if !ok || transaction.ID == 0 {
+		return nil
+	}
+	_, err := s.queue.Enqueue(ctx, JobEvaluate, evaluatePayload{TransactionID: transaction.ID})
+	return err
+}
+
+func (s *service) Evaluate(ctx context.Context, payload json.RawMessage) error {
+	var p evaluatePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return queue.Permanent(err)
+	}
+
+	transaction, err := s.repo.FindTransaction(ctx, p.TransactionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return queue.Permanent(fmt.Errorf("transaction %d not found", p.TransactionID))
+		}
+		return err
+	}
+
+	// Alerts and the freeze they trigger commit together, so a transaction
+	// with alerts has been fully handled and a redelivered job stops here.
+	evaluated, err := s.repo.HasAlerts(ctx, transaction.ID)
+	if err != nil {
+		return err
+	}
+	if evaluated {
+		return nil
+	}
+
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return err
+	}
+	var enabled []models.AMLRule
+	for _, rule := range rules {
+		if rule.Enabled {
+			enabled = append(enabled, rule)
+		}
+	}
+
+	history, err := s.repo.TransactionsBetween(ctx, transaction.UserID,
+		transaction.CreatedAt.Add(-maxWindow(enabled)), transaction.CreatedAt, transaction.ID)
+	if err != nil {
+		return err
+	}
+
+	var alerts []models.AMLAlert
+	for _, rule := range enabled {
+		hit, details := evaluate(rule, *transaction, history)
+		if !hit {
+			continue
+		}
+		encoded, _ := json.Marshal(details)
+		alerts = append(alerts, models.AMLAlert{
+			UserID:        transaction.UserID,
+			TransactionID: transaction.ID,
+			RuleName:      rule.Name,
+			Severity:      rule.Severity,
+			Details:       string(encoded),
+		})
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	amlCase, frozen, err := s.repo.RecordAlerts(ctx, transaction.UserID, alerts, s.autoFreezeSeverity)
+	if err != nil {
+		return fmt.Errorf("failed to record aml alerts: %w", err)
+	}
+	slog.InfoContext(ctx, "aml: case escalated", "case_id", amlCase.ID, "user_id", amlCase.UserID, "severity", amlCase.Severity)
+	if !frozen {
+		return nil
+	}
+
+	slog.WarnContext(ctx, "aml: wallet frozen", "user_id", amlCase.UserID, "case_id", amlCase.ID)
+	// The freeze is already committed and a retry would skip this
+	// transaction, so a failed audit write is logged instead of returned.
+	if err := s.audit.Record(ctx, 0, "wallet.freeze", "wallet", strconv.FormatUint(uint64(amlCase.UserID), 10), map[string]interface{}{
+		"reason":   freezeReason(amlCase),
+		"case_id":  amlCase.ID,
+		"severity": amlCase.Severity,
+	}); err != nil {
+		slog.ErrorContext(ctx, "aml: audit of wallet freeze failed", "user_id", amlCase.UserID, "case_id", amlCase.ID, "error", err)
+	}
+	return nil
+}
+
+func freezeReason(amlCase *models.AMLCase) string {
+	return fmt.Sprintf("AML case %d reached severity %d", amlCase.ID, amlCase.Severity)
+}
+
+func (s *service) ListRules(ctx context.Context) ([]models.AMLRule, error) {
+	return s.repo.ListRules(ctx)
+}
+
+func (s *service) UpdateRule(ctx context.Context, actorID uint, name string, update RuleUpdate) (*models.AMLRule, error) {
+	rule, err := s.repo.FindRule(ctx, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRuleNotFound
+		}
+		return nil, err
+	}
+	previous := *rule
+
+	if update.Enabled != nil {
+		rule.Enabled = *update.Enabled
+	}
+	if update.Severity != nil {
+		rule.Severity = *update.Severity
+	}
+	if update.TransactionTypes != nil {
+		rule.TransactionTypes = update.TransactionTypes
+	}
+	if update.Params != nil {
+		rule.Params = update.Params
+	}
+	if err := validateRule(*rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveRule(ctx, rule); err != nil {
+		return nil, fmt.Errorf("aml rule update failed: %w", err)
+	}
+	if err := s.audit.Record(ctx, actorID, "aml.rule_update", "aml_rule", name, map[string]interface{}{
+		"previous": previous,
+		"current":  rule,
+	}); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (s *service) ListCases(ctx context.Context, filter CaseFilter) ([]models.AMLCase, error) {
+	return s.repo.ListCases(ctx, filter)
+}
+
+func (s *service) GetCase(ctx context.Context, id uint) (*models.AMLCase, error) {
+	amlCase, err := s.repo.FindCase(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCaseNotFound
+		}
+		return nil, err
+	}
+	return amlCase, nil
+}
+
+func (s *service) Assign(ctx context.Context, actorID, caseID, assigneeID uint) error {
+	if err := s.updateOpenCase(ctx, caseID, map[string]interface{}{
+		"assignee_id": assigneeID,
+		"status":      models.AMLCaseInvestigating,
+	}); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, actorID, "aml.case_assign", "aml_case", strconv.FormatUint(uint64(caseID), 10), map[string]interface{}{
+		"assignee_id": assigneeID,
+	})
+}
+
+func (s *service) Comment(ctx context.Context, actorID, caseID uint, body string) (*models.AMLCaseComment, error) {
+	amlCase, err := s.GetCase(ctx, caseID)
+	if err != nil {
+		return nil, err
+	}
+	if amlCase.Status == models.AMLCaseClosed {
+		return nil, ErrCaseClosed
+	}
+
+	comment := &models.AMLCaseComment{CaseID: caseID, AuthorID: actorID, Body: body}
+	if err := s.repo.AddComment(ctx, comment); err != nil {
+		return nil, fmt.Errorf("failed to add comment: %w", err)
+	}
+	return comment, nil
+}
+
+// Close resolves a case. Closing does not unfreeze the wallet; that is a
+// separate, deliberate action.
+func (s *service) Close(ctx context.Context, actorID, caseID uint, resolution, note string) error {
+	now := time.Now()
+	if err := s.updateOpenCase(ctx, caseID, map[string]interface{}{
+		"status":     models.AMLCaseClosed,
+		"resolution": resolution,
+		"closed_by":  actorID,
+		"closed_at":  now,
+	}); err != nil {
+		return err
+	}
+
+	if note != "" {
+		if err := s.repo.AddComment(ctx, &models.AMLCaseComment{CaseID: caseID, AuthorID: actorID, Body: note}); err != nil {
+			return err
+		}
+	}
+	return s.audit.Record(ctx, actorID, "aml.case_close", "aml_case", strconv.FormatUint(uint64(caseID), 10), map[string]interface{}{
+		"resolution": resolution,
+		"note":       note,
+	})
+}
+
+func (s *service) updateOpenCase(ctx context.Context, caseID uint, updates map[string]interface{}) error {
+	updated, err := s.repo.UpdateCase(ctx, caseID, updates)
+	if err != nil {
+		return fmt.Errorf("aml case update failed: %w", err)
+	}
+	if updated {
+		return nil
+	}
+	if _, err := s.GetCase(ctx, caseID); err != nil {
+		return err
+	}
+	return ErrCaseClosed
+}
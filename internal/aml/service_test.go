@@ -0,0 +1,124 @@
+package aml
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/internal/wallet"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/logging"
+	"github.com/919Umesh/gold_go/pkg/queue"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type fakeRepository struct {
+	Repository
+	transaction models.Transaction
+	alerts      []models.AMLAlert
+	recorded    int
+}
+
+func (r *fakeRepository) FindTransaction(ctx context.Context, id uint) (*models.Transaction, error) {
+	transaction := r.transaction
+	return &transaction, nil
+}
+
+func (r *fakeRepository) ListRules(ctx context.Context) ([]models.AMLRule, error) {
+	return defaultRules, nil
+}
+
+func (r *fakeRepository) TransactionsBetween(ctx context.Context, userID uint, from, to time.Time, excludeID uint) ([]models.Transaction, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) HasAlerts(ctx context.Context, transactionID uint) (bool, error) {
+	for _, alert := range r.alerts {
+		if alert.TransactionID == transactionID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeRepository) RecordAlerts(ctx context.Context, userID uint, alerts []models.AMLAlert, freezeAt int) (*models.AMLCase, bool, error) {
+	r.recorded++
+	r.alerts = append(r.alerts, alerts...)
+	amlCase := &models.AMLCase{ID: 1, UserID: userID, Severity: alerts[0].Severity}
+	return amlCase, freezeAt > 0 && amlCase.Severity >= freezeAt, nil
+}
+
+type failingAudit struct {
+	audit.Service
+	records int
+}
+
+func (a *failingAudit) Record(ctx context.Context, actorID uint, action, targetType, targetID string, details interface{}) error {
+	a.records++
+	return errors.New("audit store unavailable")
+}
+
+func TestRedeliveredEvaluationIsSkipped(t *testing.T) {
+	repo := &fakeRepository{transaction: models.Transaction{
+		ID:        5,
+		UserID:    3,
+		Type:      models.TransactionTypeBuy,
+		Amount:    1500000,
+		CreatedAt: time.Now(),
+	}}
+	auditService := &failingAudit{}
+	svc := NewService(repo, nil, auditService, &config.Config{AML: config.AMLConfig{AutoFreezeSeverity: 40}})
+	payload, _ := json.Marshal(evaluatePayload{TransactionID: 5})
+
+	// The freeze is committed with the alerts, so a failed audit write must
+	// not send the job back to the queue.
+	if err := svc.Evaluate(context.Background(), payload); err != nil {
+		t.Fatalf("Evaluate = %v, want nil once alerts and the freeze are recorded", err)
+	}
+	if repo.recorded != 1 || len(repo.alerts) != 1 || repo.alerts[0].RuleName != "large_transaction" || auditService.records != 1 {
+		t.Fatalf("recorded %d times with alerts %+v and %d audit records, want one large_transaction alert and one freeze audit", repo.recorded, repo.alerts, auditService.records)
+	}
+
+	if err := svc.Evaluate(context.Background(), payload); err != nil {
+		t.Fatal(err)
+	}
+	if repo.recorded != 1 || auditService.records != 1 {
+		t.Fatalf("redelivery recorded %d times with %d audit records, want the transaction skipped", repo.recorded, auditService.records)
+	}
+}
+
+func TestPublishedTransactionsKeepTheRequestTrace(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	backend := queue.NewMemoryBackend()
+	svc := NewService(nil, queue.New(backend, queue.Options{}), nil, &config.Config{})
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := logging.WithRequestID(trace.ContextWithSpanContext(context.Background(), span), "req-7")
+
+	// The wallet publishes through its fan-out, next to webhooks.
+	publishers := wallet.Publishers{svc}
+	if err := publishers.Publish(ctx, models.WebhookEventBuy, &models.Transaction{ID: 9}); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := backend.Dequeue(context.Background(), time.Minute)
+	if err != nil || task == nil {
+		t.Fatalf("Dequeue = %v, %v, want the evaluate job", task, err)
+	}
+	want := "00-" + span.TraceID().String() + "-" + span.SpanID().String() + "-01"
+	if task.Type != JobEvaluate || task.TraceContext["traceparent"] != want || task.RequestID != "req-7" {
+		t.Fatalf("task = %+v, want %s carrying traceparent %s and request req-7", task, JobEvaluate, want)
+	}
+}
@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+)
+
+// chainLockKey serialises appends so each entry links to the one before it.
+const chainLockKey = 727001
+
+type Filter struct {
+	ActorID    uint
+	Action     string
+	TargetType string
+	TargetID   string
+	RequestID  string
+	Limit      int
+	BeforeID   uint
+}
+
+type Repository interface {
+	Append(ctx context.Context, entry *models.AuditLog, seal func(entry *models.AuditLog, prevHash string)) error
+	List(ctx context.Context, filter Filter) ([]models.AuditLog, error)
+	ListAfter(ctx context.Context, afterID uint, limit int) ([]models.AuditLog, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Append(ctx context.Context, entry *models.AuditLog, seal func(entry *models.AuditLog, prevHash string)) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", chainLockKey).Error; err != nil {
+			return err
+		}
+
+		var last models.AuditLog
+		prevHash := ""
+		err := tx.Order("id desc").Limit(1).Find(&last).Error
+		if err != nil {
+			return err
+		}
+		if last.ID != 0 {
+			prevHash = last.Hash
+		}
+
+		seal(entry, prevHash)
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *repository) List(ctx context.Context, filter Filter) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	query := r.db.WithContext(ctx).Model(&models.AuditLog{})
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	err := query.Order("id desc").Limit(filter.Limit).Find(&entries).Error
+	return entries, err
+}
+
+// ListAfter walks the chain oldest first.
+func (r *repository) ListAfter(ctx context.Context, afterID uint, limit int) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id asc").Limit(limit).Find(&entries).Error
+	return entries, err
+}
@@ -1,9 +1,14 @@
 package auth
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
 
+	"github.com/919Umesh/gold_go/internal/otp"
+	"github.com/919Umesh/gold_go/pkg/notify"
+	"github.com/919Umesh/gold_go/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,7 +24,6 @@ type RegisterRequest struct {
 	FullName string `json:"full_name" binding:"required,min=2,max=100"`
 	Email    string `json:"email" binding:"required,email"`
 	Phone    string `json:"phone" binding:"required,min=10,max=15"`
-	Role     string `json:"role" binding:"required,min=3,max=10"`
 	Password string `json:"password" binding:"required,min=6"`
 }
 
@@ -28,14 +32,49 @@ type UpdateProfileRequest struct {
 	Phone    string `json:"phone,omitempty" binding:"omitempty,min=10,max=15"`
 }
 
-type UpdateKYCAdmin struct {
-	KYCStatus string `json:"kyc_status" binding:"required,oneof=pending verified rejected under_review"`
-	Role      string `json:"role" binding:"required,oneof=user admin"`
+type LoginRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name,omitempty" binding:"omitempty,max=100"`
 }
 
-type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+	DeviceName   string `json:"device_name,omitempty" binding:"omitempty,max=100"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code,omitempty" binding:"omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code,omitempty" binding:"omitempty,max=20"`
+	DeviceName   string `json:"device_name,omitempty" binding:"omitempty,max=100"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type VerifyCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	Code        string `json:"code" binding:"required,len=6,numeric"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+type StepUpRequest struct {
+	Code     string `json:"code,omitempty" binding:"omitempty,len=6,numeric"`
+	Password string `json:"password,omitempty"`
 }
 
 func (h *Handler) Register(c *gin.Context) {
@@ -45,7 +84,7 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.Register(req.FullName, req.Email, req.Phone, req.Password, req.Role)
+	user, err := h.service.Register(c.Request.Context(), req.FullName, req.Email, req.Phone, req.Password)
 	if err != nil {
 		if err == ErrUserExists {
 			c.JSON(http.StatusConflict, gin.H{"error": "user already exists"})
@@ -71,19 +110,274 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.service.Login(req.Email, req.Password)
+	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password, deviceInfo(c, req.DeviceName))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		var lockout *LockoutError
+		switch {
+		case err == ErrInvalidCredentials:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		case err == ErrAccountDeactivated:
+			c.JSON(http.StatusForbidden, gin.H{"error": "account is deactivated"})
+		case errors.As(err, &lockout):
+			writeLockoutError(c, lockout)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "login failed"})
+		}
 		return
 	}
 
-	// Remove sensitive data
-	user.PasswordHash = ""
+	if result.MFAToken != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "two-factor code required",
+			"mfa_required": true,
+			"mfa_token":    result.MFAToken,
+		})
+		return
+	}
+
+	writeLoginResult(c, result)
+}
+
+func (h *Handler) LoginMFA(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery_code is required"})
+		return
+	}
+
+	result, err := h.service.CompleteMFALogin(c.Request.Context(), req.MFAToken, req.Code, req.RecoveryCode, deviceInfo(c, req.DeviceName))
+	if err != nil {
+		switch err {
+		case ErrInvalidMFAToken:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired mfa token, please log in again"})
+		case ErrInvalidTwoFactorCode, ErrTwoFactorNotEnabled:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid two-factor code, please log in again"})
+		case ErrAccountDeactivated:
+			c.JSON(http.StatusForbidden, gin.H{"error": "account is deactivated"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "login failed"})
+		}
+		return
+	}
+
+	writeLoginResult(c, result)
+}
+
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.service.Refresh(c.Request.Context(), req.RefreshToken, deviceInfo(c, req.DeviceName))
+	if err != nil {
+		switch err {
+		case ErrInvalidRefreshToken:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		case ErrRefreshTokenReused:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token reuse detected, please log in again"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "token refresh failed"})
+		}
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "login successful",
-		"token":   token,
-		"user":    user,
+		"message":            "token refreshed",
+		"token":              tokens.AccessToken,
+		"refresh_token":      tokens.RefreshToken,
+		"expires_in":         tokens.ExpiresIn,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
+	})
+}
+
+func (h *Handler) Logout(c *gin.Context) {
+	var req LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claims, _ := c.Get("claims")
+	accessClaims, _ := claims.(*utils.JWTClaims)
+
+	if err := h.service.Logout(c.Request.Context(), c.GetUint("user_id"), req.RefreshToken, accessClaims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "logout failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+func (h *Handler) LogoutAll(c *gin.Context) {
+	if err := h.service.LogoutAll(c.Request.Context(), c.GetUint("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "logout failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out of all devices"})
+}
+
+func (h *Handler) SendEmailVerification(c *gin.Context) {
+	if err := h.service.SendEmailVerification(c.Request.Context(), c.GetUint("user_id")); err != nil {
+		writeOTPError(c, err, "failed to send verification code")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "verification code sent"})
+}
+
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var req VerifyCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.VerifyEmail(c.Request.Context(), c.GetUint("user_id"), req.Code); err != nil {
+		writeOTPError(c, err, "email verification failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+func (h *Handler) SendPhoneVerification(c *gin.Context) {
+	if err := h.service.SendPhoneVerification(c.Request.Context(), c.GetUint("user_id")); err != nil {
+		writeOTPError(c, err, "failed to send verification code")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "verification code sent"})
+}
+
+func (h *Handler) VerifyPhone(c *gin.Context) {
+	var req VerifyCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.VerifyPhone(c.Request.Context(), c.GetUint("user_id"), req.Code); err != nil {
+		writeOTPError(c, err, "phone verification failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "phone verified"})
+}
+
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send reset code"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "if the account exists, a reset code has been sent"})
+}
+
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), req.Email, req.Code, req.NewPassword); err != nil {
+		writeOTPError(c, err, "password reset failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset, please log in again"})
+}
+
+func (h *Handler) EnrollTOTP(c *gin.Context) {
+	secret, uri, err := h.service.EnrollTOTP(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		if err == ErrTwoFactorAlreadyEnabled {
+			c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication already enabled"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "two-factor enrolment failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "scan the QR code, then confirm with a code from your app",
+		"secret":      secret,
+		"otpauth_uri": uri,
+	})
+}
+
+func (h *Handler) ConfirmTOTP(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(c.Request.Context(), c.GetUint("user_id"), req.Code)
+	if err != nil {
+		writeTwoFactorError(c, err, "two-factor confirmation failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "two-factor authentication enabled",
+		"recovery_codes": codes,
+	})
+}
+
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	var req TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.DisableTOTP(c.Request.Context(), c.GetUint("user_id"), req.Code); err != nil {
+		writeTwoFactorError(c, err, "two-factor removal failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+func (h *Handler) StepUp(c *gin.Context) {
+	var req StepUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, expiresIn, err := h.service.StepUp(c.Request.Context(), c.GetUint("user_id"), req.Code, req.Password)
+	if err != nil {
+		var lockout *LockoutError
+		switch {
+		case err == ErrInvalidCredentials:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		case errors.As(err, &lockout):
+			writeLockoutError(c, lockout)
+		default:
+			writeTwoFactorError(c, err, "step-up failed")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "step-up successful",
+		"token":      token,
+		"expires_in": expiresIn,
 	})
 }
 
@@ -94,7 +388,7 @@ func (h *Handler) GetProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.GetProfile(userID.(uint))
+	user, err := h.service.GetProfile(c.Request.Context(), userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
@@ -133,7 +427,7 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.UpdateProfile(userID.(uint), updates)
+	user, err := h.service.UpdateProfile(c.Request.Context(), userID.(uint), updates)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "profile update failed"})
@@ -147,30 +441,84 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 	})
 }
 
-func (h *Handler) UpdateKYC(c *gin.Context) {
-	userIDStr := c.Param("user_id")
-
-	userID, err := strconv.ParseUint(userIDStr, 10, 32)
+func (h *Handler) UnlockAccount(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id format"})
 		return
 	}
 
-	var request UpdateKYCAdmin
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := h.service.UnlockAccount(c.Request.Context(), c.GetUint("user_id"), uint(userID)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "account unlock failed"})
 		return
 	}
 
-	user, err := h.service.UpdateUserKYCStatus(uint(userID), request.KYCStatus, request.Role)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "KYC update failed"})
+	c.JSON(http.StatusOK, gin.H{"message": "account unlocked"})
+}
+
+func writeLockoutError(c *gin.Context, lockout *LockoutError) {
+	retryAfter := int(math.Ceil(lockout.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+
+	if lockout.Locked {
+		c.JSON(http.StatusLocked, gin.H{
+			"error":       "account temporarily locked after too many failed logins",
+			"retry_after": retryAfter,
+		})
 		return
 	}
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "too many failed logins, wait before trying again",
+		"retry_after": retryAfter,
+	})
+}
+
+func writeLoginResult(c *gin.Context, result *LoginResult) {
+	// Remove sensitive data
+	result.User.PasswordHash = ""
 
-	user.PasswordHash = ""
 	c.JSON(http.StatusOK, gin.H{
-		"message": "KYC status updated successfully",
-		"user":    user,
+		"message":            "login successful",
+		"token":              result.Tokens.AccessToken,
+		"refresh_token":      result.Tokens.RefreshToken,
+		"expires_in":         result.Tokens.ExpiresIn,
+		"refresh_expires_at": result.Tokens.RefreshExpiresAt,
+		"user":               result.User,
 	})
 }
+
+func writeTwoFactorError(c *gin.Context, err error, message string) {
+	switch err {
+	case ErrInvalidTwoFactorCode:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid two-factor code"})
+	case ErrTwoFactorNotEnabled:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "two-factor authentication not enabled"})
+	case ErrTwoFactorAlreadyEnabled:
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication already enabled"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func writeOTPError(c *gin.Context, err error, message string) {
+	switch err {
+	case otp.ErrTooManyRequests:
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case otp.ErrInvalidCode, otp.ErrCodeExpired, otp.ErrTooManyAttempts:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case notify.ErrSMSUnavailable:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case ErrAlreadyVerified:
+		c.JSON(http.StatusConflict, gin.H{"error": "already verified"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+func deviceInfo(c *gin.Context, name string) DeviceInfo {
+	return DeviceInfo{
+		Name:      name,
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
@@ -0,0 +1,199 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/notify"
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/919Umesh/gold_go/pkg/utils"
+)
+
+const maxLoginDelay = 5 * time.Minute
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// dummyPasswordHash is compared against when the email is unknown, so a
+// failed login takes as long whether or not the account exists.
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		hash, err := utils.HashPassword("not-a-real-password")
+		if err != nil {
+			slog.Error("auth: create dummy password hash failed", "error", err)
+		}
+		dummyHash = hash
+	})
+	return dummyHash
+}
+
+// LockoutError is returned by Login while an email is locked out or has to
+// wait before the next attempt.
+type LockoutError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("account locked, retry after %s", e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("too many failed logins, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginThrottle tracks failed logins per email in Redis, independent of the
+// client IP. After DelayAfter failures each further attempt must wait twice
+// as long as the previous one; after MaxFailures the email is locked out.
+// Unknown emails are tracked the same way so lockouts do not reveal which
+// accounts exist.
+type LoginThrottle struct {
+	redis      *redis.Client
+	mailer     notify.EmailSender
+	maxFails   int
+	delayAfter int
+	window     time.Duration
+	lockout    time.Duration
+}
+
+func NewLoginThrottle(redisClient *redis.Client, mailer notify.EmailSender, cfg *config.Config) *LoginThrottle {
+	return &LoginThrottle{
+		redis:      redisClient,
+		mailer:     mailer,
+		maxFails:   cfg.Auth.LoginMaxFailures,
+		delayAfter: cfg.Auth.LoginDelayAfterFailures,
+		window:     time.Duration(cfg.Auth.LoginFailureWindowMinutes) * time.Minute,
+		lockout:    time.Duration(cfg.Auth.LoginLockoutMinutes) * time.Minute,
+	}
+}
+
+func (t *LoginThrottle) Check(ctx context.Context, email string) error {
+	key := normalizeEmail(email)
+
+	ttl, err := t.redis.TTL(ctx, "auth:lockout:"+key)
+	if err != nil {
+		return err
+	}
+	if ttl > 0 {
+		return &LockoutError{Locked: true, RetryAfter: ttl}
+	}
+
+	ttl, err = t.redis.TTL(ctx, "auth:login_wait:"+key)
+	if err != nil {
+		return err
+	}
+	if ttl > 0 {
+		return &LockoutError{RetryAfter: ttl}
+	}
+	return nil
+}
+
+// RecordFailure counts a failed attempt and reports whether it caused a
+// lockout, along with when the lockout ends.
+func (t *LoginThrottle) RecordFailure(ctx context.Context, email string) (bool, time.Time, error) {
+	key := normalizeEmail(email)
+
+	failures, err := t.redis.IncrementWithExpiry(ctx, "auth:login_failures:"+key, t.window)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+
+	if int(failures) >= t.maxFails {
+		until := time.Now().Add(t.lockout)
+		if err := t.redis.Set(ctx, "auth:lockout:"+key, 1, t.lockout); err != nil {
+			return false, time.Time{}, err
+		}
+		if err := t.redis.Del(ctx, "auth:login_failures:"+key); err != nil {
+			return false, time.Time{}, err
+		}
+		return true, until, nil
+	}
+
+	if int(failures) >= t.delayAfter {
+		delay := time.Second << uint(int(failures)-t.delayAfter)
+		if delay > maxLoginDelay {
+			delay = maxLoginDelay
+		}
+		if err := t.redis.Set(ctx, "auth:login_wait:"+key, 1, delay); err != nil {
+			return false, time.Time{}, err
+		}
+	}
+	return false, time.Time{}, nil
+}
+
+func (t *LoginThrottle) Reset(ctx context.Context, email string) error {
+	key := normalizeEmail(email)
+	if err := t.redis.Del(ctx, "auth:login_failures:"+key); err != nil {
+		return err
+	}
+	return t.redis.Del(ctx, "auth:login_wait:"+key)
+}
+
+func (t *LoginThrottle) Unlock(ctx context.Context, email string) error {
+	if err := t.Reset(ctx, email); err != nil {
+		return err
+	}
+	return t.redis.Del(ctx, "auth:lockout:"+normalizeEmail(email))
+}
+
+func (t *LoginThrottle) NotifyLocked(ctx context.Context, email string, until time.Time) error {
+	body := fmt.Sprintf("Your account was locked after too many failed login attempts. "+
+		"You can try again after %s. If this was not you, reset your password "+
+		"and contact support.", until.UTC().Format("2006-01-02 15:04 MST"))
+	return t.mailer.SendEmail(ctx, email, "Your account has been locked", body)
+}
+
+func (s *service) loginFailed(ctx context.Context, email string, user *models.User) {
+	locked, until, err := s.throttle.RecordFailure(ctx, email)
+	if err != nil {
+		slog.ErrorContext(ctx, "auth: record login failure failed", "error", err)
+		return
+	}
+	if !locked || user == nil {
+		return
+	}
+
+	slog.WarnContext(ctx, "auth: user locked out after repeated failed logins", "user_id", user.ID, "until", until)
+	if err := s.repo.SetLockedUntil(ctx, user.ID, &until); err != nil {
+		slog.ErrorContext(ctx, "auth: store lockout failed", "user_id", user.ID, "error", err)
+	}
+	if err := s.throttle.NotifyLocked(ctx, user.Email, until); err != nil {
+		slog.ErrorContext(ctx, "auth: send lockout notice failed", "user_id", user.ID, "error", err)
+	}
+}
+
+func (s *service) loginSucceeded(ctx context.Context, user *models.User) {
+	if err := s.throttle.Reset(ctx, user.Email); err != nil {
+		slog.ErrorContext(ctx, "auth: reset login failures failed", "user_id", user.ID, "error", err)
+	}
+}
+
+func (s *service) UnlockAccount(ctx context.Context, actorID, userID uint) error {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	if err := s.throttle.Unlock(ctx, user.Email); err != nil {
+		return fmt.Errorf("lockout removal failed: %w", err)
+	}
+	if err := s.repo.SetLockedUntil(ctx, user.ID, nil); err != nil {
+		return fmt.Errorf("lockout removal failed: %w", err)
+	}
+
+	return s.audit.Record(ctx, actorID, "user.unlock", "user", strconv.FormatUint(uint64(user.ID), 10), map[string]interface{}{
+		"locked_until": user.LockedUntil,
+	})
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
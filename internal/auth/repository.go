@@ -1,16 +1,37 @@
 package auth
 
 import (
+	"context"
+	"time"
+
 	"github.com/919Umesh/gold_go/models"
 	"gorm.io/gorm"
 )
 
 type Repository interface {
-	Create(user *models.User) error
-	FindByEmail(email string) (*models.User, error)
-	FindByID(id uint) (*models.User, error)
-	ExistsByEmail(email string) (bool, error)
-	Update(user *models.User) error
+	Create(ctx context.Context, user *models.User) error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	Update(ctx context.Context, user *models.User) error
+	MarkEmailVerified(ctx context.Context, userID uint, email string, at time.Time) error
+	MarkPhoneVerified(ctx context.Context, userID uint, phone string, at time.Time) error
+	UpdatePassword(ctx context.Context, userID uint, passwordHash string) error
+	SetLockedUntil(ctx context.Context, userID uint, until *time.Time) error
+
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	MarkRefreshTokenRotated(ctx context.Context, id uint, at time.Time) (bool, error)
+	RevokeRefreshToken(ctx context.Context, id uint, at time.Time) error
+	RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) ([]models.RefreshToken, error)
+	RevokeUserTokens(ctx context.Context, userID uint, at time.Time) error
+
+	FindTwoFactor(ctx context.Context, userID uint) (*models.TwoFactor, error)
+	SaveTwoFactor(ctx context.Context, twoFactor *models.TwoFactor) error
+	DeleteTwoFactor(ctx context.Context, userID uint) error
+	MarkTOTPStepUsed(ctx context.Context, userID uint, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error
+	UseRecoveryCode(ctx context.Context, userID uint, hash string, at time.Time) (bool, error)
 }
 
 type repository struct {
@@ -21,34 +42,158 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+func (r *repository) Update(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
 }
 
-func (r *repository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *repository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *repository) FindByEmail(email string) (*models.User, error) {
+func (r *repository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *repository) FindByID(id uint) (*models.User, error) {
+func (r *repository) FindByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err := r.db.WithContext(ctx).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *repository) ExistsByEmail(email string) (bool, error) {
+// MarkEmailVerified and MarkPhoneVerified only apply while the address is
+// still the one the code was sent to.
+func (r *repository) MarkEmailVerified(ctx context.Context, userID uint, email string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND email = ?", userID, email).
+		Update("email_verified_at", at).Error
+}
+
+func (r *repository) MarkPhoneVerified(ctx context.Context, userID uint, phone string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND phone = ?", userID, phone).
+		Update("phone_verified_at", at).Error
+}
+
+func (r *repository) UpdatePassword(ctx context.Context, userID uint, passwordHash string) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("password_hash", passwordHash).Error
+}
+
+func (r *repository) SetLockedUntil(ctx context.Context, userID uint, until *time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("locked_until", until).Error
+}
+
+func (r *repository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", email).Count(&count).Error
 	return count > 0, err
 }
+
+func (r *repository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *repository) FindRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenRotated only succeeds for a token that is still live, so
+// two concurrent refreshes with the same token cannot both win.
+func (r *repository) MarkRefreshTokenRotated(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Update("rotated_at", at)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *repository) RevokeRefreshToken(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+func (r *repository) RevokeTokenFamily(ctx context.Context, familyID string, at time.Time) ([]models.RefreshToken, error) {
+	var tokens []models.RefreshToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("family_id = ?", familyID).Find(&tokens).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("family_id = ? AND revoked_at IS NULL", familyID).
+			Update("revoked_at", at).Error
+	})
+	return tokens, err
+}
+
+func (r *repository) RevokeUserTokens(ctx context.Context, userID uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+func (r *repository) FindTwoFactor(ctx context.Context, userID uint) (*models.TwoFactor, error) {
+	var twoFactor models.TwoFactor
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&twoFactor).Error
+	if err != nil {
+		return nil, err
+	}
+	return &twoFactor, nil
+}
+
+func (r *repository) SaveTwoFactor(ctx context.Context, twoFactor *models.TwoFactor) error {
+	return r.db.WithContext(ctx).Save(twoFactor).Error
+}
+
+func (r *repository) DeleteTwoFactor(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.TwoFactor{}).Error
+	})
+}
+
+// MarkTOTPStepUsed moves the last accepted step forward; it fails if the
+// step was already used, which rejects a replayed code.
+func (r *repository) MarkTOTPStepUsed(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.TwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *repository) ReplaceRecoveryCodes(ctx context.Context, userID uint, hashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *repository) UseRecoveryCode(ctx context.Context, userID uint, hash string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", at)
+	return result.RowsAffected == 1, result.Error
+}
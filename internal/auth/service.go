@@ -1,40 +1,104 @@
 package auth
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/internal/otp"
 	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/clock"
+	"github.com/919Umesh/gold_go/pkg/tokenstore"
 	"github.com/919Umesh/gold_go/pkg/utils"
+	"github.com/google/uuid"
 )
 
 var (
-	ErrUserExists         = errors.New("user already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserExists          = errors.New("user already exists")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected")
+	ErrAccountDeactivated  = errors.New("account is deactivated")
 )
 
 type Service interface {
-	Register(fullName, email, phone, password, role string) (*models.User, error)
-	Login(email, password string) (*models.User, string, error)
-	GetProfile(userID uint) (*models.User, error)
-	UpdateProfile(userID uint, updates map[string]interface{}) (*models.User, error)
-	UpdateUserKYCStatus(userID uint, kycStatus, role string) (*models.User, error)
+	Register(ctx context.Context, fullName, email, phone, password string) (*models.User, error)
+	Login(ctx context.Context, email, password string, device DeviceInfo) (*LoginResult, error)
+	CompleteMFALogin(ctx context.Context, mfaToken, code, recoveryCode string, device DeviceInfo) (*LoginResult, error)
+	Refresh(ctx context.Context, refreshToken string, device DeviceInfo) (*TokenPair, error)
+	Logout(ctx context.Context, userID uint, refreshToken string, accessClaims *utils.JWTClaims) error
+	LogoutAll(ctx context.Context, userID uint) error
+	GetProfile(ctx context.Context, userID uint) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID uint, updates map[string]interface{}) (*models.User, error)
+
+	SendEmailVerification(ctx context.Context, userID uint) error
+	VerifyEmail(ctx context.Context, userID uint, code string) error
+	SendPhoneVerification(ctx context.Context, userID uint) error
+	VerifyPhone(ctx context.Context, userID uint, code string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, email, code, newPassword string) error
+	SetPassword(ctx context.Context, actorID, userID uint, newPassword string) error
+	IsVerified(ctx context.Context, userID uint) (bool, error)
+	UnlockAccount(ctx context.Context, actorID, userID uint) error
+
+	EnrollTOTP(ctx context.Context, userID uint) (string, string, error)
+	ConfirmTOTP(ctx context.Context, userID uint, code string) ([]string, error)
+	DisableTOTP(ctx context.Context, userID uint, code string) error
+	StepUp(ctx context.Context, userID uint, code, password string) (string, int64, error)
+}
+
+type DeviceInfo struct {
+	Name      string
+	UserAgent string
+	IPAddress string
+}
+
+type TokenPair struct {
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresIn        int64     `json:"expires_in"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
 type service struct {
-	repo      Repository
-	jwtSecret string
+	repo       Repository
+	jwtSecret  string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	denylist   *tokenstore.Denylist
+	totpIssuer string
+	totpKey    string
+	clock      clock.Clock
+	otp        otp.Service
+	throttle   *LoginThrottle
+	audit      audit.Service
 }
 
-func NewService(repo Repository, jwtSecret string) Service {
+func NewService(repo Repository, cfg *config.Config, denylist *tokenstore.Denylist, clk clock.Clock, otpService otp.Service, throttle *LoginThrottle, auditService audit.Service) Service {
 	return &service{
-		repo:      repo,
-		jwtSecret: jwtSecret,
+		repo:       repo,
+		jwtSecret:  cfg.Auth.JWTSecret,
+		accessTTL:  time.Duration(cfg.Auth.AccessTokenTTLMinutes) * time.Minute,
+		refreshTTL: time.Duration(cfg.Auth.RefreshTokenTTLHours) * time.Hour,
+		denylist:   denylist,
+		totpIssuer: cfg.Auth.TOTPIssuer,
+		totpKey:    cfg.Auth.TOTPEncryptionKey,
+		clock:      clk,
+		otp:        otpService,
+		throttle:   throttle,
+		audit:      auditService,
 	}
 }
 
-func (s *service) Register(fullName, email, phone, password, role string) (*models.User, error) {
-	exists, err := s.repo.ExistsByEmail(email)
+// Register always creates a plain user; elevated roles are granted through
+// RBAC by an administrator.
+func (s *service) Register(ctx context.Context, fullName, email, phone, password string) (*models.User, error) {
+	exists, err := s.repo.ExistsByEmail(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("database error: %w", err)
 	}
@@ -52,71 +116,211 @@ func (s *service) Register(fullName, email, phone, password, role string) (*mode
 		Email:        email,
 		Phone:        phone,
 		PasswordHash: hashedPassword,
-		Role:         role,
+		Role:         "user",
 	}
 
-	if err := s.repo.Create(user); err != nil {
+	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("user creation failed: %w", err)
 	}
 
 	return user, nil
 }
 
-func (s *service) Login(email, password string) (*models.User, string, error) {
-	user, err := s.repo.FindByEmail(email)
+// Login checks the password. For accounts with 2FA it returns only an
+// MFAToken, to be exchanged with a code through CompleteMFALogin.
+func (s *service) Login(ctx context.Context, email, password string, device DeviceInfo) (*LoginResult, error) {
+	if err := s.throttle.Check(ctx, email); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.FindByEmail(ctx, email)
 	if err != nil {
-		return nil, "", ErrInvalidCredentials
+		// Spend the same bcrypt time as a real account would.
+		utils.ComparePassword(dummyPasswordHash(), password)
+		s.loginFailed(ctx, email, nil)
+		return nil, ErrInvalidCredentials
 	}
 
 	if err := utils.ComparePassword(user.PasswordHash, password); err != nil {
-		return nil, "", ErrInvalidCredentials
+		s.loginFailed(ctx, email, user)
+		return nil, ErrInvalidCredentials
 	}
 
-	token, err := utils.GenerateToken(user.ID, s.jwtSecret)
+	if user.LockedUntil != nil && user.LockedUntil.After(s.clock.Now()) {
+		return nil, &LockoutError{Locked: true, RetryAfter: user.LockedUntil.Sub(s.clock.Now())}
+	}
+	if user.DeactivatedAt != nil {
+		return nil, ErrAccountDeactivated
+	}
+
+	enabled, err := s.twoFactorEnabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		mfaToken, err := s.issueMFAToken(user.ID)
+		if err != nil {
+			return nil, fmt.Errorf("token generation failed: %w", err)
+		}
+		return &LoginResult{User: user, MFAToken: mfaToken}, nil
+	}
+
+	s.loginSucceeded(ctx, user)
+
+	tokens, err := s.issueTokens(ctx, user.ID, newFamilyID(), device)
 	if err != nil {
-		return nil, "", fmt.Errorf("token generation failed: %w", err)
+		return nil, err
 	}
 
-	return user, token, nil
+	return &LoginResult{User: user, Tokens: tokens}, nil
 }
 
-func (s *service) UpdateProfile(userID uint, updates map[string]interface{}) (*models.User, error) {
-	user, err := s.repo.FindByID(userID)
+// Refresh rotates a refresh token. Presenting a token that was already
+// rotated or revoked means it has leaked, so the whole family it belongs to
+// is revoked and the holder has to log in again.
+func (s *service) Refresh(ctx context.Context, refreshToken string, device DeviceInfo) (*TokenPair, error) {
+	stored, err := s.repo.FindRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, ErrInvalidRefreshToken
 	}
 
-	if fullname, ok := updates["full_name"].(string); ok {
-		user.FullName = fullname
+	if stored.RotatedAt != nil || stored.RevokedAt != nil {
+		s.revokeFamily(ctx, stored)
+		return nil, ErrRefreshTokenReused
 	}
 
-	if phone, ok := updates["phone"].(string); ok {
-		user.Phone = phone
+	now := s.clock.Now()
+	if now.After(stored.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
 	}
 
-	if err := s.repo.Update(user); err != nil {
-		return nil, fmt.Errorf("profile update error : %w", err)
+	rotated, err := s.repo.MarkRefreshTokenRotated(ctx, stored.ID, now)
+	if err != nil {
+		return nil, fmt.Errorf("refresh token rotation failed: %w", err)
+	}
+	if !rotated {
+		s.revokeFamily(ctx, stored)
+		return nil, ErrRefreshTokenReused
 	}
 
-	return user, nil
+	return s.issueTokens(ctx, stored.UserID, stored.FamilyID, device)
+}
+
+func (s *service) Logout(ctx context.Context, userID uint, refreshToken string, accessClaims *utils.JWTClaims) error {
+	if refreshToken != "" {
+		stored, err := s.repo.FindRefreshTokenByHash(ctx, utils.HashToken(refreshToken))
+		if err == nil && stored.UserID == userID {
+			if err := s.repo.RevokeRefreshToken(ctx, stored.ID, time.Now()); err != nil {
+				return fmt.Errorf("refresh token revocation failed: %w", err)
+			}
+		}
+	}
+
+	if accessClaims != nil && accessClaims.ExpiresAt != nil {
+		if err := s.denylist.Revoke(ctx, accessClaims.ID, accessClaims.ExpiresAt.Time); err != nil {
+			return fmt.Errorf("access token revocation failed: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *service) LogoutAll(ctx context.Context, userID uint) error {
+	now := time.Now()
+	if err := s.repo.RevokeUserTokens(ctx, userID, now); err != nil {
+		return fmt.Errorf("refresh token revocation failed: %w", err)
+	}
+	if err := s.denylist.RevokeUser(ctx, userID, now, s.accessTTL); err != nil {
+		return fmt.Errorf("access token revocation failed: %w", err)
+	}
+	return nil
+}
+
+func (s *service) issueTokens(ctx context.Context, userID uint, familyID string, device DeviceInfo) (*TokenPair, error) {
+	now := s.clock.Now()
+	claims := utils.NewClaims(userID, now, s.accessTTL)
+	accessToken, err := utils.SignToken(claims, s.jwtSecret)
+	if err != nil {
+		return nil, fmt.Errorf("token generation failed: %w", err)
+	}
+
+	refreshToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, fmt.Errorf("token generation failed: %w", err)
+	}
+
+	stored := &models.RefreshToken{
+		UserID:        userID,
+		FamilyID:      familyID,
+		TokenHash:     utils.HashToken(refreshToken),
+		AccessTokenID: claims.ID,
+		DeviceName:    device.Name,
+		UserAgent:     truncate(device.UserAgent, 255),
+		IPAddress:     device.IPAddress,
+		ExpiresAt:     now.Add(s.refreshTTL),
+	}
+	if err := s.repo.CreateRefreshToken(ctx, stored); err != nil {
+		return nil, fmt.Errorf("refresh token storage failed: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		TokenType:        "Bearer",
+		ExpiresIn:        int64(s.accessTTL.Seconds()),
+		RefreshExpiresAt: stored.ExpiresAt,
+	}, nil
 }
 
-func (s *service) UpdateUserKYCStatus(userID uint, kycStatus, role string) (*models.User, error) {
-	user, err := s.repo.FindByID(userID)
+// revokeFamily also denylists the access tokens minted alongside the
+// family's refresh tokens, cutting off whoever replayed the token right away.
+func (s *service) revokeFamily(ctx context.Context, token *models.RefreshToken) {
+	now := time.Now()
+	tokens, err := s.repo.RevokeTokenFamily(ctx, token.FamilyID, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "auth: revoke token family failed", "user_id", token.UserID, "error", err)
+		return
+	}
+
+	for _, member := range tokens {
+		if err := s.denylist.Revoke(ctx, member.AccessTokenID, member.CreatedAt.Add(s.accessTTL)); err != nil {
+			slog.ErrorContext(ctx, "auth: denylist access token failed", "user_id", token.UserID, "error", err)
+		}
+	}
+}
+
+func (s *service) UpdateProfile(ctx context.Context, userID uint, updates map[string]interface{}) (*models.User, error) {
+	user, err := s.repo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
 
-	user.KYCStatus = kycStatus
-	user.Role = role
+	if fullname, ok := updates["full_name"].(string); ok {
+		user.FullName = fullname
+	}
 
-	if err := s.repo.Update(user); err != nil {
-		return nil, fmt.Errorf("KYC status update failed: %w", err)
+	if phone, ok := updates["phone"].(string); ok && phone != user.Phone {
+		user.Phone = phone
+		user.PhoneVerifiedAt = nil
+	}
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("profile update error : %w", err)
 	}
 
 	return user, nil
 }
 
-func (s *service) GetProfile(userID uint) (*models.User, error) {
-	return s.repo.FindByID(userID)
+func (s *service) GetProfile(ctx context.Context, userID uint) (*models.User, error) {
+	return s.repo.FindByID(ctx, userID)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+func newFamilyID() string {
+	return uuid.New().String()
 }
@@ -0,0 +1,294 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/totp"
+	"github.com/919Umesh/gold_go/pkg/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidMFAToken         = errors.New("invalid or expired mfa token")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication not enabled")
+)
+
+const (
+	mfaTokenTTL       = 5 * time.Minute
+	totpSkewSteps     = 1
+	recoveryCodeCount = 10
+)
+
+type LoginResult struct {
+	User     *models.User
+	Tokens   *TokenPair
+	MFAToken string
+}
+
+func (s *service) EnrollTOTP(ctx context.Context, userID uint) (string, string, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return "", "", fmt.Errorf("user not found: %w", err)
+	}
+
+	existing, err := s.repo.FindTwoFactor(ctx, userID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", "", err
+	}
+	if existing != nil && existing.EnabledAt != nil {
+		return "", "", ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", fmt.Errorf("secret generation failed: %w", err)
+	}
+	encrypted, err := utils.Encrypt(s.totpKey, secret)
+	if err != nil {
+		return "", "", fmt.Errorf("secret encryption failed: %w", err)
+	}
+
+	twoFactor := existing
+	if twoFactor == nil {
+		twoFactor = &models.TwoFactor{UserID: userID}
+	}
+	twoFactor.EncryptedSecret = encrypted
+	twoFactor.LastUsedStep = 0
+	if err := s.repo.SaveTwoFactor(ctx, twoFactor); err != nil {
+		return "", "", fmt.Errorf("two-factor enrolment failed: %w", err)
+	}
+
+	return secret, totp.KeyURI(s.totpIssuer, user.Email, secret), nil
+}
+
+// ConfirmTOTP enables 2FA once the user proves their app produces valid
+// codes, and returns recovery codes. They are only ever shown here.
+func (s *service) ConfirmTOTP(ctx context.Context, userID uint, code string) ([]string, error) {
+	twoFactor, err := s.repo.FindTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTwoFactorNotEnabled
+		}
+		return nil, err
+	}
+	if twoFactor.EnabledAt != nil {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	if err := s.checkTOTP(ctx, twoFactor, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("recovery code generation failed: %w", err)
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("recovery code storage failed: %w", err)
+	}
+
+	now := s.clock.Now()
+	twoFactor.EnabledAt = &now
+	if err := s.repo.SaveTwoFactor(ctx, twoFactor); err != nil {
+		return nil, fmt.Errorf("two-factor activation failed: %w", err)
+	}
+	return codes, nil
+}
+
+func (s *service) DisableTOTP(ctx context.Context, userID uint, code string) error {
+	if err := s.verifySecondFactor(ctx, userID, code, ""); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteTwoFactor(ctx, userID); err != nil {
+		return fmt.Errorf("two-factor removal failed: %w", err)
+	}
+	return nil
+}
+
+// CompleteMFALogin finishes a login that Login paused for the second factor.
+// The mfa token is single-use: it is revoked whether the code is right or
+// wrong, so guessing codes means re-entering the password each time.
+func (s *service) CompleteMFALogin(ctx context.Context, mfaToken, code, recoveryCode string, device DeviceInfo) (*LoginResult, error) {
+	claims, err := utils.ParseToken(mfaToken, s.jwtSecret)
+	if err != nil || claims.Purpose != utils.PurposeMFA {
+		return nil, ErrInvalidMFAToken
+	}
+
+	revoked, err := s.denylist.IsRevoked(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidMFAToken
+	}
+	if err := s.denylist.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		return nil, fmt.Errorf("mfa token revocation failed: %w", err)
+	}
+
+	user, err := s.repo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if user.DeactivatedAt != nil {
+		return nil, ErrAccountDeactivated
+	}
+
+	// A wrong second factor counts towards the lockout, otherwise someone
+	// holding the password could guess codes without limit.
+	if err := s.verifySecondFactor(ctx, user.ID, code, recoveryCode); err != nil {
+		if err == ErrInvalidTwoFactorCode {
+			s.loginFailed(ctx, user.Email, user)
+		}
+		return nil, err
+	}
+	s.loginSucceeded(ctx, user)
+
+	tokens, err := s.issueTokens(ctx, user.ID, newFamilyID(), device)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{User: user, Tokens: tokens}, nil
+}
+
+// StepUp re-authenticates a logged-in user before a sensitive action and
+// returns an access token carrying step_up_at. Users with 2FA must give a
+// TOTP code; everyone else re-enters their password. Wrong answers count
+// towards the login lockout, so a stolen access token cannot be used to
+// guess them.
+func (s *service) StepUp(ctx context.Context, userID uint, code, password string) (string, int64, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return "", 0, ErrInvalidCredentials
+	}
+	if err := s.throttle.Check(ctx, user.Email); err != nil {
+		return "", 0, err
+	}
+
+	enabled, err := s.twoFactorEnabled(ctx, userID)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if enabled {
+		err = s.verifySecondFactor(ctx, userID, code, "")
+	} else if utils.ComparePassword(user.PasswordHash, password) != nil {
+		err = ErrInvalidCredentials
+	}
+	if err != nil {
+		if err == ErrInvalidTwoFactorCode || err == ErrInvalidCredentials {
+			s.loginFailed(ctx, user.Email, user)
+		}
+		return "", 0, err
+	}
+	s.loginSucceeded(ctx, user)
+
+	now := s.clock.Now()
+	claims := utils.NewClaims(userID, now, s.accessTTL)
+	claims.StepUpAt = now.Unix()
+	token, err := utils.SignToken(claims, s.jwtSecret)
+	if err != nil {
+		return "", 0, fmt.Errorf("token generation failed: %w", err)
+	}
+	return token, int64(s.accessTTL.Seconds()), nil
+}
+
+func (s *service) issueMFAToken(userID uint) (string, error) {
+	claims := utils.NewClaims(userID, s.clock.Now(), mfaTokenTTL)
+	claims.Purpose = utils.PurposeMFA
+	return utils.SignToken(claims, s.jwtSecret)
+}
+
+func (s *service) twoFactorEnabled(ctx context.Context, userID uint) (bool, error) {
+	twoFactor, err := s.repo.FindTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return twoFactor.EnabledAt != nil, nil
+}
+
+func (s *service) verifySecondFactor(ctx context.Context, userID uint, code, recoveryCode string) error {
+	twoFactor, err := s.repo.FindTwoFactor(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrTwoFactorNotEnabled
+		}
+		return err
+	}
+	if twoFactor.EnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if code != "" {
+		return s.checkTOTP(ctx, twoFactor, code)
+	}
+
+	if recoveryCode != "" {
+		used, err := s.repo.UseRecoveryCode(ctx, userID, utils.HashToken(normalizeRecoveryCode(recoveryCode)), s.clock.Now())
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		slog.InfoContext(ctx, "auth: recovery code used", "user_id", userID)
+		return nil
+	}
+
+	return ErrInvalidTwoFactorCode
+}
+
+func (s *service) checkTOTP(ctx context.Context, twoFactor *models.TwoFactor, code string) error {
+	secret, err := utils.Decrypt(s.totpKey, twoFactor.EncryptedSecret)
+	if err != nil {
+		return fmt.Errorf("two-factor secret unreadable: %w", err)
+	}
+
+	step, ok := totp.Validate(secret, code, s.clock.Now(), totpSkewSteps)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	fresh, err := s.repo.MarkTOTPStepUsed(ctx, twoFactor.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := encoding.EncodeToString(buf)
+		codes[i] = raw[:4] + "-" + raw[4:]
+		hashes[i] = utils.HashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,203 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/clock"
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/919Umesh/gold_go/pkg/tokenstore"
+	"github.com/919Umesh/gold_go/pkg/totp"
+	"github.com/919Umesh/gold_go/pkg/utils"
+	"github.com/alicebob/miniredis/v2"
+	"gorm.io/gorm"
+)
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+type fakeRepository struct {
+	Repository
+	user      *models.User
+	twoFactor *models.TwoFactor
+}
+
+func (r *fakeRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.user, nil
+}
+
+func (r *fakeRepository) FindTwoFactor(ctx context.Context, userID uint) (*models.TwoFactor, error) {
+	if r.twoFactor == nil || r.twoFactor.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.twoFactor, nil
+}
+
+func (r *fakeRepository) MarkTOTPStepUsed(ctx context.Context, userID uint, step int64) (bool, error) {
+	if step <= r.twoFactor.LastUsedStep {
+		return false, nil
+	}
+	r.twoFactor.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeRepository) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	return nil
+}
+
+func (r *fakeRepository) SetLockedUntil(ctx context.Context, userID uint, until *time.Time) error {
+	return nil
+}
+
+// newTwoFactorService returns a service for a user with 2FA enabled. The
+// clock starts at the current second because token expiry is checked
+// against the real time.
+func newTwoFactorService(t *testing.T) (*service, *clock.Fixed) {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Auth.JWTSecret = "jwt-secret"
+	cfg.Auth.TOTPEncryptionKey = "totp-key"
+	cfg.Auth.AccessTokenTTLMinutes = 15
+	cfg.Auth.RefreshTokenTTLHours = 24
+	cfg.Auth.LoginMaxFailures = 10
+	cfg.Auth.LoginDelayAfterFailures = 10
+	cfg.Auth.LoginFailureWindowMinutes = 15
+	cfg.Auth.LoginLockoutMinutes = 15
+
+	encrypted, err := utils.Encrypt(cfg.Auth.TOTPEncryptionKey, testTOTPSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enabledAt := time.Now()
+	repo := &fakeRepository{
+		user:      &models.User{ID: 1, Email: "user@example.com"},
+		twoFactor: &models.TwoFactor{UserID: 1, EncryptedSecret: encrypted, EnabledAt: &enabledAt},
+	}
+
+	redisClient := redis.NewRedisClient(miniredis.RunT(t).Addr(), "", 0)
+	clk := &clock.Fixed{Time: time.Now().Truncate(time.Second)}
+	svc := NewService(repo, cfg, tokenstore.NewDenylist(redisClient), clk, nil, NewLoginThrottle(redisClient, nil, cfg), nil)
+	return svc.(*service), clk
+}
+
+func codeAt(t *testing.T, at time.Time, offset int64) string {
+	t.Helper()
+	code, err := totp.CodeAt(testTOTPSecret, totp.Step(at)+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestCompleteMFALoginConsumesTokenEvenOnWrongCode(t *testing.T) {
+	svc, clk := newTwoFactorService(t)
+	ctx := context.Background()
+
+	mfaToken, err := svc.issueMFAToken(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CompleteMFALogin(ctx, mfaToken, codeAt(t, clk.Now(), 5), "", DeviceInfo{}); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("wrong code: err = %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if _, err := svc.CompleteMFALogin(ctx, mfaToken, codeAt(t, clk.Now(), 0), "", DeviceInfo{}); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Fatalf("reused token: err = %v, want ErrInvalidMFAToken", err)
+	}
+
+	mfaToken, err = svc.issueMFAToken(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A code one step old is still inside the allowed skew.
+	result, err := svc.CompleteMFALogin(ctx, mfaToken, codeAt(t, clk.Now(), -1), "", DeviceInfo{})
+	if err != nil {
+		t.Fatalf("valid code: %v", err)
+	}
+	if result.Tokens == nil {
+		t.Fatal("no tokens issued")
+	}
+}
+
+func TestCompleteMFALoginRejectsExpiredToken(t *testing.T) {
+	svc, clk := newTwoFactorService(t)
+	now := clk.Now()
+
+	clk.Time = now.Add(-mfaTokenTTL - time.Second)
+	mfaToken, err := svc.issueMFAToken(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk.Time = now
+
+	if _, err := svc.CompleteMFALogin(context.Background(), mfaToken, codeAt(t, now, 0), "", DeviceInfo{}); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Fatalf("err = %v, want ErrInvalidMFAToken", err)
+	}
+}
+
+func TestCompleteMFALoginRejectsAccessToken(t *testing.T) {
+	svc, clk := newTwoFactorService(t)
+
+	accessToken, err := utils.SignToken(utils.NewClaims(1, clk.Now(), time.Minute), svc.jwtSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.CompleteMFALogin(context.Background(), accessToken, codeAt(t, clk.Now(), 0), "", DeviceInfo{}); !errors.Is(err, ErrInvalidMFAToken) {
+		t.Fatalf("err = %v, want ErrInvalidMFAToken", err)
+	}
+}
+
+func TestStepUpTokenCarriesStepUpTime(t *testing.T) {
+	svc, clk := newTwoFactorService(t)
+
+	token, _, err := svc.StepUp(context.Background(), 1, codeAt(t, clk.Now(), 0), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := utils.ParseToken(token, svc.jwtSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.StepUpAt != clk.Now().Unix() {
+		t.Fatalf("step_up_at = %d, want %d", claims.StepUpAt, clk.Now().Unix())
+	}
+
+	if _, _, err := svc.StepUp(context.Background(), 1, codeAt(t, clk.Now(), 0), ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("replayed code: err = %v, want ErrInvalidTwoFactorCode", err)
+	}
+}
+
+type recordingMailer struct {
+	sent []string
+}
+
+func (m *recordingMailer) SendEmail(ctx context.Context, to, subject, body string) error {
+	m.sent = append(m.sent, to)
+	return nil
+}
+
+func TestStepUpLocksOutAfterRepeatedWrongCodes(t *testing.T) {
+	svc, clk := newTwoFactorService(t)
+	mailer := &recordingMailer{}
+	svc.throttle.maxFails, svc.throttle.mailer = 3, mailer
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := svc.StepUp(ctx, 1, codeAt(t, clk.Now(), 5), ""); !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("attempt %d: err = %v, want ErrInvalidTwoFactorCode", i+1, err)
+		}
+	}
+
+	var lockout *LockoutError
+	if _, _, err := svc.StepUp(ctx, 1, codeAt(t, clk.Now(), 0), ""); !errors.As(err, &lockout) || !lockout.Locked {
+		t.Fatalf("correct code after 3 failures: err = %v, want a lockout", err)
+	}
+	if len(mailer.sent) != 1 || mailer.sent[0] != "user@example.com" {
+		t.Fatalf("lockout notices = %v, want one to the user", mailer.sent)
+	}
+}
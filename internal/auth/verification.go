@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/919Umesh/gold_go/internal/otp"
+	"github.com/919Umesh/gold_go/pkg/utils"
+)
+
+var ErrAlreadyVerified = errors.New("already verified")
+
+func (s *service) SendEmailVerification(ctx context.Context, userID uint) error {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+	return s.otp.Send(ctx, otp.PurposeEmailVerification, user.Email)
+}
+
+func (s *service) VerifyEmail(ctx context.Context, userID uint, code string) error {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+	if err := s.otp.Verify(ctx, otp.PurposeEmailVerification, user.Email, code); err != nil {
+		return err
+	}
+	return s.repo.MarkEmailVerified(ctx, user.ID, user.Email, s.clock.Now())
+}
+
+func (s *service) SendPhoneVerification(ctx context.Context, userID uint) error {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if user.PhoneVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+	return s.otp.Send(ctx, otp.PurposePhoneVerification, user.Phone)
+}
+
+func (s *service) VerifyPhone(ctx context.Context, userID uint, code string) error {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if user.PhoneVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+	if err := s.otp.Verify(ctx, otp.PurposePhoneVerification, user.Phone, code); err != nil {
+		return err
+	}
+	return s.repo.MarkPhoneVerified(ctx, user.ID, user.Phone, s.clock.Now())
+}
+
+// RequestPasswordReset reports success for unknown emails and for rate
+// limited requests alike, so the endpoint cannot be used to find accounts.
+func (s *service) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil
+	}
+
+	if err := s.otp.Send(ctx, otp.PurposePasswordReset, user.Email); err != nil {
+		if errors.Is(err, otp.ErrTooManyRequests) {
+			slog.WarnContext(ctx, "auth: password reset rate limited", "user_id", user.ID)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// ResetPassword sets a new password and ends every existing session.
+func (s *service) ResetPassword(ctx context.Context, email, code, newPassword string) error {
+	if err := s.otp.Verify(ctx, otp.PurposePasswordReset, email, code); err != nil {
+		return err
+	}
+
+	user, err := s.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return otp.ErrInvalidCode
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("password hashing failed: %w", err)
+	}
+	if err := s.repo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return fmt.Errorf("password update failed: %w", err)
+	}
+	if user.EmailVerifiedAt == nil {
+		// The code arrived by email, so the address is proven as well.
+		if err := s.repo.MarkEmailVerified(ctx, user.ID, user.Email, s.clock.Now()); err != nil {
+			slog.ErrorContext(ctx, "auth: mark email verified failed", "user_id", user.ID, "error", err)
+		}
+	}
+
+	return s.LogoutAll(ctx, user.ID)
+}
+
+// SetPassword replaces a user's password on an operator's behalf and ends
+// every session.
+func (s *service) SetPassword(ctx context.Context, actorID, userID uint, newPassword string) error {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+
+	hashedPassword, err := utils.HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("password hashing failed: %w", err)
+	}
+	if err := s.repo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return fmt.Errorf("password update failed: %w", err)
+	}
+	if err := s.LogoutAll(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return s.audit.Record(ctx, actorID, "user.password_set", "user", strconv.FormatUint(uint64(user.ID), 10), nil)
+}
+
+// IsVerified reports whether both the email and phone of a user have been
+// confirmed. Trading endpoints require it.
+func (s *service) IsVerified(ctx context.Context, userID uint) (bool, error) {
+	user, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.EmailVerifiedAt != nil && user.PhoneVerifiedAt != nil, nil
+}
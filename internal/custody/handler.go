@@ -0,0 +1,171 @@
+package custody
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+type ReceiveBarRequest struct {
+	SerialNumber string  `json:"serial_number" binding:"required,max=100"`
+	Refiner      string  `json:"refiner" binding:"max=100"`
+	WeightGrams  float64 `json:"weight_grams" binding:"required,gt=0"`
+	Purity       float64 `json:"purity" binding:"required,gt=0,lte=1"`
+	Vault        string  `json:"vault" binding:"required,max=100"`
+	Reference    string  `json:"reference" binding:"max=100"`
+	Note         string  `json:"note" binding:"max=500"`
+}
+
+type WithdrawBarRequest struct {
+	Reference string `json:"reference" binding:"required,max=100"`
+	Note      string `json:"note" binding:"max=500"`
+}
+
+type TransferBarRequest struct {
+	Vault     string `json:"vault" binding:"required,max=100"`
+	Reference string `json:"reference" binding:"max=100"`
+	Note      string `json:"note" binding:"max=500"`
+}
+
+func (h *Handler) ListBars(c *gin.Context) {
+	bars, err := h.service.ListBars(c.Request.Context(), BarFilter{Vault: c.Query("vault"), Status: c.Query("status")})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch gold bars"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bars": bars})
+}
+
+func (h *Handler) ReceiveBar(c *gin.Context) {
+	var req ReceiveBarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bar, err := h.service.ReceiveBar(c.Request.Context(), c.GetUint("user_id"), BarInput{
+		SerialNumber: req.SerialNumber,
+		Refiner:      req.Refiner,
+		WeightGrams:  req.WeightGrams,
+		Purity:       req.Purity,
+		Vault:        req.Vault,
+		Reference:    req.Reference,
+		Note:         req.Note,
+	})
+	if err != nil {
+		writeError(c, err, "failed to record gold bar")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"bar": bar})
+}
+
+func (h *Handler) WithdrawBar(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req WithdrawBarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bar, err := h.service.WithdrawBar(c.Request.Context(), c.GetUint("user_id"), id, req.Reference, req.Note)
+	if err != nil {
+		writeError(c, err, "failed to withdraw gold bar")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bar": bar})
+}
+
+func (h *Handler) TransferBar(c *gin.Context) {
+	id, ok := parseID(c)
+	if !ok {
+		return
+	}
+
+	var req TransferBarRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bar, err := h.service.TransferBar(c.Request.Context(), c.GetUint("user_id"), id, req.Vault, req.Reference, req.Note)
+	if err != nil {
+		writeError(c, err, "failed to transfer gold bar")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bar": bar})
+}
+
+func (h *Handler) ListMovements(c *gin.Context) {
+	barID, _ := strconv.ParseUint(c.Query("bar_id"), 10, 32)
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	movements, err := h.service.ListMovements(c.Request.Context(), uint(barID), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch custody movements"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"movements": movements})
+}
+
+func (h *Handler) ListChecks(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	checks, err := h.service.ListChecks(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch coverage checks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checks": checks})
+}
+
+func (h *Handler) ProofOfReserves(c *gin.Context) {
+	proof, err := h.service.ProofOfReserves(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build proof of reserves"})
+		return
+	}
+
+	c.JSON(http.StatusOK, proof)
+}
+
+func parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bar id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrBarNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrDuplicateSerial), errors.Is(err, ErrBarNotInVault), errors.Is(err, ErrSameVault):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidBar):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
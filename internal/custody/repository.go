@@ -0,0 +1,165 @@
+package custody
+
+import (
+	"context"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BarFilter struct {
+	Vault  string
+	Status string
+	Limit  int
+}
+
+type VaultHolding struct {
+	Vault      string  `json:"vault"`
+	Bars       int64   `json:"bars"`
+	FineGrams  float64 `json:"fine_grams"`
+	GrossGrams float64 `json:"gross_grams"`
+}
+
+type Repository interface {
+	SerialExists(ctx context.Context, serial string) (bool, error)
+	CreateBar(ctx context.Context, bar *models.GoldBar, movement *models.CustodyMovement) error
+	FindBar(ctx context.Context, id uint) (*models.GoldBar, error)
+	ListBars(ctx context.Context, filter BarFilter) ([]models.GoldBar, error)
+	MoveBar(ctx context.Context, id uint, apply func(bar *models.GoldBar, movement *models.CustodyMovement) error, movement *models.CustodyMovement) (*models.GoldBar, error)
+	ListMovements(ctx context.Context, barID uint, limit int) ([]models.CustodyMovement, error)
+
+	CustomerGrams(ctx context.Context) (float64, int64, error)
+	VaultHoldings(ctx context.Context) ([]VaultHolding, error)
+	SaveCheck(ctx context.Context, check *models.CoverageCheck) error
+	MarkCheckAlerted(ctx context.Context, id uint, at time.Time) error
+	LatestCheck(ctx context.Context) (*models.CoverageCheck, error)
+	ListChecks(ctx context.Context, limit int) ([]models.CoverageCheck, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) SerialExists(ctx context.Context, serial string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.GoldBar{}).Where("serial_number = ?", serial).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *repository) CreateBar(ctx context.Context, bar *models.GoldBar, movement *models.CustodyMovement) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(bar).Error; err != nil {
+			return err
+		}
+		movement.BarID = bar.ID
+		return tx.Create(movement).Error
+	})
+}
+
+func (r *repository) FindBar(ctx context.Context, id uint) (*models.GoldBar, error) {
+	var bar models.GoldBar
+	if err := r.db.WithContext(ctx).First(&bar, id).Error; err != nil {
+		return nil, err
+	}
+	return &bar, nil
+}
+
+func (r *repository) ListBars(ctx context.Context, filter BarFilter) ([]models.GoldBar, error) {
+	var bars []models.GoldBar
+	query := r.db.WithContext(ctx).Model(&models.GoldBar{})
+	if filter.Vault != "" {
+		query = query.Where("vault = ?", filter.Vault)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	err := query.Order("id").Find(&bars).Error
+	return bars, err
+}
+
+// MoveBar locks the bar, lets apply change it and fill in the movement, and
+// stores both together.
+func (r *repository) MoveBar(ctx context.Context, id uint, apply func(bar *models.GoldBar, movement *models.CustodyMovement) error, movement *models.CustodyMovement) (*models.GoldBar, error) {
+	var bar models.GoldBar
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bar, id).Error; err != nil {
+			return err
+		}
+		if err := apply(&bar, movement); err != nil {
+			return err
+		}
+		if err := tx.Save(&bar).Error; err != nil {
+			return err
+		}
+		movement.BarID = bar.ID
+		return tx.Create(movement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bar, nil
+}
+
+func (r *repository) ListMovements(ctx context.Context, barID uint, limit int) ([]models.CustodyMovement, error) {
+	var movements []models.CustodyMovement
+	query := r.db.WithContext(ctx).Model(&models.CustodyMovement{})
+	if barID != 0 {
+		query = query.Where("bar_id = ?", barID)
+	}
+	err := query.Order("id desc").Limit(limit).Find(&movements).Error
+	return movements, err
+}
+
+// CustomerGrams is the gold owed to customers and the number of wallets
+// holding any.
+func (r *repository) CustomerGrams(ctx context.Context) (float64, int64, error) {
+	var grams float64
+	var holders int64
+	err := r.db.WithContext(ctx).Model(&models.Wallet{}).
+		Select("COALESCE(SUM(gold_grams), 0), COUNT(*) FILTER (WHERE gold_grams > 0)").
+		Row().Scan(&grams, &holders)
+	return grams, holders, err
+}
+
+func (r *repository) VaultHoldings(ctx context.Context) ([]VaultHolding, error) {
+	var holdings []VaultHolding
+	err := r.db.WithContext(ctx).Model(&models.GoldBar{}).
+		Select("vault, COUNT(*) AS bars, COALESCE(SUM(fine_grams), 0) AS fine_grams, COALESCE(SUM(weight_grams), 0) AS gross_grams").
+		Where("status = ?", models.GoldBarInVault).
+		Group("vault").
+		Order("vault").
+		Scan(&holdings).Error
+	return holdings, err
+}
+
+func (r *repository) SaveCheck(ctx context.Context, check *models.CoverageCheck) error {
+	return r.db.WithContext(ctx).Create(check).Error
+}
+
+func (r *repository) MarkCheckAlerted(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.CoverageCheck{}).Where("id = ?", id).Update("alerted_at", at).Error
+}
+
+func (r *repository) LatestCheck(ctx context.Context) (*models.CoverageCheck, error) {
+	var check models.CoverageCheck
+	result := r.db.WithContext(ctx).Order("id desc").Limit(1).Find(&check)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &check, nil
+}
+
+func (r *repository) ListChecks(ctx context.Context, limit int) ([]models.CoverageCheck, error) {
+	var checks []models.CoverageCheck
+	err := r.db.WithContext(ctx).Order("id desc").Limit(limit).Find(&checks).Error
+	return checks, err
+}
@@ -0,0 +1,352 @@
+package custody
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/notify"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBarNotFound     = errors.New("gold bar not found")
+	ErrDuplicateSerial = errors.New("a bar with this serial number already exists")
+	ErrBarNotInVault   = errors.New("gold bar is not in a vault")
+	ErrSameVault       = errors.New("gold bar is already in this vault")
+	ErrInvalidBar      = errors.New("weight must be positive and purity between 0 and 1")
+)
+
+type BarInput struct {
+	SerialNumber string
+	Refiner      string
+	WeightGrams  float64
+	Purity       float64
+	Vault        string
+	Reference    string
+	Note         string
+}
+
+type BarSummary struct {
+	SerialNumber string  `json:"serial_number"`
+	Refiner      string  `json:"refiner"`
+	WeightGrams  float64 `json:"weight_grams"`
+	Purity       float64 `json:"purity"`
+	FineGrams    float64 `json:"fine_grams"`
+	Vault        string  `json:"vault"`
+}
+
+type ProofOfReserves struct {
+	GeneratedAt      time.Time      `json:"generated_at"`
+	CustomerGrams    float64        `json:"customer_grams"`
+	CustomerAccounts int64          `json:"customer_accounts"`
+	VaultedGrams     float64        `json:"vaulted_fine_grams"`
+	Coverage         float64        `json:"coverage"`
+	Threshold        float64        `json:"threshold"`
+	Status           string         `json:"status"`
+	Vaults           []VaultHolding `json:"vaults"`
+	Bars             []BarSummary   `json:"bars"`
+}
+
+type Service interface {
+	ReceiveBar(ctx context.Context, actorID uint, input BarInput) (*models.GoldBar, error)
+	WithdrawBar(ctx context.Context, actorID, barID uint, reference, note string) (*models.GoldBar, error)
+	TransferBar(ctx context.Context, actorID, barID uint, vault, reference, note string) (*models.GoldBar, error)
+	ListBars(ctx context.Context, filter BarFilter) ([]models.GoldBar, error)
+	ListMovements(ctx context.Context, barID uint, limit int) ([]models.CustodyMovement, error)
+
+	CheckCoverage(ctx context.Context, scheduledFor time.Time) error
+	ListChecks(ctx context.Context, limit int) ([]models.CoverageCheck, error)
+	ProofOfReserves(ctx context.Context) (*ProofOfReserves, error)
+}
+
+type service struct {
+	repo       Repository
+	audit      audit.Service
+	mailer     notify.EmailSender
+	threshold  float64
+	alertEmail string
+}
+
+func NewService(repo Repository, auditService audit.Service, mailer notify.EmailSender, cfg *config.Config) Service {
+	return &service{
+		repo:       repo,
+		audit:      auditService,
+		mailer:     mailer,
+		threshold:  cfg.Custody.CoverageThreshold,
+		alertEmail: cfg.Custody.AlertEmail,
+	}
+}
+
+func (s *service) ReceiveBar(ctx context.Context, actorID uint, input BarInput) (*models.GoldBar, error) {
+	if input.WeightGrams <= 0 || input.Purity <= 0 || input.Purity > 1 {
+		return nil, ErrInvalidBar
+	}
+	exists, err := s.repo.SerialExists(ctx, input.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrDuplicateSerial
+	}
+
+	bar := &models.GoldBar{
+		SerialNumber: input.SerialNumber,
+		Refiner:      input.Refiner,
+		WeightGrams:  input.WeightGrams,
+		Purity:       input.Purity,
+		FineGrams:    math.Round(input.WeightGrams*input.Purity*10000) / 10000,
+		Vault:        input.Vault,
+		Status:       models.GoldBarInVault,
+		ReceivedAt:   time.Now(),
+	}
+	movement := &models.CustodyMovement{
+		Direction: models.CustodyInbound,
+		ToVault:   input.Vault,
+		Reference: input.Reference,
+		Note:      input.Note,
+		ActorID:   actorID,
+	}
+	if err := s.repo.CreateBar(ctx, bar, movement); err != nil {
+		return nil, fmt.Errorf("failed to record gold bar: %w", err)
+	}
+
+	if err := s.record(ctx, actorID, "custody.receive", bar, movement); err != nil {
+		return nil, err
+	}
+	return bar, nil
+}
+
+func (s *service) WithdrawBar(ctx context.Context, actorID, barID uint, reference, note string) (*models.GoldBar, error) {
+	movement := &models.CustodyMovement{
+		Direction: models.CustodyOutbound,
+		Reference: reference,
+		Note:      note,
+		ActorID:   actorID,
+	}
+	bar, err := s.move(ctx, barID, movement, func(bar *models.GoldBar, movement *models.CustodyMovement) error {
+		if bar.Status != models.GoldBarInVault {
+			return ErrBarNotInVault
+		}
+		now := time.Now()
+		movement.FromVault = bar.Vault
+		bar.Status = models.GoldBarWithdrawn
+		bar.WithdrawnAt = &now
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.record(ctx, actorID, "custody.withdraw", bar, movement); err != nil {
+		return nil, err
+	}
+	return bar, nil
+}
+
+func (s *service) TransferBar(ctx context.Context, actorID, barID uint, vault, reference, note string) (*models.GoldBar, error) {
+	movement := &models.CustodyMovement{
+		Direction: models.CustodyTransfer,
+		ToVault:   vault,
+		Reference: reference,
+		Note:      note,
+		ActorID:   actorID,
+	}
+	bar, err := s.move(ctx, barID, movement, func(bar *models.GoldBar, movement *models.CustodyMovement) error {
+		if bar.Status != models.GoldBarInVault {
+			return ErrBarNotInVault
+		}
+		if bar.Vault == vault {
+			return ErrSameVault
+		}
+		movement.FromVault = bar.Vault
+		bar.Vault = vault
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.record(ctx, actorID, "custody.transfer", bar, movement); err != nil {
+		return nil, err
+	}
+	return bar, nil
+}
+
+func (s *service) move(ctx context.Context, barID uint, movement *models.CustodyMovement, apply func(*models.GoldBar, *models.CustodyMovement) error) (*models.GoldBar, error) {
+	bar, err := s.repo.MoveBar(ctx, barID, apply, movement)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrBarNotFound
+		}
+		return nil, err
+	}
+	return bar, nil
+}
+
+func (s *service) record(ctx context.Context, actorID uint, action string, bar *models.GoldBar, movement *models.CustodyMovement) error {
+	return s.audit.Record(ctx, actorID, action, "gold_bar", strconv.FormatUint(uint64(bar.ID), 10), map[string]interface{}{
+		"serial_number": bar.SerialNumber,
+		"fine_grams":    bar.FineGrams,
+		"from_vault":    movement.FromVault,
+		"to_vault":      movement.ToVault,
+		"reference":     movement.Reference,
+	})
+}
+
+func (s *service) ListBars(ctx context.Context, filter BarFilter) ([]models.GoldBar, error) {
+	return s.repo.ListBars(ctx, filter)
+}
+
+func (s *service) ListMovements(ctx context.Context, barID uint, limit int) ([]models.CustodyMovement, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.ListMovements(ctx, barID, limit)
+}
+
+// CheckCoverage compares vaulted fine gold with customer balances and
+// stores the result. An alert goes out when coverage first drops below the
+// threshold, not on every run while it stays there; if sending fails, the
+// next run tries again.
+func (s *service) CheckCoverage(ctx context.Context, scheduledFor time.Time) error {
+	previous, err := s.repo.LatestCheck(ctx)
+	if err != nil {
+		return err
+	}
+
+	customerGrams, _, err := s.repo.CustomerGrams(ctx)
+	if err != nil {
+		return err
+	}
+	holdings, err := s.repo.VaultHoldings(ctx)
+	if err != nil {
+		return err
+	}
+
+	check := &models.CoverageCheck{
+		CustomerGrams: customerGrams,
+		VaultedGrams:  totalFineGrams(holdings),
+		Threshold:     s.threshold,
+		CheckedAt:     time.Now(),
+	}
+	check.Coverage = coverage(check.VaultedGrams, check.CustomerGrams)
+	check.Status = s.status(check.VaultedGrams, check.CustomerGrams)
+	// Once an alert has gone out, later checks in the same shortfall inherit
+	// it so operators are not mailed every run.
+	alreadyAlerted := check.Status == models.CoverageBelowThreshold &&
+		previous != nil && previous.Status == models.CoverageBelowThreshold && previous.AlertedAt != nil
+	if alreadyAlerted {
+		check.AlertedAt = previous.AlertedAt
+	}
+	if err := s.repo.SaveCheck(ctx, check); err != nil {
+		return fmt.Errorf("failed to store coverage check: %w", err)
+	}
+
+	if check.Status != models.CoverageBelowThreshold {
+		return nil
+	}
+	slog.WarnContext(ctx, "custody: gold coverage below threshold",
+		"coverage", check.Coverage, "threshold", check.Threshold, "vaulted_grams", check.VaultedGrams, "customer_grams", check.CustomerGrams)
+	if alreadyAlerted {
+		return nil
+	}
+
+	if err := s.audit.Record(ctx, 0, "custody.coverage_alert", "coverage_check", strconv.FormatUint(uint64(check.ID), 10), check); err != nil {
+		return err
+	}
+	if s.alertEmail != "" {
+		body := fmt.Sprintf("Vaulted gold covers %.2f%% of customer balances, below the %.2f%% threshold.\n\n"+
+			"Customer balances: %.4f g\nVaulted fine gold: %.4f g\nShortfall: %.4f g\n",
+			check.Coverage*100, check.Threshold*100, check.CustomerGrams, check.VaultedGrams,
+			check.CustomerGrams*check.Threshold-check.VaultedGrams)
+		if err := s.mailer.SendEmail(ctx, s.alertEmail, "Gold reserve coverage below threshold", body); err != nil {
+			return fmt.Errorf("failed to send coverage alert: %w", err)
+		}
+	}
+
+	// Only a delivered alert counts; after a failure the next run tries again.
+	now := time.Now()
+	if err := s.repo.MarkCheckAlerted(ctx, check.ID, now); err != nil {
+		return fmt.Errorf("failed to mark coverage check alerted: %w", err)
+	}
+	check.AlertedAt = &now
+	return nil
+}
+
+func (s *service) ListChecks(ctx context.Context, limit int) ([]models.CoverageCheck, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.ListChecks(ctx, limit)
+}
+
+// ProofOfReserves is computed live, listing every bar that backs customer
+// balances.
+func (s *service) ProofOfReserves(ctx context.Context) (*ProofOfReserves, error) {
+	customerGrams, holders, err := s.repo.CustomerGrams(ctx)
+	if err != nil {
+		return nil, err
+	}
+	holdings, err := s.repo.VaultHoldings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bars, err := s.repo.ListBars(ctx, BarFilter{Status: models.GoldBarInVault})
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &ProofOfReserves{
+		GeneratedAt:      time.Now(),
+		CustomerGrams:    customerGrams,
+		CustomerAccounts: holders,
+		VaultedGrams:     totalFineGrams(holdings),
+		Threshold:        s.threshold,
+		Vaults:           holdings,
+		Bars:             make([]BarSummary, 0, len(bars)),
+	}
+	proof.Coverage = coverage(proof.VaultedGrams, proof.CustomerGrams)
+	proof.Status = s.status(proof.VaultedGrams, proof.CustomerGrams)
+	for _, bar := range bars {
+		proof.Bars = append(proof.Bars, BarSummary{
+			SerialNumber: bar.SerialNumber,
+			Refiner:      bar.Refiner,
+			WeightGrams:  bar.WeightGrams,
+			Purity:       bar.Purity,
+			FineGrams:    bar.FineGrams,
+			Vault:        bar.Vault,
+		})
+	}
+	return proof, nil
+}
+
+func (s *service) status(vaulted, owed float64) string {
+	if owed > 0 && coverage(vaulted, owed) < s.threshold {
+		return models.CoverageBelowThreshold
+	}
+	return models.CoverageOK
+}
+
+func totalFineGrams(holdings []VaultHolding) float64 {
+	total := 0.0
+	for _, holding := range holdings {
+		total += holding.FineGrams
+	}
+	return total
+}
+
+// coverage is vaulted over owed, reported as 1 while nothing is owed.
+func coverage(vaulted, owed float64) float64 {
+	if owed <= 0 {
+		return 1
+	}
+	return math.Round(vaulted/owed*1000000) / 1000000
+}
@@ -0,0 +1,99 @@
+package custody
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/models"
+)
+
+// shortfallRepository always reports 100g owed against 50g vaulted.
+type shortfallRepository struct {
+	Repository
+	checks []*models.CoverageCheck
+}
+
+func (r *shortfallRepository) CustomerGrams(ctx context.Context) (float64, int64, error) {
+	return 100, 1, nil
+}
+
+func (r *shortfallRepository) VaultHoldings(ctx context.Context) ([]VaultHolding, error) {
+	return []VaultHolding{{Vault: "main", FineGrams: 50}}, nil
+}
+
+func (r *shortfallRepository) LatestCheck(ctx context.Context) (*models.CoverageCheck, error) {
+	if len(r.checks) == 0 {
+		return nil, nil
+	}
+	latest := *r.checks[len(r.checks)-1]
+	return &latest, nil
+}
+
+func (r *shortfallRepository) SaveCheck(ctx context.Context, check *models.CoverageCheck) error {
+	check.ID = uint(len(r.checks) + 1)
+	r.checks = append(r.checks, check)
+	return nil
+}
+
+func (r *shortfallRepository) MarkCheckAlerted(ctx context.Context, id uint, at time.Time) error {
+	r.checks[id-1].AlertedAt = &at
+	return nil
+}
+
+type flakyMailer struct {
+	fail bool
+	sent int
+}
+
+func (m *flakyMailer) SendEmail(ctx context.Context, to, subject, body string) error {
+	if m.fail {
+		return errors.New("smtp unavailable")
+	}
+	m.sent++
+	return nil
+}
+
+type nopAudit struct{ audit.Service }
+
+func (nopAudit) Record(ctx context.Context, actorID uint, action, targetType, targetID string, details interface{}) error {
+	return nil
+}
+
+func TestCheckCoverageRetriesAlertUntilItIsSent(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Custody.CoverageThreshold = 1
+	cfg.Custody.AlertEmail = "ops@example.com"
+	repo := &shortfallRepository{}
+	mailer := &flakyMailer{fail: true}
+	svc := NewService(repo, nopAudit{}, mailer, cfg)
+	ctx := context.Background()
+
+	if err := svc.CheckCoverage(ctx, time.Now()); err == nil {
+		t.Fatal("failed alert reported success")
+	}
+	if repo.checks[0].AlertedAt != nil {
+		t.Fatal("check marked alerted although the email failed")
+	}
+
+	mailer.fail = false
+	if err := svc.CheckCoverage(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if mailer.sent != 1 || repo.checks[1].AlertedAt == nil {
+		t.Fatalf("sent = %d, alerted_at = %v; want the second run to deliver the alert", mailer.sent, repo.checks[1].AlertedAt)
+	}
+
+	if err := svc.CheckCoverage(ctx, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if mailer.sent != 1 {
+		t.Fatalf("sent = %d; an ongoing shortfall was alerted twice", mailer.sent)
+	}
+	if repo.checks[2].AlertedAt == nil {
+		t.Fatal("later check in the same shortfall lost the alert time")
+	}
+}
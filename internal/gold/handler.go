@@ -16,7 +16,7 @@ func NewHandler(service *Service) *Handler {
 }
 
 func (h *Handler) GetCurrentPrice(c *gin.Context) {
-	price, updatedAt, err := h.service.GetCurrentPrice()
+	price, updatedAt, err := h.service.GetCurrentPrice(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "price not available"})
 		return
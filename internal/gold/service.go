@@ -4,17 +4,22 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/919Umesh/gold_go/config"
 	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/metrics"
+	"github.com/919Umesh/gold_go/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
 )
 
 type PriceFetcher interface {
+	Name() string
 	FetchPrice(ctx context.Context) (float64, error)
 }
 
@@ -25,10 +30,28 @@ type Service struct {
 	fetcher    PriceFetcher
 }
 
+const priceCacheTTL = time.Minute
+
 type PriceCache struct {
-	price float64
-	mu    sync.RWMutex
-	time  time.Time
+	price    float64
+	mu       sync.RWMutex
+	time     time.Time
+	loadedAt time.Time
+}
+
+func (c *PriceCache) get() (float64, time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	fresh := c.price != 0 && time.Since(c.loadedAt) < priceCacheTTL
+	return c.price, c.time, fresh
+}
+
+func (c *PriceCache) set(price float64, updatedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.price = price
+	c.time = updatedAt
+	c.loadedAt = time.Now()
 }
 
 func NewService(db *gorm.DB, cfg *config.Config) *Service {
@@ -39,53 +62,74 @@ func NewService(db *gorm.DB, cfg *config.Config) *Service {
 	}
 
 	service.fetcher = &MockPriceFetcher{}
+	if cfg.Pricing.Source == "http" {
+		service.fetcher = NewRealPriceFetcher(cfg.Pricing.ProviderURL)
+	}
 
 	return service
 }
 
-func (s *Service) StartPriceUpdater(ctx context.Context) {
-	ticker := time.NewTicker(600 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			price, err := s.fetcher.FetchPrice(ctx)
-			if err != nil {
-				log.Printf("Failed to fetch gold price: %v", err)
-				continue
-			}
-
-			s.priceCache.mu.Lock()
-			s.priceCache.price = price
-			s.priceCache.time = time.Now()
-			s.priceCache.mu.Unlock()
-
-			goldPrice := &models.GoldPrice{
-				PricePerGram: price,
-				Source:       "provider",
-			}
-			if err := s.db.Create(goldPrice).Error; err != nil {
-				log.Printf("Failed to save gold price: %v", err)
-			}
-
-			log.Printf("Gold price updated: %.2f", price)
-
-		case <-ctx.Done():
-			return
-		}
+// UpdatePrice is run by the scheduler on a single instance; the others pick
+// the new price up from the database through GetCurrentPrice.
+func (s *Service) UpdatePrice(ctx context.Context, scheduledFor time.Time) error {
+	provider := s.fetcher.Name()
+	fetchCtx, span := tracing.Tracer().Start(ctx, "gold.fetch_price", trace.WithAttributes(attribute.String("provider", provider)))
+	start := time.Now()
+	price, err := s.fetcher.FetchPrice(fetchCtx)
+	metrics.PriceFetchDuration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+	tracing.End(span, err)
+	if err != nil {
+		metrics.PriceFetchErrors.WithLabelValues(provider).Inc()
+		return fmt.Errorf("failed to fetch gold price: %w", err)
+	}
+
+	price = models.RoundPrice(price)
+	goldPrice := &models.GoldPrice{
+		PricePerGram: price,
+		Source:       "provider",
+	}
+	if err := s.db.WithContext(ctx).Create(goldPrice).Error; err != nil {
+		return fmt.Errorf("failed to save gold price: %w", err)
 	}
+
+	s.priceCache.set(price, goldPrice.UpdatedAt)
+	slog.InfoContext(ctx, "gold: price updated", "price_per_gram", price)
+	return nil
 }
 
-func (s *Service) GetCurrentPrice() (float64, time.Time, error) {
-	s.priceCache.mu.RLock()
-	defer s.priceCache.mu.RUnlock()
+func (s *Service) GetCurrentPrice(ctx context.Context) (float64, time.Time, error) {
+	price, updatedAt, fresh := s.priceCache.get()
+	if fresh {
+		return price, updatedAt, nil
+	}
 
-	if s.priceCache.price == 0 {
+	var latest models.GoldPrice
+	if err := s.db.WithContext(ctx).Order("updated_at desc").First(&latest).Error; err != nil {
+		if price != 0 {
+			return price, updatedAt, nil
+		}
 		return 0, time.Time{}, fmt.Errorf("price not available")
 	}
 
-	return s.priceCache.price, s.priceCache.time, nil
+	s.priceCache.set(latest.PricePerGram, latest.UpdatedAt)
+	return latest.PricePerGram, latest.UpdatedAt, nil
+}
+
+// ImportPrices loads historical prices, all or none. The cached current
+// price is left alone; it expires on its own.
+func (s *Service) ImportPrices(prices []models.GoldPrice) (int, error) {
+	for _, price := range prices {
+		if price.PricePerGram <= 0 {
+			return 0, fmt.Errorf("invalid price %.4f at %s", price.PricePerGram, price.UpdatedAt.Format(time.RFC3339))
+		}
+	}
+	if len(prices) == 0 {
+		return 0, nil
+	}
+	if err := s.db.CreateInBatches(prices, 500).Error; err != nil {
+		return 0, fmt.Errorf("failed to import gold prices: %w", err)
+	}
+	return len(prices), nil
 }
 
 func (s *Service) GetPriceHistory(days int) ([]models.GoldPrice, error) {
@@ -101,6 +145,10 @@ func (s *Service) GetPriceHistory(days int) ([]models.GoldPrice, error) {
 
 type MockPriceFetcher struct{}
 
+func (m *MockPriceFetcher) Name() string {
+	return "mock"
+}
+
 func (m *MockPriceFetcher) FetchPrice(ctx context.Context) (float64, error) {
 	basePrice := 6500.0
 	variation := (float64(time.Now().Unix()%100) - 50) / 100.0
@@ -112,6 +160,17 @@ type RealPriceFetcher struct {
 	url    string
 }
 
+func NewRealPriceFetcher(url string) *RealPriceFetcher {
+	return &RealPriceFetcher{
+		client: &http.Client{Timeout: 10 * time.Second, Transport: tracing.Transport(nil)},
+		url:    url,
+	}
+}
+
+func (r *RealPriceFetcher) Name() string {
+	return "http"
+}
+
 func (r *RealPriceFetcher) FetchPrice(ctx context.Context) (float64, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", r.url, nil)
 	if err != nil {
@@ -0,0 +1,203 @@
+package kyc
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+type SubmitRequest struct {
+	CitizenshipNumber string `form:"citizenship_number" binding:"required,min=5,max=30"`
+	DateOfBirth       string `form:"date_of_birth" binding:"required"`
+	Address           string `form:"address" binding:"required,min=5,max=300"`
+}
+
+type DecisionRequest struct {
+	Status string `json:"status" binding:"required,oneof=verified rejected"`
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
+
+func (h *Handler) Submit(c *gin.Context) {
+	var req SubmitRequest
+	if err := c.ShouldBind(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dob, err := time.Parse("2006-01-02", req.DateOfBirth)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "date_of_birth must be YYYY-MM-DD"})
+		return
+	}
+
+	uploads := make(map[models.KYCDocumentKind]Upload)
+	for _, kind := range models.KYCDocumentKinds {
+		header, err := c.FormFile(string(kind))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": ErrMissingDocument.Error()})
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read " + string(kind)})
+			return
+		}
+		defer file.Close()
+		uploads[kind] = Upload{Reader: file, Size: header.Size}
+	}
+
+	submission, err := h.service.Submit(c.Request.Context(), c.GetUint("user_id"), SubmissionInput{
+		CitizenshipNumber: req.CitizenshipNumber,
+		DateOfBirth:       dob,
+		Address:           req.Address,
+	}, uploads)
+	if err != nil {
+		writeError(c, err, "kyc submission failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "kyc submitted for review",
+		"submission": submission,
+	})
+}
+
+func (h *Handler) GetStatus(c *gin.Context) {
+	submission, err := h.service.GetStatus(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		if err == ErrSubmissionNotFound {
+			c.JSON(http.StatusOK, gin.H{"status": models.KYCStatusPending})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch kyc status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     submission.Status,
+		"submission": submission,
+	})
+}
+
+func (h *Handler) Queue(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	submissions, err := h.service.Queue(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch kyc queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"submissions": submissions})
+}
+
+func (h *Handler) GetSubmission(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	submission, err := h.service.GetSubmission(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, err, "failed to fetch kyc submission")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"submission": submission})
+}
+
+func (h *Handler) GetDocument(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	documentID, ok := parseID(c, "document_id")
+	if !ok {
+		return
+	}
+
+	document, reader, err := h.service.OpenDocument(c.Request.Context(), c.GetUint("user_id"), id, documentID)
+	if err != nil {
+		writeError(c, err, "failed to fetch kyc document")
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Cache-Control", "no-store")
+	c.DataFromReader(http.StatusOK, document.Size, document.ContentType, io.Reader(reader), nil)
+}
+
+func (h *Handler) Claim(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.Claim(c.Request.Context(), c.GetUint("user_id"), id); err != nil {
+		writeError(c, err, "kyc claim failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "kyc submission under review"})
+}
+
+func (h *Handler) Decide(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req DecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Decide(c.Request.Context(), c.GetUint("user_id"), id, req.Status, req.Reason); err != nil {
+		writeError(c, err, "kyc decision failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "kyc submission " + req.Status})
+}
+
+func parseID(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case ErrSubmissionNotFound, ErrDocumentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case ErrAlreadyVerified, ErrSubmissionPending, ErrInvalidTransition:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case ErrOwnSubmission:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case ErrMissingDocument, ErrUnsupportedDocument, ErrUnderage, ErrReasonRequired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case ErrDocumentTooLarge:
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
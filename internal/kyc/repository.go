@@ -0,0 +1,148 @@
+package kyc
+
+import (
+	"context"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/uow"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	WithTx(tx uow.Tx) Repository
+
+	FindUser(ctx context.Context, userID uint) (*models.User, error)
+	MarkUserSubmitted(ctx context.Context, userID uint) (bool, error)
+	SetUserStatus(ctx context.Context, userID uint, status string) error
+	CreateSubmission(ctx context.Context, submission *models.KYCSubmission) error
+	LatestSubmission(ctx context.Context, userID uint) (*models.KYCSubmission, error)
+	FindSubmission(ctx context.Context, id uint) (*models.KYCSubmission, error)
+	ListQueue(ctx context.Context, limit int) ([]models.KYCSubmission, error)
+	ClaimSubmission(ctx context.Context, id, reviewerID uint, at time.Time) (bool, error)
+	DecideSubmission(ctx context.Context, id, reviewerID uint, status, reason string, at time.Time) (bool, error)
+
+	FindDocument(ctx context.Context, submissionID, documentID uint) (*models.KYCDocument, error)
+	ListDocumentsForPurge(ctx context.Context, status string, reviewedBefore time.Time, limit int) ([]models.KYCDocument, error)
+	MarkDocumentPurged(ctx context.Context, id uint, at time.Time) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) WithTx(tx uow.Tx) Repository {
+	return &repository{db: tx.DB()}
+}
+
+func (r *repository) FindUser(ctx context.Context, userID uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// MarkUserSubmitted moves the user to submitted, conditional on them having
+// nothing pending or verified; the row lock makes a concurrent submit wait
+// and then match nothing. It reports false when the user was not eligible.
+func (r *repository) MarkUserSubmitted(ctx context.Context, userID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND kyc_status NOT IN ?", userID,
+			[]string{models.KYCStatusSubmitted, models.KYCStatusUnderReview, models.KYCStatusVerified}).
+		Update("kyc_status", models.KYCStatusSubmitted)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *repository) SetUserStatus(ctx context.Context, userID uint, status string) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("kyc_status", status).Error
+}
+
+func (r *repository) CreateSubmission(ctx context.Context, submission *models.KYCSubmission) error {
+	return r.db.WithContext(ctx).Create(submission).Error
+}
+
+func (r *repository) LatestSubmission(ctx context.Context, userID uint) (*models.KYCSubmission, error) {
+	var submission models.KYCSubmission
+	err := r.db.WithContext(ctx).Preload("Documents").
+		Where("user_id = ?", userID).
+		Order("id desc").
+		First(&submission).Error
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+func (r *repository) FindSubmission(ctx context.Context, id uint) (*models.KYCSubmission, error) {
+	var submission models.KYCSubmission
+	if err := r.db.WithContext(ctx).Preload("Documents").First(&submission, id).Error; err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+func (r *repository) ListQueue(ctx context.Context, limit int) ([]models.KYCSubmission, error) {
+	var submissions []models.KYCSubmission
+	err := r.db.WithContext(ctx).Where("status IN ?", []string{models.KYCStatusSubmitted, models.KYCStatusUnderReview}).
+		Order("submitted_at").
+		Limit(limit).
+		Find(&submissions).Error
+	return submissions, err
+}
+
+// ClaimSubmission and DecideSubmission are conditional on the current
+// status, so two reviewers cannot act on the same submission at once. They
+// report false when the submission was not in the expected state.
+func (r *repository) ClaimSubmission(ctx context.Context, id, reviewerID uint, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.KYCSubmission{}).
+		Where("id = ? AND status = ?", id, models.KYCStatusSubmitted).
+		Updates(map[string]interface{}{
+			"status":            models.KYCStatusUnderReview,
+			"reviewer_id":       reviewerID,
+			"review_started_at": at,
+			"updated_at":        at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *repository) DecideSubmission(ctx context.Context, id, reviewerID uint, status, reason string, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.KYCSubmission{}).
+		Where("id = ? AND status = ? AND reviewer_id = ?", id, models.KYCStatusUnderReview, reviewerID).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reason":      reason,
+			"reviewed_at": at,
+			"updated_at":  at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *repository) FindDocument(ctx context.Context, submissionID, documentID uint) (*models.KYCDocument, error) {
+	var document models.KYCDocument
+	err := r.db.WithContext(ctx).Where("id = ? AND submission_id = ?", documentID, submissionID).First(&document).Error
+	if err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (r *repository) ListDocumentsForPurge(ctx context.Context, status string, reviewedBefore time.Time, limit int) ([]models.KYCDocument, error) {
+	var documents []models.KYCDocument
+	err := r.db.WithContext(ctx).Joins("JOIN kyc_submissions ON kyc_submissions.id = kyc_documents.submission_id").
+		Where("kyc_submissions.status = ? AND kyc_submissions.reviewed_at < ? AND kyc_documents.purged_at IS NULL", status, reviewedBefore).
+		Order("kyc_documents.id").
+		Limit(limit).
+		Find(&documents).Error
+	return documents, err
+}
+
+func (r *repository) MarkDocumentPurged(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.KYCDocument{}).
+		Where("id = ?", id).
+		Update("purged_at", at).Error
+}
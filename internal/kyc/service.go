@@ -0,0 +1,389 @@
+package kyc
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/blobstore"
+	"github.com/919Umesh/gold_go/pkg/uow"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSubmissionNotFound  = errors.New("kyc submission not found")
+	ErrDocumentNotFound    = errors.New("kyc document not found")
+	ErrAlreadyVerified     = errors.New("kyc already verified")
+	ErrSubmissionPending   = errors.New("a kyc submission is already awaiting review")
+	ErrInvalidTransition   = errors.New("kyc submission is not in a state that allows this action")
+	ErrOwnSubmission       = errors.New("reviewers cannot review their own submission")
+	ErrMissingDocument     = errors.New("document_front, document_back and selfie are required")
+	ErrDocumentTooLarge    = errors.New("document too large")
+	ErrUnsupportedDocument = errors.New("documents must be JPEG, PNG or PDF")
+	ErrUnderage            = errors.New("applicant must be at least 18 years old")
+	ErrReasonRequired      = errors.New("a reason is required")
+)
+
+const purgeBatchSize = 200
+
+var allowedContentTypes = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+type SubmissionInput struct {
+	CitizenshipNumber string
+	DateOfBirth       time.Time
+	Address           string
+}
+
+type Upload struct {
+	Reader io.Reader
+	Size   int64
+}
+
+type Service interface {
+	Submit(ctx context.Context, userID uint, input SubmissionInput, uploads map[models.KYCDocumentKind]Upload) (*models.KYCSubmission, error)
+	GetStatus(ctx context.Context, userID uint) (*models.KYCSubmission, error)
+
+	Queue(ctx context.Context, limit int) ([]models.KYCSubmission, error)
+	GetSubmission(ctx context.Context, id uint) (*models.KYCSubmission, error)
+	OpenDocument(ctx context.Context, actorID, submissionID, documentID uint) (*models.KYCDocument, io.ReadCloser, error)
+	Claim(ctx context.Context, actorID, submissionID uint) error
+	Decide(ctx context.Context, actorID, submissionID uint, status, reason string) error
+
+	PurgeExpiredDocuments(ctx context.Context, scheduledFor time.Time) error
+}
+
+type service struct {
+	repo              Repository
+	work              *uow.UnitOfWork
+	blobs             blobstore.Store
+	audit             audit.Service
+	maxDocumentSize   int64
+	rejectedRetention time.Duration
+	verifiedRetention time.Duration
+}
+
+func NewService(repo Repository, work *uow.UnitOfWork, blobs blobstore.Store, auditService audit.Service, cfg *config.Config) Service {
+	return &service{
+		repo:              repo,
+		work:              work,
+		blobs:             blobs,
+		audit:             auditService,
+		maxDocumentSize:   int64(cfg.KYC.MaxDocumentMB) << 20,
+		rejectedRetention: time.Duration(cfg.KYC.RejectedRetentionDays) * 24 * time.Hour,
+		verifiedRetention: time.Duration(cfg.KYC.VerifiedRetentionDays) * 24 * time.Hour,
+	}
+}
+
+func (s *service) Submit(ctx context.Context, userID uint, input SubmissionInput, uploads map[models.KYCDocumentKind]Upload) (*models.KYCSubmission, error) {
+	user, err := s.repo.FindUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	switch user.KYCStatus {
+	case models.KYCStatusVerified:
+		return nil, ErrAlreadyVerified
+	case models.KYCStatusSubmitted, models.KYCStatusUnderReview:
+		return nil, ErrSubmissionPending
+	}
+
+	if input.DateOfBirth.AddDate(18, 0, 0).After(time.Now()) {
+		return nil, ErrUnderage
+	}
+	for _, kind := range models.KYCDocumentKinds {
+		upload, ok := uploads[kind]
+		if !ok || upload.Size == 0 {
+			return nil, ErrMissingDocument
+		}
+		if upload.Size > s.maxDocumentSize {
+			return nil, ErrDocumentTooLarge
+		}
+	}
+
+	now := time.Now()
+	submission := &models.KYCSubmission{
+		UserID:            userID,
+		CitizenshipNumber: input.CitizenshipNumber,
+		DateOfBirth:       input.DateOfBirth,
+		Address:           input.Address,
+		Status:            models.KYCStatusSubmitted,
+		SubmittedAt:       now,
+	}
+
+	// Blobs are written before the rows so a committed submission never
+	// points at missing files; on failure the stored blobs are removed.
+	batch := uuid.New().String()
+	for _, kind := range models.KYCDocumentKinds {
+		document, err := s.storeDocument(ctx, userID, batch, kind, uploads[kind])
+		if err != nil {
+			s.deleteBlobs(ctx, submission.Documents)
+			return nil, err
+		}
+		submission.Documents = append(submission.Documents, *document)
+	}
+
+	created := false
+	err = s.work.Do(ctx, func(ctx context.Context, tx uow.Tx) error {
+		repo := s.repo.WithTx(tx)
+		marked, err := repo.MarkUserSubmitted(ctx, userID)
+		if err != nil || !marked {
+			return err
+		}
+		created = true
+		return repo.CreateSubmission(ctx, submission)
+	})
+	if err != nil {
+		s.deleteBlobs(ctx, submission.Documents)
+		return nil, fmt.Errorf("kyc submission failed: %w", err)
+	}
+	if !created {
+		// Another submission won the race since the check above.
+		s.deleteBlobs(ctx, submission.Documents)
+		return nil, s.ineligibleError(ctx, userID)
+	}
+	return submission, nil
+}
+
+func (s *service) ineligibleError(ctx context.Context, userID uint) error {
+	user, err := s.repo.FindUser(ctx, userID)
+	if err == nil && user.KYCStatus == models.KYCStatusVerified {
+		return ErrAlreadyVerified
+	}
+	return ErrSubmissionPending
+}
+
+func (s *service) GetStatus(ctx context.Context, userID uint) (*models.KYCSubmission, error) {
+	submission, err := s.repo.LatestSubmission(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubmissionNotFound
+		}
+		return nil, err
+	}
+	return submission, nil
+}
+
+func (s *service) Queue(ctx context.Context, limit int) ([]models.KYCSubmission, error) {
+	return s.repo.ListQueue(ctx, limit)
+}
+
+func (s *service) GetSubmission(ctx context.Context, id uint) (*models.KYCSubmission, error) {
+	submission, err := s.repo.FindSubmission(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubmissionNotFound
+		}
+		return nil, err
+	}
+	return submission, nil
+}
+
+// OpenDocument streams a stored document to a reviewer. Every access is
+// written to the audit log.
+func (s *service) OpenDocument(ctx context.Context, actorID, submissionID, documentID uint) (*models.KYCDocument, io.ReadCloser, error) {
+	document, err := s.repo.FindDocument(ctx, submissionID, documentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrDocumentNotFound
+		}
+		return nil, nil, err
+	}
+	if document.PurgedAt != nil {
+		return nil, nil, ErrDocumentNotFound
+	}
+
+	reader, err := s.blobs.Get(ctx, document.BlobKey)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			return nil, nil, ErrDocumentNotFound
+		}
+		return nil, nil, err
+	}
+
+	if err := s.audit.Record(ctx, actorID, "kyc.document_view", "kyc_submission", strconv.FormatUint(uint64(submissionID), 10), map[string]interface{}{
+		"document_id": document.ID,
+		"kind":        document.Kind,
+	}); err != nil {
+		reader.Close()
+		return nil, nil, err
+	}
+	return document, reader, nil
+}
+
+func (s *service) Claim(ctx context.Context, actorID, submissionID uint) error {
+	submission, err := s.GetSubmission(ctx, submissionID)
+	if err != nil {
+		return err
+	}
+	if submission.UserID == actorID {
+		return ErrOwnSubmission
+	}
+
+	claimed := false
+	err = s.work.Do(ctx, func(ctx context.Context, tx uow.Tx) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		claimed, err = repo.ClaimSubmission(ctx, submissionID, actorID, time.Now())
+		if err != nil || !claimed {
+			return err
+		}
+		return repo.SetUserStatus(ctx, submission.UserID, models.KYCStatusUnderReview)
+	})
+	if err != nil {
+		return fmt.Errorf("kyc claim failed: %w", err)
+	}
+	if !claimed {
+		return ErrInvalidTransition
+	}
+
+	return s.audit.Record(ctx, actorID, "kyc.claim", "kyc_submission", strconv.FormatUint(uint64(submissionID), 10), map[string]interface{}{
+		"user_id": submission.UserID,
+	})
+}
+
+// Decide records the outcome of a review. Only the reviewer who claimed the
+// submission can decide it, and both outcomes need a reason.
+func (s *service) Decide(ctx context.Context, actorID, submissionID uint, status, reason string) error {
+	if status != models.KYCStatusVerified && status != models.KYCStatusRejected {
+		return ErrInvalidTransition
+	}
+	if reason == "" {
+		return ErrReasonRequired
+	}
+
+	submission, err := s.GetSubmission(ctx, submissionID)
+	if err != nil {
+		return err
+	}
+
+	decided := false
+	err = s.work.Do(ctx, func(ctx context.Context, tx uow.Tx) error {
+		repo := s.repo.WithTx(tx)
+		var err error
+		decided, err = repo.DecideSubmission(ctx, submissionID, actorID, status, reason, time.Now())
+		if err != nil || !decided {
+			return err
+		}
+		return repo.SetUserStatus(ctx, submission.UserID, status)
+	})
+	if err != nil {
+		return fmt.Errorf("kyc decision failed: %w", err)
+	}
+	if !decided {
+		return ErrInvalidTransition
+	}
+
+	return s.audit.Record(ctx, actorID, "kyc."+status, "kyc_submission", strconv.FormatUint(uint64(submissionID), 10), map[string]interface{}{
+		"user_id": submission.UserID,
+		"reason":  reason,
+	})
+}
+
+// PurgeExpiredDocuments deletes the files of reviewed submissions once their
+// retention period is over: KYC_REJECTED_RETENTION_DAYS after a rejection
+// and KYC_VERIFIED_RETENTION_DAYS after a verification. The document rows
+// stay, marked as purged, so the review history remains intact.
+func (s *service) PurgeExpiredDocuments(ctx context.Context, scheduledFor time.Time) error {
+	rules := map[string]time.Duration{
+		models.KYCStatusRejected: s.rejectedRetention,
+		models.KYCStatusVerified: s.verifiedRetention,
+	}
+
+	purged := 0
+	for status, retention := range rules {
+		for {
+			documents, err := s.repo.ListDocumentsForPurge(ctx, status, scheduledFor.Add(-retention), purgeBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, document := range documents {
+				if err := s.blobs.Delete(ctx, document.BlobKey); err != nil {
+					return fmt.Errorf("failed to delete kyc document %d: %w", document.ID, err)
+				}
+				if err := s.repo.MarkDocumentPurged(ctx, document.ID, time.Now()); err != nil {
+					return err
+				}
+				purged++
+			}
+			if len(documents) < purgeBatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	if purged > 0 {
+		slog.InfoContext(ctx, "kyc: purged expired documents", "count", purged)
+	}
+	return ctx.Err()
+}
+
+func (s *service) storeDocument(ctx context.Context, userID uint, batch string, kind models.KYCDocumentKind, upload Upload) (*models.KYCDocument, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(upload.Reader, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read %s: %w", kind, err)
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	ext, ok := allowedContentTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedDocument
+	}
+
+	key := fmt.Sprintf("kyc/%d/%s/%s%s", userID, batch, kind, ext)
+	hash := sha256.New()
+	counter := &countingReader{r: io.TeeReader(io.MultiReader(bytes.NewReader(head), upload.Reader), hash)}
+	limited := io.LimitReader(counter, s.maxDocumentSize+1)
+
+	if err := s.blobs.Put(ctx, key, limited); err != nil {
+		return nil, fmt.Errorf("failed to store %s: %w", kind, err)
+	}
+	if counter.n > s.maxDocumentSize {
+		s.blobs.Delete(ctx, key)
+		return nil, ErrDocumentTooLarge
+	}
+
+	return &models.KYCDocument{
+		UserID:      userID,
+		Kind:        kind,
+		BlobKey:     key,
+		ContentType: contentType,
+		Size:        counter.n,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+func (s *service) deleteBlobs(ctx context.Context, documents []models.KYCDocument) {
+	for _, document := range documents {
+		if err := s.blobs.Delete(ctx, document.BlobKey); err != nil {
+			slog.ErrorContext(ctx, "kyc: delete orphaned blob failed", "blob_key", document.BlobKey, "error", err)
+		}
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
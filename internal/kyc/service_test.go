@@ -0,0 +1,98 @@
+package kyc
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/blobstore"
+	"github.com/919Umesh/gold_go/pkg/uow"
+	"github.com/919Umesh/gold_go/pkg/uow/uowtest"
+)
+
+// racingRepository behaves as if another submission for the same user
+// committed between Submit's eligibility check and its insert.
+type racingRepository struct {
+	Repository
+	lookups int
+}
+
+func (r *racingRepository) FindUser(ctx context.Context, userID uint) (*models.User, error) {
+	r.lookups++
+	status := models.KYCStatusPending
+	if r.lookups > 1 {
+		status = models.KYCStatusSubmitted
+	}
+	return &models.User{ID: userID, KYCStatus: status}, nil
+}
+
+func (r *racingRepository) WithTx(tx uow.Tx) Repository { return r }
+
+func (r *racingRepository) MarkUserSubmitted(ctx context.Context, userID uint) (bool, error) {
+	return false, nil
+}
+
+func TestSubmitLosingRaceReportsPendingAndRemovesBlobs(t *testing.T) {
+	root := t.TempDir()
+	blobs, err := blobstore.NewLocalStore(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{}
+	cfg.KYC.MaxDocumentMB = 1
+	db, _ := uowtest.Open(t, nil)
+	svc := NewService(&racingRepository{}, uow.New(db, uow.Options{}), blobs, nil, cfg)
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 64)...)
+	uploads := map[models.KYCDocumentKind]Upload{}
+	for _, kind := range models.KYCDocumentKinds {
+		uploads[kind] = Upload{Reader: bytes.NewReader(png), Size: int64(len(png))}
+	}
+	input := SubmissionInput{CitizenshipNumber: "123", DateOfBirth: time.Now().AddDate(-30, 0, 0), Address: "Kathmandu"}
+
+	if _, err := svc.Submit(context.Background(), 1, input, uploads); !errors.Is(err, ErrSubmissionPending) {
+		t.Fatalf("err = %v, want ErrSubmissionPending", err)
+	}
+
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			t.Errorf("blob left behind: %s", path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecideRollsBackWhenTheUserCannotBeUpdated(t *testing.T) {
+	errStep := errors.New("users table unavailable")
+	gormDB, db := uowtest.Open(t, func(query string, args []driver.NamedValue) (uowtest.Result, error) {
+		switch {
+		case strings.HasPrefix(query, `SELECT * FROM "kyc_submissions"`):
+			return uowtest.Result{
+				Columns: []string{"id", "user_id", "status"},
+				Rows:    [][]driver.Value{{int64(4), int64(9), models.KYCStatusUnderReview}},
+			}, nil
+		case strings.HasPrefix(query, `UPDATE "users"`):
+			return uowtest.Result{}, errStep
+		}
+		return uowtest.Result{RowsAffected: 1}, nil
+	})
+	svc := NewService(NewRepository(gormDB), uow.New(gormDB, uow.Options{}), nil, nil, &config.Config{})
+
+	if err := svc.Decide(context.Background(), 2, 4, models.KYCStatusVerified, "documents match"); !errors.Is(err, errStep) {
+		t.Fatalf("err = %v, want the user update error", err)
+	}
+	if db.Count(`UPDATE "kyc_submissions"`) != 1 || db.Count("COMMIT") != 0 || db.Count("ROLLBACK") != 1 {
+		t.Fatalf("statements = %v, want the decision rolled back", db.Statements())
+	}
+}
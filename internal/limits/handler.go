@@ -0,0 +1,78 @@
+package limits
+
+import (
+	"net/http"
+
+	"github.com/919Umesh/gold_go/models"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+type UpdateLimitRequest struct {
+	PerTransactionNPR   *float64 `json:"per_transaction_npr"`
+	DailyNPR            *float64 `json:"daily_npr"`
+	MonthlyNPR          *float64 `json:"monthly_npr"`
+	PerTransactionGrams *float64 `json:"per_transaction_grams"`
+	DailyGrams          *float64 `json:"daily_grams"`
+	MonthlyGrams        *float64 `json:"monthly_grams"`
+}
+
+func (h *Handler) GetUsage(c *gin.Context) {
+	usage, err := h.service.Usage(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch limits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"limits": usage})
+}
+
+func (h *Handler) ListLimits(c *gin.Context) {
+	limits, err := h.service.ListLimits(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch limits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"limits": limits})
+}
+
+// UpdateLimit replaces every cap of one tier and operation. Omitted or null
+// fields remove that cap.
+func (h *Handler) UpdateLimit(c *gin.Context) {
+	var req UpdateLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, err := h.service.UpdateLimit(c.Request.Context(), c.GetUint("user_id"), c.Param("tier"), c.Param("operation"), models.TransactionLimit{
+		PerTransactionNPR:   req.PerTransactionNPR,
+		DailyNPR:            req.DailyNPR,
+		MonthlyNPR:          req.MonthlyNPR,
+		PerTransactionGrams: req.PerTransactionGrams,
+		DailyGrams:          req.DailyGrams,
+		MonthlyGrams:        req.MonthlyGrams,
+	})
+	if err != nil {
+		switch err {
+		case ErrUnknownTier, ErrUnknownOperation, ErrInvalidLimit:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "limit update failed"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "limit updated",
+		"limit":   limit,
+	})
+}
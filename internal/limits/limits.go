@@ -0,0 +1,96 @@
+package limits
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/models"
+)
+
+const (
+	OperationTopUp    = "topup"
+	OperationBuy      = "buy"
+	OperationSell     = "sell"
+	OperationWithdraw = "withdraw"
+)
+
+var Operations = []string{OperationTopUp, OperationBuy, OperationSell, OperationWithdraw}
+
+// Tiers are the KYC statuses. Users whose status has no limits configured
+// are held to the pending tier.
+var Tiers = []string{
+	models.KYCStatusPending,
+	models.KYCStatusSubmitted,
+	models.KYCStatusUnderReview,
+	models.KYCStatusRejected,
+	models.KYCStatusVerified,
+}
+
+const (
+	PeriodTransaction = "transaction"
+	PeriodDaily       = "daily"
+	PeriodMonthly     = "monthly"
+
+	UnitNPR   = "NPR"
+	UnitGrams = "grams"
+)
+
+// LimitError says which limit stopped an operation and, for daily and
+// monthly caps, when the period rolls over.
+type LimitError struct {
+	Tier      string     `json:"tier"`
+	Operation string     `json:"operation"`
+	Period    string     `json:"period"`
+	Unit      string     `json:"unit"`
+	Limit     float64    `json:"limit"`
+	Used      float64    `json:"used"`
+	Requested float64    `json:"requested"`
+	ResetsAt  *time.Time `json:"resets_at,omitempty"`
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s %s limit of %.2f %s exceeded for kyc tier %s", e.Period, e.Operation, e.Limit, e.Unit, e.Tier)
+}
+
+func amount(v float64) *float64 {
+	return &v
+}
+
+// defaultLimit is the limit seeded for a tier and operation. Withdrawals are
+// in NPR only, capped by the configuration for verified users and blocked
+// for every other tier.
+func defaultLimit(tier, operation string, cfg config.LimitsConfig) models.TransactionLimit {
+	limit := models.TransactionLimit{Tier: tier, Operation: operation}
+
+	if operation == OperationWithdraw {
+		if tier != models.KYCStatusVerified {
+			limit.PerTransactionNPR = amount(0)
+			return limit
+		}
+		limit.PerTransactionNPR = amount(cfg.WithdrawPerTransactionNPR)
+		limit.DailyNPR = amount(cfg.WithdrawDailyNPR)
+		limit.MonthlyNPR = amount(cfg.WithdrawMonthlyNPR)
+		return limit
+	}
+
+	if tier == models.KYCStatusVerified {
+		limit.PerTransactionNPR = amount(1000000)
+		limit.DailyNPR = amount(2000000)
+		limit.MonthlyNPR = amount(10000000)
+		if operation != OperationTopUp {
+			limit.DailyGrams = amount(200)
+			limit.MonthlyGrams = amount(1000)
+		}
+		return limit
+	}
+
+	limit.PerTransactionNPR = amount(10000)
+	limit.DailyNPR = amount(25000)
+	limit.MonthlyNPR = amount(100000)
+	if operation != OperationTopUp {
+		limit.DailyGrams = amount(2)
+		limit.MonthlyGrams = amount(10)
+	}
+	return limit
+}
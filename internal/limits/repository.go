@@ -0,0 +1,54 @@
+package limits
+
+import (
+	"context"
+
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	ListLimits(ctx context.Context) ([]models.TransactionLimit, error)
+	FindLimit(ctx context.Context, tier, operation string) (*models.TransactionLimit, error)
+	SaveLimit(ctx context.Context, limit *models.TransactionLimit) error
+	CreateMissing(ctx context.Context, limits []models.TransactionLimit) error
+	GetKYCStatus(ctx context.Context, userID uint) (string, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) ListLimits(ctx context.Context) ([]models.TransactionLimit, error) {
+	var limits []models.TransactionLimit
+	err := r.db.WithContext(ctx).Order("tier, operation").Find(&limits).Error
+	return limits, err
+}
+
+func (r *repository) FindLimit(ctx context.Context, tier, operation string) (*models.TransactionLimit, error) {
+	var limit models.TransactionLimit
+	err := r.db.WithContext(ctx).Where("tier = ? AND operation = ?", tier, operation).First(&limit).Error
+	if err != nil {
+		return nil, err
+	}
+	return &limit, nil
+}
+
+func (r *repository) SaveLimit(ctx context.Context, limit *models.TransactionLimit) error {
+	return r.db.WithContext(ctx).Save(limit).Error
+}
+
+func (r *repository) CreateMissing(ctx context.Context, limits []models.TransactionLimit) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&limits).Error
+}
+
+func (r *repository) GetKYCStatus(ctx context.Context, userID uint) (string, error) {
+	var status string
+	err := r.db.WithContext(ctx).Model(&models.User{}).Select("kyc_status").Where("id = ?", userID).Row().Scan(&status)
+	return status, err
+}
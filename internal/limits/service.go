@@ -0,0 +1,399 @@
+package limits
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownTier      = errors.New("unknown kyc tier")
+	ErrUnknownOperation = errors.New("unknown operation")
+	ErrInvalidLimit     = errors.New("limits cannot be negative")
+)
+
+const cacheTTL = 30 * time.Second
+
+// Usage is kept in integer minor units (paisa and 0.1 mg) so repeated
+// increments do not drift.
+const (
+	nprScale   = 100
+	gramsScale = 10000
+)
+
+// reserveScript checks the daily and monthly caps and adds the amounts in
+// one step, so concurrent requests cannot both squeeze under a cap. A cap
+// of -1 means unlimited.
+const reserveScript = `
+local npr = tonumber(ARGV[1])
+local grams = tonumber(ARGV[2])
+local dayNPR = tonumber(redis.call('HGET', KEYS[1], 'npr') or '0')
+local monthNPR = tonumber(redis.call('HGET', KEYS[2], 'npr') or '0')
+local dayGrams = tonumber(redis.call('HGET', KEYS[1], 'grams') or '0')
+local monthGrams = tonumber(redis.call('HGET', KEYS[2], 'grams') or '0')
+if tonumber(ARGV[3]) >= 0 and dayNPR + npr > tonumber(ARGV[3]) then
+  return {1, dayNPR}
+end
+if tonumber(ARGV[4]) >= 0 and monthNPR + npr > tonumber(ARGV[4]) then
+  return {2, monthNPR}
+end
+if tonumber(ARGV[5]) >= 0 and dayGrams + grams > tonumber(ARGV[5]) then
+  return {3, dayGrams}
+end
+if tonumber(ARGV[6]) >= 0 and monthGrams + grams > tonumber(ARGV[6]) then
+  return {4, monthGrams}
+end
+redis.call('HINCRBY', KEYS[1], 'npr', ARGV[1])
+redis.call('HINCRBY', KEYS[1], 'grams', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[7])
+redis.call('HINCRBY', KEYS[2], 'npr', ARGV[1])
+redis.call('HINCRBY', KEYS[2], 'grams', ARGV[2])
+redis.call('PEXPIRE', KEYS[2], ARGV[8])
+return {0, 0}
+`
+
+const releaseScript = `
+for i = 1, #KEYS do
+  if redis.call('EXISTS', KEYS[i]) == 1 then
+    redis.call('HINCRBY', KEYS[i], 'npr', '-' .. ARGV[1])
+    redis.call('HINCRBY', KEYS[i], 'grams', '-' .. ARGV[2])
+  end
+end
+return 1
+`
+
+type OperationUsage struct {
+	Operation    string                   `json:"operation"`
+	Limit        *models.TransactionLimit `json:"limit"`
+	DailyNPR     float64                  `json:"daily_npr_used"`
+	MonthlyNPR   float64                  `json:"monthly_npr_used"`
+	DailyGrams   float64                  `json:"daily_grams_used"`
+	MonthlyGrams float64                  `json:"monthly_grams_used"`
+}
+
+type UserUsage struct {
+	Tier         string           `json:"tier"`
+	DailyReset   time.Time        `json:"daily_resets_at"`
+	MonthlyReset time.Time        `json:"monthly_resets_at"`
+	Operations   []OperationUsage `json:"operations"`
+}
+
+type Service interface {
+	EnsureDefaults(ctx context.Context) error
+	Reserve(ctx context.Context, userID uint, operation string, amountNPR, grams float64) (func(), error)
+	Usage(ctx context.Context, userID uint) (*UserUsage, error)
+	ListLimits(ctx context.Context) ([]models.TransactionLimit, error)
+	UpdateLimit(ctx context.Context, actorID uint, tier, operation string, values models.TransactionLimit) (*models.TransactionLimit, error)
+}
+
+type service struct {
+	repo     Repository
+	redis    *redis.Client
+	audit    audit.Service
+	location *time.Location
+	defaults config.LimitsConfig
+
+	mu       sync.Mutex
+	cache    map[string]models.TransactionLimit
+	loadedAt time.Time
+}
+
+func NewService(repo Repository, redisClient *redis.Client, auditService audit.Service, cfg *config.Config) Service {
+	location, err := time.LoadLocation(cfg.Limits.Timezone)
+	if err != nil {
+		slog.Warn("limits: unknown LIMITS_TIMEZONE, using UTC", "timezone", cfg.Limits.Timezone, "error", err)
+		location = time.UTC
+	}
+	return &service{
+		repo:     repo,
+		redis:    redisClient,
+		audit:    auditService,
+		location: location,
+		defaults: cfg.Limits,
+	}
+}
+
+// EnsureDefaults inserts the built-in limits for any tier and operation
+// that has none yet. Edited limits are left alone.
+func (s *service) EnsureDefaults(ctx context.Context) error {
+	var defaults []models.TransactionLimit
+	for _, tier := range Tiers {
+		for _, operation := range Operations {
+			defaults = append(defaults, defaultLimit(tier, operation, s.defaults))
+		}
+	}
+	return s.repo.CreateMissing(ctx, defaults)
+}
+
+// Reserve checks every limit for the operation and records the usage. The
+// returned release function gives the usage back and must be called if the
+// operation does not go through.
+func (s *service) Reserve(ctx context.Context, userID uint, operation string, amountNPR, grams float64) (func(), error) {
+	tier, limit, err := s.limitFor(ctx, userID, operation)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit.PerTransactionNPR != nil && amountNPR > *limit.PerTransactionNPR {
+		return nil, &LimitError{Tier: tier, Operation: operation, Period: PeriodTransaction, Unit: UnitNPR,
+			Limit: *limit.PerTransactionNPR, Requested: amountNPR}
+	}
+	if limit.PerTransactionGrams != nil && grams > *limit.PerTransactionGrams {
+		return nil, &LimitError{Tier: tier, Operation: operation, Period: PeriodTransaction, Unit: UnitGrams,
+			Limit: *limit.PerTransactionGrams, Requested: grams}
+	}
+
+	now := time.Now().In(s.location)
+	dayKey, dayReset := s.dailyKey(userID, operation, now)
+	monthKey, monthReset := s.monthlyKey(userID, operation, now)
+	nprUnits := toUnits(amountNPR, nprScale)
+	gramUnits := toUnits(grams, gramsScale)
+
+	result, err := s.redis.Eval(ctx, reserveScript, []string{dayKey, monthKey},
+		nprUnits, gramUnits,
+		capUnits(limit.DailyNPR, nprScale), capUnits(limit.MonthlyNPR, nprScale),
+		capUnits(limit.DailyGrams, gramsScale), capUnits(limit.MonthlyGrams, gramsScale),
+		time.Until(dayReset.Add(time.Hour)).Milliseconds(), time.Until(monthReset.Add(time.Hour)).Milliseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("limit check failed: %w", err)
+	}
+
+	values, _ := result.([]interface{})
+	if len(values) != 2 {
+		return nil, fmt.Errorf("limit check failed: unexpected result %v", result)
+	}
+	code, _ := values[0].(int64)
+	used, _ := values[1].(int64)
+
+	if code != 0 {
+		limitErr := &LimitError{Tier: tier, Operation: operation}
+		switch code {
+		case 1:
+			limitErr.Period, limitErr.Unit, limitErr.Limit, limitErr.ResetsAt = PeriodDaily, UnitNPR, *limit.DailyNPR, &dayReset
+		case 2:
+			limitErr.Period, limitErr.Unit, limitErr.Limit, limitErr.ResetsAt = PeriodMonthly, UnitNPR, *limit.MonthlyNPR, &monthReset
+		case 3:
+			limitErr.Period, limitErr.Unit, limitErr.Limit, limitErr.ResetsAt = PeriodDaily, UnitGrams, *limit.DailyGrams, &dayReset
+		case 4:
+			limitErr.Period, limitErr.Unit, limitErr.Limit, limitErr.ResetsAt = PeriodMonthly, UnitGrams, *limit.MonthlyGrams, &monthReset
+		}
+		if limitErr.Unit == UnitNPR {
+			limitErr.Used, limitErr.Requested = fromUnits(used, nprScale), amountNPR
+		} else {
+			limitErr.Used, limitErr.Requested = fromUnits(used, gramsScale), grams
+		}
+		return nil, limitErr
+	}
+
+	release := func() {
+		if _, err := s.redis.Eval(context.Background(), releaseScript, []string{dayKey, monthKey}, nprUnits, gramUnits); err != nil {
+			slog.ErrorContext(ctx, "limits: release usage failed", "operation", operation, "user_id", userID, "error", err)
+		}
+	}
+	return release, nil
+}
+
+func (s *service) Usage(ctx context.Context, userID uint) (*UserUsage, error) {
+	tier, err := s.tierFor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(s.location)
+	usage := &UserUsage{Tier: tier}
+	for _, operation := range Operations {
+		limit, err := s.lookup(ctx, tier, operation)
+		if err != nil {
+			return nil, err
+		}
+
+		dayKey, dayReset := s.dailyKey(userID, operation, now)
+		monthKey, monthReset := s.monthlyKey(userID, operation, now)
+		usage.DailyReset, usage.MonthlyReset = dayReset, monthReset
+
+		entry := OperationUsage{Operation: operation, Limit: limit}
+		if entry.DailyNPR, err = s.used(ctx, dayKey, "npr", nprScale); err != nil {
+			return nil, err
+		}
+		if entry.MonthlyNPR, err = s.used(ctx, monthKey, "npr", nprScale); err != nil {
+			return nil, err
+		}
+		if entry.DailyGrams, err = s.used(ctx, dayKey, "grams", gramsScale); err != nil {
+			return nil, err
+		}
+		if entry.MonthlyGrams, err = s.used(ctx, monthKey, "grams", gramsScale); err != nil {
+			return nil, err
+		}
+		usage.Operations = append(usage.Operations, entry)
+	}
+	return usage, nil
+}
+
+func (s *service) ListLimits(ctx context.Context) ([]models.TransactionLimit, error) {
+	return s.repo.ListLimits(ctx)
+}
+
+func (s *service) UpdateLimit(ctx context.Context, actorID uint, tier, operation string, values models.TransactionLimit) (*models.TransactionLimit, error) {
+	if !contains(Tiers, tier) {
+		return nil, ErrUnknownTier
+	}
+	if !contains(Operations, operation) {
+		return nil, ErrUnknownOperation
+	}
+	for _, v := range []*float64{values.PerTransactionNPR, values.DailyNPR, values.MonthlyNPR,
+		values.PerTransactionGrams, values.DailyGrams, values.MonthlyGrams} {
+		if v != nil && *v < 0 {
+			return nil, ErrInvalidLimit
+		}
+	}
+
+	limit, err := s.repo.FindLimit(ctx, tier, operation)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		limit = &models.TransactionLimit{Tier: tier, Operation: operation}
+	}
+	previous := *limit
+
+	limit.PerTransactionNPR = values.PerTransactionNPR
+	limit.DailyNPR = values.DailyNPR
+	limit.MonthlyNPR = values.MonthlyNPR
+	limit.PerTransactionGrams = values.PerTransactionGrams
+	limit.DailyGrams = values.DailyGrams
+	limit.MonthlyGrams = values.MonthlyGrams
+	limit.UpdatedBy = &actorID
+	if err := s.repo.SaveLimit(ctx, limit); err != nil {
+		return nil, fmt.Errorf("limit update failed: %w", err)
+	}
+
+	s.mu.Lock()
+	s.cache = nil
+	s.mu.Unlock()
+
+	if err := s.audit.Record(ctx, actorID, "limits.update", "transaction_limit", tier+"/"+operation, map[string]interface{}{
+		"previous": previous,
+		"current":  limit,
+	}); err != nil {
+		return nil, err
+	}
+	return limit, nil
+}
+
+func (s *service) limitFor(ctx context.Context, userID uint, operation string) (string, *models.TransactionLimit, error) {
+	if !contains(Operations, operation) {
+		return "", nil, ErrUnknownOperation
+	}
+	tier, err := s.tierFor(ctx, userID)
+	if err != nil {
+		return "", nil, err
+	}
+	limit, err := s.lookup(ctx, tier, operation)
+	return tier, limit, err
+}
+
+func (s *service) tierFor(ctx context.Context, userID uint) (string, error) {
+	status, err := s.repo.GetKYCStatus(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("user %d not found", userID)
+		}
+		return "", err
+	}
+	if !contains(Tiers, status) {
+		return models.KYCStatusPending, nil
+	}
+	return status, nil
+}
+
+// lookup serves limits from a cache that is reloaded every 30 seconds, so
+// edits made on another instance apply without a restart. A tier without a
+// row for the operation falls back to the pending tier.
+func (s *service) lookup(ctx context.Context, tier, operation string) (*models.TransactionLimit, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cache == nil || time.Since(s.loadedAt) > cacheTTL {
+		limits, err := s.repo.ListLimits(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load limits: %w", err)
+		}
+		s.cache = make(map[string]models.TransactionLimit, len(limits))
+		for _, limit := range limits {
+			s.cache[limit.Tier+"/"+limit.Operation] = limit
+		}
+		s.loadedAt = time.Now()
+	}
+
+	if limit, ok := s.cache[tier+"/"+operation]; ok {
+		return &limit, nil
+	}
+	if limit, ok := s.cache[models.KYCStatusPending+"/"+operation]; ok {
+		return &limit, nil
+	}
+	fallback := defaultLimit(models.KYCStatusPending, operation, s.defaults)
+	return &fallback, nil
+}
+
+func (s *service) used(ctx context.Context, key, field string, scale float64) (float64, error) {
+	value, err := s.redis.HGet(ctx, key, field)
+	if err != nil {
+		if redis.IsNil(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	units, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return fromUnits(units, scale), nil
+}
+
+func (s *service) dailyKey(userID uint, operation string, now time.Time) (string, time.Time) {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	return fmt.Sprintf("limits:%d:%s:d:%s", userID, operation, start.Format("20060102")), start.AddDate(0, 0, 1)
+}
+
+func (s *service) monthlyKey(userID uint, operation string, now time.Time) (string, time.Time) {
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, s.location)
+	return fmt.Sprintf("limits:%d:%s:m:%s", userID, operation, start.Format("200601")), start.AddDate(0, 1, 0)
+}
+
+func toUnits(value, scale float64) int64 {
+	return int64(math.Round(value * scale))
+}
+
+func fromUnits(units int64, scale float64) float64 {
+	return float64(units) / scale
+}
+
+func capUnits(limit *float64, scale float64) int64 {
+	if limit == nil {
+		return -1
+	}
+	return toUnits(*limit, scale)
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package limits
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+)
+
+type fakeRepository struct {
+	Repository
+	tiers  map[uint]string
+	limits []models.TransactionLimit
+}
+
+func (r *fakeRepository) GetKYCStatus(ctx context.Context, userID uint) (string, error) {
+	return r.tiers[userID], nil
+}
+
+func (r *fakeRepository) ListLimits(ctx context.Context) ([]models.TransactionLimit, error) {
+	return r.limits, nil
+}
+
+func (r *fakeRepository) CreateMissing(ctx context.Context, limits []models.TransactionLimit) error {
+	r.limits = append(r.limits, limits...)
+	return nil
+}
+
+func newTestService(t *testing.T, repo *fakeRepository, limits config.LimitsConfig) Service {
+	t.Helper()
+	limits.Timezone = "Asia/Kathmandu"
+	redisClient := redis.NewRedisClient(miniredis.RunT(t).Addr(), "", 0)
+	return NewService(repo, redisClient, nil, &config.Config{Limits: limits})
+}
+
+func verifiedBuyer(limit models.TransactionLimit) *fakeRepository {
+	limit.Tier, limit.Operation = models.KYCStatusVerified, OperationBuy
+	return &fakeRepository{
+		tiers:  map[uint]string{1: models.KYCStatusVerified},
+		limits: []models.TransactionLimit{limit},
+	}
+}
+
+func limitError(t *testing.T, err error) *LimitError {
+	t.Helper()
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) {
+		t.Fatalf("err = %v, want a LimitError", err)
+	}
+	return limitErr
+}
+
+func TestReserveStopsAtTheDailyCapUntilUsageIsReleased(t *testing.T) {
+	svc := newTestService(t, verifiedBuyer(models.TransactionLimit{DailyNPR: amount(1000)}), config.LimitsConfig{})
+	ctx := context.Background()
+
+	release, err := svc.Reserve(ctx, 1, OperationBuy, 600, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Reserve(ctx, 1, OperationBuy, 400, 0); err != nil {
+		t.Fatalf("reserve up to the cap: %v", err)
+	}
+
+	_, err = svc.Reserve(ctx, 1, OperationBuy, 0.01, 0)
+	limitErr := limitError(t, err)
+	if limitErr.Period != PeriodDaily || limitErr.Unit != UnitNPR || limitErr.Limit != 1000 || limitErr.Used != 1000 || limitErr.Requested != 0.01 {
+		t.Fatalf("limit error = %+v, want the daily NPR cap with 1000 used", limitErr)
+	}
+	location, _ := time.LoadLocation("Asia/Kathmandu")
+	now := time.Now().In(location)
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, location)
+	if limitErr.ResetsAt == nil || !limitErr.ResetsAt.Equal(midnight) {
+		t.Fatalf("resets at %v, want the next Kathmandu midnight %v", limitErr.ResetsAt, midnight)
+	}
+
+	release()
+	if _, err := svc.Reserve(ctx, 1, OperationBuy, 600, 0); err != nil {
+		t.Fatalf("reserve after release: %v", err)
+	}
+	usage, err := svc.Usage(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range usage.Operations {
+		if entry.Operation == OperationBuy && (entry.DailyNPR != 1000 || entry.MonthlyNPR != 1000) {
+			t.Fatalf("buy usage = %+v, want 1000 NPR today and this month", entry)
+		}
+	}
+}
+
+func TestConcurrentReservationsNeverPassTheCap(t *testing.T) {
+	svc := newTestService(t, verifiedBuyer(models.TransactionLimit{DailyNPR: amount(1000)}), config.LimitsConfig{})
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		reserved int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := svc.Reserve(context.Background(), 1, OperationBuy, 100, 0)
+			var limitErr *LimitError
+			if err != nil && !errors.As(err, &limitErr) {
+				t.Error(err)
+				return
+			}
+			if err == nil {
+				mu.Lock()
+				reserved++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if reserved != 10 {
+		t.Fatalf("%d reservations succeeded, want exactly 10 under a 1000 NPR cap", reserved)
+	}
+}
+
+func TestReserveChecksMonthlyAndGramCaps(t *testing.T) {
+	svc := newTestService(t, verifiedBuyer(models.TransactionLimit{
+		MonthlyNPR: amount(1500),
+		DailyGrams: amount(1),
+	}), config.LimitsConfig{})
+	ctx := context.Background()
+
+	if _, err := svc.Reserve(ctx, 1, OperationBuy, 1000, 0.6); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := svc.Reserve(ctx, 1, OperationBuy, 600, 0)
+	if limitErr := limitError(t, err); limitErr.Period != PeriodMonthly || limitErr.Unit != UnitNPR || limitErr.Used != 1000 {
+		t.Fatalf("limit error = %+v, want the monthly NPR cap with 1000 used", limitErr)
+	}
+
+	_, err = svc.Reserve(ctx, 1, OperationBuy, 10, 0.5)
+	if limitErr := limitError(t, err); limitErr.Period != PeriodDaily || limitErr.Unit != UnitGrams || limitErr.Used != 0.6 || limitErr.Requested != 0.5 {
+		t.Fatalf("limit error = %+v, want the daily grams cap with 0.6 used", limitErr)
+	}
+}
+
+func TestWithdrawLimitsComeFromTheConfiguration(t *testing.T) {
+	repo := &fakeRepository{tiers: map[uint]string{
+		1: models.KYCStatusVerified,
+		2: models.KYCStatusPending,
+	}}
+	svc := newTestService(t, repo, config.LimitsConfig{
+		WithdrawPerTransactionNPR: 100,
+		WithdrawDailyNPR:          150,
+		WithdrawMonthlyNPR:        1000,
+	})
+	ctx := context.Background()
+	if err := svc.EnsureDefaults(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := svc.Reserve(ctx, 1, OperationWithdraw, 120, 0)
+	if limitErr := limitError(t, err); limitErr.Period != PeriodTransaction || limitErr.Limit != 100 {
+		t.Fatalf("limit error = %+v, want the 100 NPR per-transaction cap", limitErr)
+	}
+	if _, err := svc.Reserve(ctx, 1, OperationWithdraw, 100, 0); err != nil {
+		t.Fatal(err)
+	}
+	_, err = svc.Reserve(ctx, 1, OperationWithdraw, 60, 0)
+	if limitErr := limitError(t, err); limitErr.Period != PeriodDaily || limitErr.Limit != 150 {
+		t.Fatalf("limit error = %+v, want the 150 NPR daily cap", limitErr)
+	}
+
+	_, err = svc.Reserve(ctx, 2, OperationWithdraw, 1, 0)
+	if limitErr := limitError(t, err); limitErr.Period != PeriodTransaction || limitErr.Limit != 0 {
+		t.Fatalf("limit error = %+v, want pending users blocked from withdrawing", limitErr)
+	}
+}
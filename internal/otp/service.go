@@ -0,0 +1,195 @@
+package otp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/pkg/notify"
+	"github.com/919Umesh/gold_go/pkg/redis"
+)
+
+type Purpose string
+
+const (
+	PurposeEmailVerification Purpose = "email_verification"
+	PurposePhoneVerification Purpose = "phone_verification"
+	PurposePasswordReset     Purpose = "password_reset"
+)
+
+var (
+	ErrTooManyRequests = errors.New("too many codes requested, try again later")
+	ErrInvalidCode     = errors.New("invalid code")
+	ErrCodeExpired     = errors.New("code expired or not requested")
+	ErrTooManyAttempts = errors.New("too many attempts, request a new code")
+)
+
+const (
+	codeDigits     = 6
+	resendCooldown = time.Minute
+)
+
+// verifyScript counts the attempt and compares the stored hash in one step,
+// so parallel guesses cannot exceed the attempt limit. The code is deleted
+// on success and once the attempts are used up.
+const verifyScript = `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+  return -1
+end
+local attempts = redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+if attempts > tonumber(ARGV[2]) then
+  redis.call('DEL', KEYS[1])
+  return -2
+end
+if redis.call('HGET', KEYS[1], 'hash') == ARGV[1] then
+  redis.call('DEL', KEYS[1])
+  return 1
+end
+return 0
+`
+
+const storeScript = `
+redis.call('DEL', KEYS[1])
+redis.call('HSET', KEYS[1], 'hash', ARGV[1], 'attempts', 0)
+redis.call('PEXPIRE', KEYS[1], ARGV[2])
+return 1
+`
+
+type Service interface {
+	Send(ctx context.Context, purpose Purpose, destination string) error
+	Verify(ctx context.Context, purpose Purpose, destination, code string) error
+}
+
+type service struct {
+	redis       *redis.Client
+	email       notify.EmailSender
+	sms         notify.SMSSender
+	secret      []byte
+	ttl         time.Duration
+	maxAttempts int
+	maxSends    int
+}
+
+func NewService(redisClient *redis.Client, email notify.EmailSender, sms notify.SMSSender, cfg *config.Config) Service {
+	return &service{
+		redis:       redisClient,
+		email:       email,
+		sms:         sms,
+		secret:      []byte(cfg.OTP.Secret),
+		ttl:         time.Duration(cfg.OTP.TTLMinutes) * time.Minute,
+		maxAttempts: cfg.OTP.MaxAttempts,
+		maxSends:    cfg.OTP.MaxSendsPerHour,
+	}
+}
+
+// Send issues a fresh code, replacing any earlier one for the same purpose
+// and destination. Sends are limited per destination regardless of which
+// account or IP asks for them.
+func (s *service) Send(ctx context.Context, purpose Purpose, destination string) error {
+	destination = normalize(destination)
+
+	ok, err := s.redis.SetNX(ctx, cooldownKey(purpose, destination), 1, resendCooldown)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTooManyRequests
+	}
+
+	sent, err := s.redis.IncrementWithExpiry(ctx, "otp:sends:"+destination, time.Hour)
+	if err != nil {
+		return err
+	}
+	if int(sent) > s.maxSends {
+		return ErrTooManyRequests
+	}
+
+	code, err := generateCode()
+	if err != nil {
+		return fmt.Errorf("code generation failed: %w", err)
+	}
+
+	if _, err := s.redis.Eval(ctx, storeScript, []string{codeKey(purpose, destination)}, s.hash(purpose, destination, code), s.ttl.Milliseconds()); err != nil {
+		return err
+	}
+
+	return s.deliver(ctx, purpose, destination, code)
+}
+
+func (s *service) Verify(ctx context.Context, purpose Purpose, destination, code string) error {
+	destination = normalize(destination)
+
+	result, err := s.redis.Eval(ctx, verifyScript, []string{codeKey(purpose, destination)}, s.hash(purpose, destination, strings.TrimSpace(code)), s.maxAttempts)
+	if err != nil {
+		return err
+	}
+
+	n, _ := result.(int64)
+	switch n {
+	case 1:
+		return nil
+	case -1:
+		return ErrCodeExpired
+	case -2:
+		return ErrTooManyAttempts
+	default:
+		return ErrInvalidCode
+	}
+}
+
+func (s *service) deliver(ctx context.Context, purpose Purpose, destination, code string) error {
+	minutes := int(s.ttl.Minutes())
+
+	switch purpose {
+	case PurposePhoneVerification:
+		return s.sms.SendSMS(ctx, destination, fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, minutes))
+	case PurposeEmailVerification:
+		return s.email.SendEmail(ctx, destination, "Verify your email address",
+			fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, minutes))
+	case PurposePasswordReset:
+		return s.email.SendEmail(ctx, destination, "Reset your password",
+			fmt.Sprintf("Your password reset code is %s. It expires in %d minutes. If you did not ask to reset your password, ignore this email.", code, minutes))
+	default:
+		return fmt.Errorf("unknown otp purpose %q", purpose)
+	}
+}
+
+// hash binds the code to its purpose and destination and keys it with a
+// server secret, so the six digits cannot be brute-forced from a Redis dump.
+func (s *service) hash(purpose Purpose, destination, code string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(string(purpose) + "|" + destination + "|" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func generateCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < codeDigits; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n), nil
+}
+
+func normalize(destination string) string {
+	return strings.ToLower(strings.TrimSpace(destination))
+}
+
+func codeKey(purpose Purpose, destination string) string {
+	return "otp:code:" + string(purpose) + ":" + destination
+}
+
+func cooldownKey(purpose Purpose, destination string) string {
+	return "otp:cooldown:" + string(purpose) + ":" + destination
+}
@@ -0,0 +1,107 @@
+package rbac
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+type UpsertRoleRequest struct {
+	Description string   `json:"description" binding:"max=255"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required,max=50"`
+}
+
+func (h *Handler) ListRoles(c *gin.Context) {
+	roles, err := h.service.ListRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"roles":       roles,
+		"permissions": AllPermissions,
+	})
+}
+
+func (h *Handler) UpsertRole(c *gin.Context) {
+	var req UpsertRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := h.service.UpsertRole(c.Request.Context(), c.GetUint("user_id"), c.Param("name"), req.Description, req.Permissions)
+	if err != nil {
+		writeError(c, err, "role update failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "role saved",
+		"role":    role,
+	})
+}
+
+func (h *Handler) DeleteRole(c *gin.Context) {
+	if err := h.service.DeleteRole(c.Request.Context(), c.GetUint("user_id"), c.Param("name")); err != nil {
+		writeError(c, err, "role deletion failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "role deleted"})
+}
+
+func (h *Handler) AssignRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id format"})
+		return
+	}
+
+	var req AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.AssignRole(c.Request.Context(), c.GetUint("user_id"), uint(userID), req.Role)
+	if err != nil {
+		writeError(c, err, "role assignment failed")
+		return
+	}
+
+	user.PasswordHash = ""
+	c.JSON(http.StatusOK, gin.H{
+		"message": "role assigned",
+		"user":    user,
+	})
+}
+
+func writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case ErrRoleNotFound, ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case ErrUnknownPermission:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case ErrSystemRole, ErrRoleInUse, ErrLastSuperAdmin:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case ErrPrivilegeEscalation, ErrOutranked:
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
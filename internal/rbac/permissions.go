@@ -0,0 +1,97 @@
+package rbac
+
+const (
+	PermKYCReview      = "kyc:review"
+	PermPriceOverride  = "price:override"
+	PermWalletFreeze   = "wallet:freeze"
+	PermWalletAdjust   = "wallet:adjust"
+	PermReportsRead    = "reports:read"
+	PermUsersManage    = "users:manage"
+	PermRolesManage    = "roles:manage"
+	PermWebhooksManage = "webhooks:manage"
+	PermJobsManage     = "jobs:manage"
+	PermAuditRead      = "audit:read"
+	PermLimitsManage   = "limits:manage"
+	PermAMLManage      = "aml:manage"
+	PermCustodyManage  = "custody:manage"
+
+	// PermAll is only granted to super_admin and matches every permission.
+	PermAll = "*"
+)
+
+// The operations bundle is deliberately not called "admin": registration
+// used to accept role "admin" from anyone, and those accounts must not pick
+// up real permissions. Migration 0004 demotes them.
+const (
+	RoleUser       = "user"
+	RoleSupport    = "support"
+	RoleCompliance = "compliance"
+	RoleOperations = "operations"
+	RoleSuperAdmin = "super_admin"
+)
+
+var AllPermissions = []string{
+	PermKYCReview,
+	PermPriceOverride,
+	PermWalletFreeze,
+	PermWalletAdjust,
+	PermReportsRead,
+	PermUsersManage,
+	PermRolesManage,
+	PermWebhooksManage,
+	PermJobsManage,
+	PermAuditRead,
+	PermLimitsManage,
+	PermAMLManage,
+	PermCustodyManage,
+}
+
+type roleDefinition struct {
+	description string
+	permissions []string
+}
+
+var defaultRoles = map[string]roleDefinition{
+	RoleUser: {
+		description: "Customer account",
+		permissions: []string{},
+	},
+	RoleSupport: {
+		description: "Customer support",
+		permissions: []string{PermKYCReview, PermUsersManage},
+	},
+	RoleCompliance: {
+		description: "Compliance and risk",
+		permissions: []string{PermKYCReview, PermWalletFreeze, PermReportsRead, PermAuditRead, PermLimitsManage, PermAMLManage},
+	},
+	RoleOperations: {
+		description: "Platform operations",
+		permissions: []string{
+			PermKYCReview, PermPriceOverride, PermWalletFreeze, PermReportsRead,
+			PermUsersManage, PermWebhooksManage, PermJobsManage, PermAuditRead,
+			PermLimitsManage, PermAMLManage, PermWalletAdjust, PermCustodyManage,
+		},
+	},
+	RoleSuperAdmin: {
+		description: "Full access, including role management",
+		permissions: []string{PermAll},
+	},
+}
+
+func grants(permissions []string, permission string) bool {
+	for _, candidate := range permissions {
+		if candidate == PermAll || candidate == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func isKnownPermission(permission string) bool {
+	for _, candidate := range AllPermissions {
+		if candidate == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package rbac
+
+import (
+	"context"
+
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	FindRole(ctx context.Context, name string) (*models.Role, error)
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	SaveRole(ctx context.Context, role *models.Role) error
+	DeleteRole(ctx context.Context, name string) error
+	CountUsersWithRole(ctx context.Context, name string) (int64, error)
+
+	FindUser(ctx context.Context, id uint) (*models.User, error)
+	FindUserByEmail(ctx context.Context, email string) (*models.User, error)
+	CreateUser(ctx context.Context, user *models.User) error
+	UpdateUserRole(ctx context.Context, userID uint, role string) error
+	UserPermissions(ctx context.Context, userID uint) ([]string, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) FindRole(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *repository) ListRoles(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.WithContext(ctx).Order("name").Find(&roles).Error
+	return roles, err
+}
+
+func (r *repository) SaveRole(ctx context.Context, role *models.Role) error {
+	return r.db.WithContext(ctx).Save(role).Error
+}
+
+func (r *repository) DeleteRole(ctx context.Context, name string) error {
+	return r.db.WithContext(ctx).Where("name = ?", name).Delete(&models.Role{}).Error
+}
+
+func (r *repository) CountUsersWithRole(ctx context.Context, name string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) FindUser(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *repository) FindUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *repository) CreateUser(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *repository) UpdateUserRole(ctx context.Context, userID uint, role string) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error
+}
+
+func (r *repository) UserPermissions(ctx context.Context, userID uint) ([]string, error) {
+	var role models.Role
+	err := r.db.WithContext(ctx).Model(&models.Role{}).
+		Joins("JOIN users ON users.role = roles.name").
+		Where("users.id = ?", userID).
+		First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return role.Permissions, nil
+}
@@ -0,0 +1,290 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRoleNotFound        = errors.New("role not found")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrUnknownPermission   = errors.New("unknown permission")
+	ErrSystemRole          = errors.New("system roles cannot be changed")
+	ErrRoleInUse           = errors.New("role is assigned to users")
+	ErrPrivilegeEscalation = errors.New("cannot grant permissions you do not hold")
+	ErrOutranked           = errors.New("target user holds permissions you do not")
+	ErrSuperAdminExists    = errors.New("a super admin already exists")
+	ErrLastSuperAdmin      = errors.New("cannot remove the last super admin")
+	ErrBootstrapDetails    = errors.New("name, phone and password are required to create a new account")
+)
+
+type Service interface {
+	EnsureDefaultRoles(ctx context.Context) error
+	HasPermission(ctx context.Context, userID uint, permission string) (bool, error)
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	UpsertRole(ctx context.Context, actorID uint, name, description string, permissions []string) (*models.Role, error)
+	DeleteRole(ctx context.Context, actorID uint, name string) error
+	AssignRole(ctx context.Context, actorID, userID uint, role string) (*models.User, error)
+	CheckCanManage(ctx context.Context, actorID uint, targetRole string) error
+	BootstrapSuperAdmin(ctx context.Context, fullName, email, phone, password string) (*models.User, error)
+}
+
+type service struct {
+	repo  Repository
+	audit audit.Service
+}
+
+func NewService(repo Repository, auditService audit.Service) Service {
+	return &service{repo: repo, audit: auditService}
+}
+
+// EnsureDefaultRoles creates the built-in roles that are missing. Roles
+// edited by an operator are left alone, except super_admin which must always
+// hold every permission.
+func (s *service) EnsureDefaultRoles(ctx context.Context) error {
+	for name, definition := range defaultRoles {
+		role, err := s.repo.FindRole(ctx, name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if role != nil && name != RoleSuperAdmin {
+			continue
+		}
+		if role == nil {
+			role = &models.Role{Name: name}
+		}
+		role.Description = definition.description
+		role.Permissions = definition.permissions
+		role.System = true
+		if err := s.repo.SaveRole(ctx, role); err != nil {
+			return fmt.Errorf("failed to create role %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (s *service) HasPermission(ctx context.Context, userID uint, permission string) (bool, error) {
+	permissions, err := s.repo.UserPermissions(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return grants(permissions, permission), nil
+}
+
+func (s *service) ListRoles(ctx context.Context) ([]models.Role, error) {
+	return s.repo.ListRoles(ctx)
+}
+
+func (s *service) UpsertRole(ctx context.Context, actorID uint, name, description string, permissions []string) (*models.Role, error) {
+	if _, ok := defaultRoles[name]; ok {
+		return nil, ErrSystemRole
+	}
+	for _, permission := range permissions {
+		if !isKnownPermission(permission) {
+			return nil, ErrUnknownPermission
+		}
+	}
+	if err := s.checkCanGrant(ctx, actorID, permissions); err != nil {
+		return nil, err
+	}
+
+	role, err := s.repo.FindRole(ctx, name)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	previous := []string{}
+	if role == nil {
+		role = &models.Role{Name: name}
+	} else {
+		previous = role.Permissions
+	}
+	role.Description = description
+	role.Permissions = permissions
+
+	if err := s.repo.SaveRole(ctx, role); err != nil {
+		return nil, fmt.Errorf("role update failed: %w", err)
+	}
+
+	if err := s.audit.Record(ctx, actorID, "role.upsert", "role", name, map[string]interface{}{
+		"previous_permissions": previous,
+		"permissions":          permissions,
+	}); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *service) DeleteRole(ctx context.Context, actorID uint, name string) error {
+	if _, ok := defaultRoles[name]; ok {
+		return ErrSystemRole
+	}
+	role, err := s.repo.FindRole(ctx, name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		return err
+	}
+
+	count, err := s.repo.CountUsersWithRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse
+	}
+
+	if err := s.repo.DeleteRole(ctx, name); err != nil {
+		return fmt.Errorf("role deletion failed: %w", err)
+	}
+
+	return s.audit.Record(ctx, actorID, "role.delete", "role", name, map[string]interface{}{
+		"permissions": role.Permissions,
+	})
+}
+
+func (s *service) AssignRole(ctx context.Context, actorID, userID uint, roleName string) (*models.User, error) {
+	role, err := s.repo.FindRole(ctx, roleName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	if err := s.checkCanGrant(ctx, actorID, role.Permissions); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.FindUser(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if err := s.CheckCanManage(ctx, actorID, user.Role); err != nil {
+		return nil, err
+	}
+
+	if user.Role == RoleSuperAdmin && roleName != RoleSuperAdmin {
+		count, err := s.repo.CountUsersWithRole(ctx, RoleSuperAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if count <= 1 {
+			return nil, ErrLastSuperAdmin
+		}
+	}
+
+	previous := user.Role
+	if err := s.repo.UpdateUserRole(ctx, userID, roleName); err != nil {
+		return nil, fmt.Errorf("role assignment failed: %w", err)
+	}
+	user.Role = roleName
+
+	if err := s.audit.Record(ctx, actorID, "user.role_assign", "user", strconv.FormatUint(uint64(userID), 10), map[string]interface{}{
+		"previous_role": previous,
+		"role":          roleName,
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// BootstrapSuperAdmin creates the first super admin, or promotes an existing
+// account with that email. It refuses to run once any super admin exists.
+func (s *service) BootstrapSuperAdmin(ctx context.Context, fullName, email, phone, password string) (*models.User, error) {
+	if err := s.EnsureDefaultRoles(ctx); err != nil {
+		return nil, err
+	}
+
+	count, err := s.repo.CountUsersWithRole(ctx, RoleSuperAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrSuperAdminExists
+	}
+
+	user, err := s.repo.FindUserByEmail(ctx, email)
+	switch {
+	case err == nil:
+		if err := s.repo.UpdateUserRole(ctx, user.ID, RoleSuperAdmin); err != nil {
+			return nil, fmt.Errorf("role assignment failed: %w", err)
+		}
+		user.Role = RoleSuperAdmin
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if fullName == "" || phone == "" || password == "" {
+			return nil, ErrBootstrapDetails
+		}
+		hashedPassword, err := utils.HashPassword(password)
+		if err != nil {
+			return nil, fmt.Errorf("password hashing failed: %w", err)
+		}
+		user = &models.User{
+			FullName:     fullName,
+			Email:        email,
+			Phone:        phone,
+			PasswordHash: hashedPassword,
+			Role:         RoleSuperAdmin,
+		}
+		if err := s.repo.CreateUser(ctx, user); err != nil {
+			return nil, fmt.Errorf("user creation failed: %w", err)
+		}
+	default:
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, 0, "user.bootstrap_super_admin", "user", strconv.FormatUint(uint64(user.ID), 10), map[string]interface{}{
+		"role": RoleSuperAdmin,
+	}); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CheckCanManage refuses actions on a user whose role holds any permission
+// the actor lacks, so that nobody can demote, lock out or log out someone
+// more privileged than themselves.
+func (s *service) CheckCanManage(ctx context.Context, actorID uint, targetRole string) error {
+	role, err := s.repo.FindRole(ctx, targetRole)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if err := s.checkCanGrant(ctx, actorID, role.Permissions); err != nil {
+		if errors.Is(err, ErrPrivilegeEscalation) {
+			return ErrOutranked
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *service) checkCanGrant(ctx context.Context, actorID uint, permissions []string) error {
+	actorPermissions, err := s.repo.UserPermissions(ctx, actorID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPrivilegeEscalation
+		}
+		return err
+	}
+	for _, permission := range permissions {
+		if !grants(actorPermissions, permission) {
+			return ErrPrivilegeEscalation
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+)
+
+type fakeRepository struct {
+	roles map[string]*models.Role
+	users map[uint]*models.User
+}
+
+func newFakeRepository() *fakeRepository {
+	repo := &fakeRepository{roles: map[string]*models.Role{}, users: map[uint]*models.User{}}
+	for name, definition := range defaultRoles {
+		repo.roles[name] = &models.Role{Name: name, Permissions: definition.permissions, System: true}
+	}
+	repo.roles["roles_only"] = &models.Role{Name: "roles_only", Permissions: []string{PermRolesManage}}
+	return repo
+}
+
+func (r *fakeRepository) FindRole(ctx context.Context, name string) (*models.Role, error) {
+	if role, ok := r.roles[name]; ok {
+		return role, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepository) ListRoles(ctx context.Context) ([]models.Role, error) { return nil, nil }
+
+func (r *fakeRepository) SaveRole(ctx context.Context, role *models.Role) error {
+	r.roles[role.Name] = role
+	return nil
+}
+
+func (r *fakeRepository) DeleteRole(ctx context.Context, name string) error {
+	delete(r.roles, name)
+	return nil
+}
+
+func (r *fakeRepository) CountUsersWithRole(ctx context.Context, name string) (int64, error) {
+	var count int64
+	for _, user := range r.users {
+		if user.Role == name {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRepository) FindUser(ctx context.Context, id uint) (*models.User, error) {
+	if user, ok := r.users[id]; ok {
+		copied := *user
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepository) FindUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepository) CreateUser(ctx context.Context, user *models.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeRepository) UpdateUserRole(ctx context.Context, userID uint, role string) error {
+	r.users[userID].Role = role
+	return nil
+}
+
+func (r *fakeRepository) UserPermissions(ctx context.Context, userID uint) ([]string, error) {
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.roles[user.Role].Permissions, nil
+}
+
+type nopAudit struct{ audit.Service }
+
+func (nopAudit) Record(ctx context.Context, actorID uint, action, targetType, targetID string, details interface{}) error {
+	return nil
+}
+
+func TestAssignRoleRefusesTargetsThatOutrankTheActor(t *testing.T) {
+	repo := newFakeRepository()
+	repo.users[1] = &models.User{ID: 1, Role: "roles_only"}
+	repo.users[2] = &models.User{ID: 2, Role: RoleSuperAdmin}
+	repo.users[3] = &models.User{ID: 3, Role: RoleSuperAdmin}
+	repo.users[4] = &models.User{ID: 4, Role: RoleUser}
+	svc := NewService(repo, nopAudit{})
+	ctx := context.Background()
+
+	if _, err := svc.AssignRole(ctx, 1, 2, RoleUser); !errors.Is(err, ErrOutranked) {
+		t.Fatalf("demoting a super admin: err = %v, want ErrOutranked", err)
+	}
+	if repo.users[2].Role != RoleSuperAdmin {
+		t.Fatalf("super admin was demoted to %s", repo.users[2].Role)
+	}
+
+	if _, err := svc.AssignRole(ctx, 1, 4, "roles_only"); err != nil {
+		t.Fatalf("assigning a role the actor holds to a plain user: %v", err)
+	}
+	if _, err := svc.AssignRole(ctx, 2, 3, RoleOperations); err != nil {
+		t.Fatalf("super admin demoting another super admin: %v", err)
+	}
+}
+
+func TestAssignRoleRefusesPermissionsTheActorLacks(t *testing.T) {
+	repo := newFakeRepository()
+	repo.users[1] = &models.User{ID: 1, Role: RoleSupport}
+	repo.users[2] = &models.User{ID: 2, Role: RoleUser}
+	svc := NewService(repo, nopAudit{})
+
+	if _, err := svc.AssignRole(context.Background(), 1, 2, RoleOperations); !errors.Is(err, ErrPrivilegeEscalation) {
+		t.Fatalf("err = %v, want ErrPrivilegeEscalation", err)
+	}
+}
+
+func TestNoBuiltInRoleIsCalledAdmin(t *testing.T) {
+	// Self-registration once accepted "admin"; such accounts must not map to
+	// a permission bundle.
+	if _, ok := defaultRoles["admin"]; ok {
+		t.Fatal(`built-in role "admin" would grant permissions to self-registered accounts`)
+	}
+}
@@ -0,0 +1,78 @@
+package reconciliation
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) ListRuns(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	runs, err := h.service.ListRuns(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reconciliation runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+func (h *Handler) GetRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid run id"})
+		return
+	}
+
+	run, err := h.service.GetRun(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, ErrRunNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reconciliation run"})
+		return
+	}
+	mismatches, err := h.service.ListMismatches(c.Request.Context(), MismatchFilter{RunID: run.ID, Limit: 500})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch mismatches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"run":        run,
+		"mismatches": mismatches,
+	})
+}
+
+func (h *Handler) ListMismatches(c *gin.Context) {
+	filter := MismatchFilter{Status: c.Query("status")}
+	if runID, err := strconv.ParseUint(c.Query("run_id"), 10, 32); err == nil {
+		filter.RunID = uint(runID)
+	}
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		filter.UserID = uint(userID)
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil {
+		filter.Limit = limit
+	}
+
+	mismatches, err := h.service.ListMismatches(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch mismatches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mismatches": mismatches})
+}
@@ -0,0 +1,181 @@
+package reconciliation
+
+import (
+	"context"
+
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/uow"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ledgerTotals sums each user's successful transactions into the balances
+// they should produce. Buys spend fiat; every other type carries a fiat
+// amount that is credited, negative for debit adjustments.
+const ledgerTotals = `
+	SELECT user_id,
+		SUM(CASE type WHEN 'buy' THEN -amount ELSE amount END) AS fiat,
+		SUM(CASE type WHEN 'buy' THEN gold_grams WHEN 'sell' THEN -gold_grams WHEN 'adjustment' THEN gold_grams ELSE 0 END) AS gold
+	FROM transactions
+	WHERE status = 'success'`
+
+const (
+	fiatTolerance = 0.005
+	goldTolerance = 0.00005
+)
+
+type MismatchFilter struct {
+	RunID  uint
+	UserID uint
+	Status string
+	Limit  int
+}
+
+type Repository interface {
+	WithTx(tx uow.Tx) Repository
+
+	CreateRun(ctx context.Context, run *models.ReconciliationRun) error
+	SaveRun(ctx context.Context, run *models.ReconciliationRun) error
+	FindRun(ctx context.Context, id uint) (*models.ReconciliationRun, error)
+	ListRuns(ctx context.Context, limit int) ([]models.ReconciliationRun, error)
+
+	CountWallets(ctx context.Context) (int64, error)
+	FindMismatches(ctx context.Context) ([]models.BalanceMismatch, error)
+	CreateMismatches(ctx context.Context, mismatches []models.BalanceMismatch) error
+	FindMismatch(ctx context.Context, id uint) (*models.BalanceMismatch, error)
+	ListMismatches(ctx context.Context, filter MismatchFilter) ([]models.BalanceMismatch, error)
+	LockMismatch(ctx context.Context, id uint) (*models.BalanceMismatch, error)
+	SaveMismatch(ctx context.Context, mismatch *models.BalanceMismatch) error
+	LockWallet(ctx context.Context, userID uint) (*models.Wallet, error)
+	LedgerBalance(ctx context.Context, userID uint) (float64, float64, error)
+	CreateTransaction(ctx context.Context, transaction *models.Transaction) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) WithTx(tx uow.Tx) Repository {
+	return &repository{db: tx.DB()}
+}
+
+func (r *repository) CreateRun(ctx context.Context, run *models.ReconciliationRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *repository) SaveRun(ctx context.Context, run *models.ReconciliationRun) error {
+	return r.db.WithContext(ctx).Save(run).Error
+}
+
+func (r *repository) FindRun(ctx context.Context, id uint) (*models.ReconciliationRun, error) {
+	var run models.ReconciliationRun
+	if err := r.db.WithContext(ctx).First(&run, id).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *repository) ListRuns(ctx context.Context, limit int) ([]models.ReconciliationRun, error) {
+	var runs []models.ReconciliationRun
+	err := r.db.WithContext(ctx).Order("id desc").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+func (r *repository) CountWallets(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Wallet{}).Count(&count).Error
+	return count, err
+}
+
+// FindMismatches compares every wallet with its ledger in one statement, so
+// all wallets are read from the same snapshot. Users with transactions but
+// no wallet count as holding zero.
+func (r *repository) FindMismatches(ctx context.Context) ([]models.BalanceMismatch, error) {
+	var mismatches []models.BalanceMismatch
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT COALESCE(w.user_id, l.user_id) AS user_id,
+			COALESCE(w.fiat_balance, 0) AS wallet_fiat,
+			COALESCE(l.fiat, 0) AS expected_fiat,
+			COALESCE(w.fiat_balance, 0) - COALESCE(l.fiat, 0) AS fiat_diff,
+			COALESCE(w.gold_grams, 0) AS wallet_gold,
+			COALESCE(l.gold, 0) AS expected_gold,
+			COALESCE(w.gold_grams, 0) - COALESCE(l.gold, 0) AS gold_diff
+		FROM wallets w
+		FULL OUTER JOIN (`+ledgerTotals+` GROUP BY user_id) l ON l.user_id = w.user_id
+		WHERE ABS(COALESCE(w.fiat_balance, 0) - COALESCE(l.fiat, 0)) >= ?
+			OR ABS(COALESCE(w.gold_grams, 0) - COALESCE(l.gold, 0)) >= ?
+		ORDER BY 1`, fiatTolerance, goldTolerance).
+		Scan(&mismatches).Error
+	return mismatches, err
+}
+
+func (r *repository) CreateMismatches(ctx context.Context, mismatches []models.BalanceMismatch) error {
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(mismatches, 500).Error
+}
+
+func (r *repository) FindMismatch(ctx context.Context, id uint) (*models.BalanceMismatch, error) {
+	var mismatch models.BalanceMismatch
+	if err := r.db.WithContext(ctx).First(&mismatch, id).Error; err != nil {
+		return nil, err
+	}
+	return &mismatch, nil
+}
+
+func (r *repository) ListMismatches(ctx context.Context, filter MismatchFilter) ([]models.BalanceMismatch, error) {
+	var mismatches []models.BalanceMismatch
+	query := r.db.WithContext(ctx).Model(&models.BalanceMismatch{})
+	if filter.RunID != 0 {
+		query = query.Where("run_id = ?", filter.RunID)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	err := query.Order("id desc").Limit(filter.Limit).Find(&mismatches).Error
+	return mismatches, err
+}
+
+func (r *repository) LockMismatch(ctx context.Context, id uint) (*models.BalanceMismatch, error) {
+	var mismatch models.BalanceMismatch
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&mismatch, id).Error; err != nil {
+		return nil, err
+	}
+	return &mismatch, nil
+}
+
+func (r *repository) SaveMismatch(ctx context.Context, mismatch *models.BalanceMismatch) error {
+	return r.db.WithContext(ctx).Save(mismatch).Error
+}
+
+// LockWallet returns the user's wallet with its row locked, or a zero wallet
+// when the user has none.
+func (r *repository) LockWallet(ctx context.Context, userID uint) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).Limit(1).Find(&wallet).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// LedgerBalance is the fiat and gold the user's successful transactions add
+// up to.
+func (r *repository) LedgerBalance(ctx context.Context, userID uint) (float64, float64, error) {
+	var fiat, gold float64
+	err := r.db.WithContext(ctx).Raw(`SELECT COALESCE(SUM(fiat), 0), COALESCE(SUM(gold), 0) FROM (`+ledgerTotals+` AND user_id = ? GROUP BY user_id) l`, userID).
+		Row().Scan(&fiat, &gold)
+	return fiat, gold, err
+}
+
+func (r *repository) CreateTransaction(ctx context.Context, transaction *models.Transaction) error {
+	return r.db.WithContext(ctx).Create(transaction).Error
+}
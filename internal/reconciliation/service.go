@@ -0,0 +1,201 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/uow"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrRunNotFound      = errors.New("reconciliation run not found")
+	ErrMismatchNotFound = errors.New("balance mismatch not found")
+	ErrMismatchResolved = errors.New("balance mismatch is already resolved")
+)
+
+type Service interface {
+	Run(ctx context.Context, scheduledFor time.Time) (*models.ReconciliationRun, error)
+	ListRuns(ctx context.Context, limit int) ([]models.ReconciliationRun, error)
+	GetRun(ctx context.Context, id uint) (*models.ReconciliationRun, error)
+	ListMismatches(ctx context.Context, filter MismatchFilter) ([]models.BalanceMismatch, error)
+	Resolve(ctx context.Context, actorID, mismatchID uint) (*models.BalanceMismatch, *models.Transaction, error)
+}
+
+type service struct {
+	repo  Repository
+	work  *uow.UnitOfWork
+	audit audit.Service
+}
+
+func NewService(repo Repository, work *uow.UnitOfWork, auditService audit.Service) Service {
+	return &service{repo: repo, work: work, audit: auditService}
+}
+
+// Run recomputes every wallet from its transaction history, stores the
+// wallets that disagree and returns the run it recorded. The run row is kept
+// even when it fails.
+func (s *service) Run(ctx context.Context, scheduledFor time.Time) (*models.ReconciliationRun, error) {
+	run := &models.ReconciliationRun{Status: models.ReconciliationRunning, StartedAt: time.Now()}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		return nil, fmt.Errorf("failed to start reconciliation run: %w", err)
+	}
+
+	err := s.reconcile(ctx, run)
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Status = models.ReconciliationCompleted
+	if err != nil {
+		run.Status = models.ReconciliationFailed
+		run.Error = err.Error()
+	}
+	if saveErr := s.repo.SaveRun(ctx, run); saveErr != nil {
+		return run, errors.Join(err, saveErr)
+	}
+	if err != nil {
+		return run, err
+	}
+
+	if run.Mismatches > 0 {
+		slog.WarnContext(ctx, "reconciliation: wallets out of balance", "run_id", run.ID, "mismatches", run.Mismatches, "wallets_checked", run.WalletsChecked)
+	}
+	return run, nil
+}
+
+func (s *service) reconcile(ctx context.Context, run *models.ReconciliationRun) error {
+	checked, err := s.repo.CountWallets(ctx)
+	if err != nil {
+		return err
+	}
+	mismatches, err := s.repo.FindMismatches(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range mismatches {
+		mismatches[i].RunID = run.ID
+		mismatches[i].Status = models.MismatchOpen
+		mismatches[i].FiatDiff = round(mismatches[i].FiatDiff, 2)
+		mismatches[i].GoldDiff = round(mismatches[i].GoldDiff, 4)
+	}
+	if err := s.repo.CreateMismatches(ctx, mismatches); err != nil {
+		return err
+	}
+
+	run.WalletsChecked = checked
+	run.Mismatches = int64(len(mismatches))
+	return nil
+}
+
+func (s *service) ListRuns(ctx context.Context, limit int) ([]models.ReconciliationRun, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.ListRuns(ctx, limit)
+}
+
+func (s *service) GetRun(ctx context.Context, id uint) (*models.ReconciliationRun, error) {
+	run, err := s.repo.FindRun(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRunNotFound
+		}
+		return nil, err
+	}
+	return run, nil
+}
+
+func (s *service) ListMismatches(ctx context.Context, filter MismatchFilter) ([]models.BalanceMismatch, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.repo.ListMismatches(ctx, filter)
+}
+
+// Resolve closes a mismatch by posting an adjustment transaction for the
+// difference, so the history explains the wallet balance again. The
+// difference is re-measured with the wallet row locked and the wallet itself
+// is left untouched; when the difference has disappeared in the meantime no
+// transaction is written.
+func (s *service) Resolve(ctx context.Context, actorID, mismatchID uint) (*models.BalanceMismatch, *models.Transaction, error) {
+	var mismatch *models.BalanceMismatch
+	var transaction *models.Transaction
+
+	err := s.work.Do(ctx, func(ctx context.Context, tx uow.Tx) error {
+		repo := s.repo.WithTx(tx)
+		transaction = nil
+
+		var err error
+		mismatch, err = repo.LockMismatch(ctx, mismatchID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMismatchNotFound
+			}
+			return err
+		}
+		if mismatch.Status != models.MismatchOpen {
+			return ErrMismatchResolved
+		}
+
+		wallet, err := repo.LockWallet(ctx, mismatch.UserID)
+		if err != nil {
+			return err
+		}
+		fiat, gold, err := repo.LedgerBalance(ctx, mismatch.UserID)
+		if err != nil {
+			return err
+		}
+
+		fiatDiff, goldDiff := wallet.FiatBalance-fiat, wallet.GoldGrams-gold
+		if math.Abs(fiatDiff) >= fiatTolerance || math.Abs(goldDiff) >= goldTolerance {
+			transaction = &models.Transaction{
+				UserID:      mismatch.UserID,
+				Type:        models.TransactionTypeAdjustment,
+				Amount:      fiatDiff,
+				GoldGrams:   goldDiff,
+				Status:      models.TransactionStatusSuccess,
+				ReferenceID: fmt.Sprintf("reconciliation_%d", mismatch.ID),
+			}
+			if err := repo.CreateTransaction(ctx, transaction); err != nil {
+				return err
+			}
+			mismatch.TransactionID = &transaction.ID
+		}
+
+		now := time.Now()
+		mismatch.Status = models.MismatchResolved
+		mismatch.ResolvedBy = &actorID
+		mismatch.ResolvedAt = &now
+		return repo.SaveMismatch(ctx, mismatch)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	details := map[string]interface{}{
+		"user_id":   mismatch.UserID,
+		"fiat_diff": mismatch.FiatDiff,
+		"gold_diff": mismatch.GoldDiff,
+	}
+	if transaction != nil {
+		details["transaction_id"] = transaction.ID
+		details["posted_fiat"] = transaction.Amount
+		details["posted_gold"] = transaction.GoldGrams
+	}
+	if err := s.audit.Record(ctx, actorID, "reconciliation.resolve", "balance_mismatch", strconv.FormatUint(uint64(mismatch.ID), 10), details); err != nil {
+		return nil, nil, err
+	}
+	return mismatch, transaction, nil
+}
+
+func round(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
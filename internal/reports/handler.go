@@ -0,0 +1,133 @@
+package reports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const defaultRangeDays = 30
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"reports": Names()})
+}
+
+// Get serves one report. Without from/to it covers the last 30 completed
+// days. compare=previous compares with the equally long period right
+// before; compare_from/compare_to pick any other period.
+func (h *Handler) Get(c *gin.Context) {
+	current, err := h.currentRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var previous *DateRange
+	switch {
+	case c.Query("compare_from") != "" || c.Query("compare_to") != "":
+		from, err := parseDate(c, "compare_from")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		to, err := parseDate(c, "compare_to")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		previous = &DateRange{From: from, To: to}
+	case c.Query("compare") == "previous":
+		days := int(current.To.Sub(current.From).Hours()/24) + 1
+		previous = &DateRange{From: current.From.AddDate(0, 0, -days), To: current.From.AddDate(0, 0, -1)}
+	case c.Query("compare") != "":
+		c.JSON(http.StatusBadRequest, gin.H{"error": "compare must be previous"})
+		return
+	}
+
+	report, err := h.service.Report(c.Request.Context(), c.Param("name"), current, previous)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnknownReport):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrInvalidRange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: to must not be before from, and a range can span at most %d days", err, maxRangeDays)})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		}
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		writeCSV(c, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) currentRange(c *gin.Context) (DateRange, error) {
+	if c.Query("from") == "" && c.Query("to") == "" {
+		now := time.Now().In(h.service.Location())
+		to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+		return DateRange{From: to.AddDate(0, 0, 1-defaultRangeDays), To: to}, nil
+	}
+
+	from, err := parseDate(c, "from")
+	if err != nil {
+		return DateRange{}, err
+	}
+	to, err := parseDate(c, "to")
+	if err != nil {
+		return DateRange{}, err
+	}
+	return DateRange{From: from, To: to}, nil
+}
+
+func parseDate(c *gin.Context, param string) (time.Time, error) {
+	date, err := time.Parse(dateLayout, c.Query(param))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be YYYY-MM-DD", param)
+	}
+	return date, nil
+}
+
+// writeCSV emits one line per day; with a comparison the previous period's
+// days follow, told apart by the period column.
+func writeCSV(c *gin.Context, report *Report) {
+	filename := fmt.Sprintf("%s_%s_%s.csv", report.Name, report.Current.From, report.Current.To)
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(append([]string{"period", "date"}, report.Columns...))
+	periods := []struct {
+		label  string
+		period *Period
+	}{{"current", &report.Current}, {"previous", report.Previous}}
+	for _, p := range periods {
+		if p.period == nil {
+			continue
+		}
+		for _, row := range p.period.Rows {
+			record := []string{p.label, row.Date}
+			for _, column := range report.Columns {
+				record = append(record, strconv.FormatFloat(row.Values[column], 'f', -1, 64))
+			}
+			w.Write(record)
+		}
+	}
+	w.Flush()
+}
@@ -0,0 +1,126 @@
+package reports
+
+import (
+	"context"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	LatestSnapshotDate(ctx context.Context) (*time.Time, error)
+	FirstActivity(ctx context.Context) (*time.Time, error)
+	ComputeSnapshot(ctx context.Context, date, start, end time.Time, saverWindow time.Duration) (*models.DailySnapshot, error)
+	SaveSnapshot(ctx context.Context, snapshot *models.DailySnapshot) error
+	ListSnapshots(ctx context.Context, from, to time.Time) ([]models.DailySnapshot, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) LatestSnapshotDate(ctx context.Context) (*time.Time, error) {
+	var snapshot models.DailySnapshot
+	result := r.db.WithContext(ctx).Order("date desc").Limit(1).Find(&snapshot)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &snapshot.Date, nil
+}
+
+// FirstActivity is the time of the first signup, or nil on an empty
+// database.
+func (r *repository) FirstActivity(ctx context.Context) (*time.Time, error) {
+	var first *time.Time
+	err := r.db.WithContext(ctx).Model(&models.User{}).Select("MIN(created_at)").Row().Scan(&first)
+	return first, err
+}
+
+// ComputeSnapshot derives the figures for [start, end) from the source
+// tables. Liabilities replay every successful transaction up to end, so
+// past days can be rebuilt.
+func (r *repository) ComputeSnapshot(ctx context.Context, date, start, end time.Time, saverWindow time.Duration) (*models.DailySnapshot, error) {
+	snapshot := &models.DailySnapshot{Date: date}
+
+	var volumes []struct {
+		Type  string
+		Count int64
+		NPR   float64
+		Grams float64
+	}
+	err := r.db.WithContext(ctx).Model(&models.Transaction{}).
+		Select("type, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS npr, COALESCE(SUM(gold_grams), 0) AS grams").
+		Where("status = ? AND created_at >= ? AND created_at < ?", models.TransactionStatusSuccess, start, end).
+		Group("type").
+		Scan(&volumes).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, volume := range volumes {
+		switch models.TransactionType(volume.Type) {
+		case models.TransactionTypeBuy:
+			snapshot.BuyCount, snapshot.BuyNPR, snapshot.BuyGrams = volume.Count, volume.NPR, volume.Grams
+		case models.TransactionTypeSell:
+			snapshot.SellCount, snapshot.SellNPR, snapshot.SellGrams = volume.Count, volume.NPR, volume.Grams
+		case models.TransactionTypeTopUp:
+			snapshot.TopUpNPR = volume.NPR
+		}
+	}
+
+	err = r.db.WithContext(ctx).Model(&models.Transaction{}).
+		Select(`COALESCE(SUM(CASE type WHEN 'buy' THEN gold_grams WHEN 'sell' THEN -gold_grams WHEN 'adjustment' THEN gold_grams ELSE 0 END), 0),
+			COALESCE(SUM(CASE type WHEN 'buy' THEN -amount ELSE amount END), 0)`).
+		Where("status = ? AND created_at < ?", models.TransactionStatusSuccess, end).
+		Row().Scan(&snapshot.GoldLiabilityGrams, &snapshot.FiatLiabilityNPR)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := []struct {
+		target *int64
+		query  *gorm.DB
+	}{
+		{&snapshot.NewSignups, r.db.WithContext(ctx).Model(&models.User{}).
+			Where("created_at >= ? AND created_at < ?", start, end)},
+		{&snapshot.KYCSubmitted, r.db.WithContext(ctx).Model(&models.KYCSubmission{}).
+			Where("submitted_at >= ? AND submitted_at < ?", start, end)},
+		{&snapshot.KYCVerified, r.db.WithContext(ctx).Model(&models.KYCSubmission{}).
+			Where("status = ? AND reviewed_at >= ? AND reviewed_at < ?", models.KYCStatusVerified, start, end)},
+		{&snapshot.KYCRejected, r.db.WithContext(ctx).Model(&models.KYCSubmission{}).
+			Where("status = ? AND reviewed_at >= ? AND reviewed_at < ?", models.KYCStatusRejected, start, end)},
+		{&snapshot.ActiveSavers, r.db.WithContext(ctx).Model(&models.Transaction{}).
+			Distinct("user_id").
+			Where("type = ? AND status = ? AND created_at >= ? AND created_at < ?",
+				models.TransactionTypeBuy, models.TransactionStatusSuccess, end.Add(-saverWindow), end)},
+	}
+	for _, count := range counts {
+		if err := count.query.Count(count.target).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return snapshot, nil
+}
+
+func (r *repository) SaveSnapshot(ctx context.Context, snapshot *models.DailySnapshot) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"buy_count", "buy_grams", "buy_npr", "sell_count", "sell_grams", "sell_npr", "top_up_npr",
+			"gold_liability_grams", "fiat_liability_npr", "new_signups", "kyc_submitted", "kyc_verified",
+			"kyc_rejected", "active_savers", "updated_at",
+		}),
+	}).Create(snapshot).Error
+}
+
+func (r *repository) ListSnapshots(ctx context.Context, from, to time.Time) ([]models.DailySnapshot, error) {
+	var snapshots []models.DailySnapshot
+	err := r.db.WithContext(ctx).Where("date >= ? AND date <= ?", from, to).Order("date").Find(&snapshots).Error
+	return snapshots, err
+}
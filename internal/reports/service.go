@@ -0,0 +1,286 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/models"
+)
+
+const (
+	// maxBackfillDays bounds how far the snapshot job reaches back when it
+	// finds a gap, including the first run on an existing database.
+	maxBackfillDays = 400
+	maxRangeDays    = 366
+	dateLayout      = "2006-01-02"
+)
+
+var (
+	ErrUnknownReport = errors.New("unknown report")
+	ErrInvalidRange  = errors.New("invalid date range")
+)
+
+type summaryKind int
+
+const (
+	sumOverRange summaryKind = iota
+	lastInRange
+)
+
+type metric struct {
+	name    string
+	summary summaryKind
+	value   func(s *models.DailySnapshot) float64
+}
+
+type definition struct {
+	metrics []metric
+	derive  func(summary map[string]float64)
+}
+
+var definitions = map[string]definition{
+	"volume": {metrics: []metric{
+		{"buy_count", sumOverRange, func(s *models.DailySnapshot) float64 { return float64(s.BuyCount) }},
+		{"buy_grams", sumOverRange, func(s *models.DailySnapshot) float64 { return s.BuyGrams }},
+		{"buy_npr", sumOverRange, func(s *models.DailySnapshot) float64 { return s.BuyNPR }},
+		{"sell_count", sumOverRange, func(s *models.DailySnapshot) float64 { return float64(s.SellCount) }},
+		{"sell_grams", sumOverRange, func(s *models.DailySnapshot) float64 { return s.SellGrams }},
+		{"sell_npr", sumOverRange, func(s *models.DailySnapshot) float64 { return s.SellNPR }},
+		{"net_grams", sumOverRange, func(s *models.DailySnapshot) float64 { return s.BuyGrams - s.SellGrams }},
+		{"topup_npr", sumOverRange, func(s *models.DailySnapshot) float64 { return s.TopUpNPR }},
+	}},
+	"liability": {metrics: []metric{
+		{"gold_liability_grams", lastInRange, func(s *models.DailySnapshot) float64 { return s.GoldLiabilityGrams }},
+		{"fiat_liability_npr", lastInRange, func(s *models.DailySnapshot) float64 { return s.FiatLiabilityNPR }},
+	}},
+	"signups": {metrics: []metric{
+		{"new_signups", sumOverRange, func(s *models.DailySnapshot) float64 { return float64(s.NewSignups) }},
+	}},
+	"kyc-funnel": {
+		metrics: []metric{
+			{"new_signups", sumOverRange, func(s *models.DailySnapshot) float64 { return float64(s.NewSignups) }},
+			{"kyc_submitted", sumOverRange, func(s *models.DailySnapshot) float64 { return float64(s.KYCSubmitted) }},
+			{"kyc_verified", sumOverRange, func(s *models.DailySnapshot) float64 { return float64(s.KYCVerified) }},
+			{"kyc_rejected", sumOverRange, func(s *models.DailySnapshot) float64 { return float64(s.KYCRejected) }},
+		},
+		derive: func(summary map[string]float64) {
+			summary["submission_rate"] = ratio(summary["kyc_submitted"], summary["new_signups"])
+			summary["approval_rate"] = ratio(summary["kyc_verified"], summary["kyc_verified"]+summary["kyc_rejected"])
+		},
+	},
+	"active-savers": {metrics: []metric{
+		{"active_savers", lastInRange, func(s *models.DailySnapshot) float64 { return float64(s.ActiveSavers) }},
+	}},
+}
+
+// Names lists the available reports.
+func Names() []string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type DateRange struct {
+	From time.Time
+	To   time.Time
+}
+
+type Row struct {
+	Date   string             `json:"date"`
+	Values map[string]float64 `json:"values"`
+}
+
+type Period struct {
+	From    string             `json:"from"`
+	To      string             `json:"to"`
+	Rows    []Row              `json:"rows"`
+	Summary map[string]float64 `json:"summary"`
+}
+
+type Change struct {
+	Absolute float64  `json:"absolute"`
+	Percent  *float64 `json:"percent"`
+}
+
+type Report struct {
+	Name     string            `json:"name"`
+	Columns  []string          `json:"columns"`
+	Current  Period            `json:"current"`
+	Previous *Period           `json:"previous,omitempty"`
+	Change   map[string]Change `json:"change,omitempty"`
+}
+
+type Service interface {
+	BuildSnapshots(ctx context.Context, scheduledFor time.Time) error
+	Report(ctx context.Context, name string, current DateRange, previous *DateRange) (*Report, error)
+	Location() *time.Location
+}
+
+type service struct {
+	repo        Repository
+	location    *time.Location
+	saverWindow time.Duration
+}
+
+func NewService(repo Repository, cfg *config.Config) Service {
+	location, err := time.LoadLocation(cfg.Reports.Timezone)
+	if err != nil {
+		slog.Warn("reports: unknown REPORT_TIMEZONE, using UTC", "timezone", cfg.Reports.Timezone, "error", err)
+		location = time.UTC
+	}
+	return &service{
+		repo:        repo,
+		location:    location,
+		saverWindow: time.Duration(cfg.Reports.ActiveSaverWindowDays) * 24 * time.Hour,
+	}
+}
+
+func (s *service) Location() *time.Location {
+	return s.location
+}
+
+// BuildSnapshots writes the snapshot of every completed day that has none
+// yet, and always rebuilds the day before scheduledFor so late writes are
+// picked up.
+func (s *service) BuildSnapshots(ctx context.Context, scheduledFor time.Time) error {
+	local := scheduledFor.In(s.location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	last := today.AddDate(0, 0, -1)
+
+	first := last
+	latest, err := s.repo.LatestSnapshotDate(ctx)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		if next := latest.AddDate(0, 0, 1); next.Before(first) {
+			first = next
+		}
+	} else {
+		activity, err := s.repo.FirstActivity(ctx)
+		if err != nil {
+			return err
+		}
+		if activity != nil {
+			a := activity.In(s.location)
+			first = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+		}
+	}
+	if earliest := last.AddDate(0, 0, -maxBackfillDays); first.Before(earliest) {
+		first = earliest
+	}
+
+	built := 0
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, s.location)
+		snapshot, err := s.repo.ComputeSnapshot(ctx, day, start, start.AddDate(0, 0, 1), s.saverWindow)
+		if err != nil {
+			return fmt.Errorf("failed to compute snapshot for %s: %w", day.Format(dateLayout), err)
+		}
+		if err := s.repo.SaveSnapshot(ctx, snapshot); err != nil {
+			return fmt.Errorf("failed to save snapshot for %s: %w", day.Format(dateLayout), err)
+		}
+		built++
+	}
+
+	slog.InfoContext(ctx, "reports: built daily snapshots", "count", built, "through", last.Format(dateLayout))
+	return nil
+}
+
+func (s *service) Report(ctx context.Context, name string, current DateRange, previous *DateRange) (*Report, error) {
+	def, ok := definitions[name]
+	if !ok {
+		return nil, ErrUnknownReport
+	}
+
+	report := &Report{Name: name}
+	for _, m := range def.metrics {
+		report.Columns = append(report.Columns, m.name)
+	}
+
+	period, err := s.period(ctx, def, current)
+	if err != nil {
+		return nil, err
+	}
+	report.Current = *period
+
+	if previous != nil {
+		period, err := s.period(ctx, def, *previous)
+		if err != nil {
+			return nil, err
+		}
+		report.Previous = period
+		report.Change = make(map[string]Change, len(report.Current.Summary))
+		for key, now := range report.Current.Summary {
+			before := period.Summary[key]
+			change := Change{Absolute: round(now - before)}
+			if before != 0 {
+				percent := round((now - before) / math.Abs(before) * 100)
+				change.Percent = &percent
+			}
+			report.Change[key] = change
+		}
+	}
+	return report, nil
+}
+
+func (s *service) period(ctx context.Context, def definition, r DateRange) (*Period, error) {
+	if r.To.Before(r.From) || r.To.Sub(r.From) > maxRangeDays*24*time.Hour {
+		return nil, ErrInvalidRange
+	}
+
+	snapshots, err := s.repo.ListSnapshots(ctx, r.From, r.To)
+	if err != nil {
+		return nil, err
+	}
+
+	period := &Period{
+		From:    r.From.Format(dateLayout),
+		To:      r.To.Format(dateLayout),
+		Rows:    make([]Row, 0, len(snapshots)),
+		Summary: make(map[string]float64, len(def.metrics)),
+	}
+	for i := range snapshots {
+		row := Row{Date: snapshots[i].Date.Format(dateLayout), Values: make(map[string]float64, len(def.metrics))}
+		for _, m := range def.metrics {
+			value := m.value(&snapshots[i])
+			row.Values[m.name] = round(value)
+			if m.summary == sumOverRange {
+				period.Summary[m.name] += value
+			} else {
+				period.Summary[m.name] = value
+			}
+		}
+		period.Rows = append(period.Rows, row)
+	}
+	for _, m := range def.metrics {
+		period.Summary[m.name] = round(period.Summary[m.name])
+	}
+	if def.derive != nil {
+		def.derive(period.Summary)
+	}
+	return period, nil
+}
+
+func ratio(numerator, denominator float64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return round(numerator / denominator)
+}
+
+func round(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
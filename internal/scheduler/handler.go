@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) ListJobs(c *gin.Context) {
+	jobs, err := h.service.ListJobs(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch jobs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
+
+func (h *Handler) ListRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	runs, err := h.service.ListRuns(c.Request.Context(), c.Param("name"), limit)
+	if err != nil {
+		if err == ErrJobNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch job runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+func (h *Handler) Trigger(c *gin.Context) {
+	if err := h.service.Trigger(c.Param("name")); err != nil {
+		h.writeError(c, err, "job trigger failed")
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "job triggered"})
+}
+
+func (h *Handler) Pause(c *gin.Context) {
+	if err := h.service.Pause(c.Request.Context(), c.Param("name")); err != nil {
+		h.writeError(c, err, "job pause failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "job paused"})
+}
+
+func (h *Handler) Resume(c *gin.Context) {
+	if err := h.service.Resume(c.Request.Context(), c.Param("name")); err != nil {
+		h.writeError(c, err, "job resume failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "job resumed"})
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, message string) {
+	switch err {
+	case ErrJobNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+	case ErrJobRunning:
+		c.JSON(http.StatusConflict, gin.H{"error": "job is already running"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/google/uuid"
+)
+
+const releaseScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+const renewScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`
+
+type Lease interface {
+	Renew(ctx context.Context, ttl time.Duration) (bool, error)
+	Release(ctx context.Context) error
+}
+
+type Locker interface {
+	Acquire(ctx context.Context, name string, ttl time.Duration) (Lease, bool, error)
+}
+
+// RedisLocker elects a single owner per job with SET NX PX. Each lease
+// carries a random token so an instance whose lease expired cannot release
+// or extend the lease of the instance that took over.
+type RedisLocker struct {
+	client *redis.Client
+}
+
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{client: client}
+}
+
+func (l *RedisLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (Lease, bool, error) {
+	key := "scheduler:lease:" + name
+	token := uuid.New().String()
+
+	ok, err := l.client.SetNX(ctx, key, token, ttl)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return &redisLease{client: l.client, key: key, token: token}, true, nil
+}
+
+type redisLease struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+func (l *redisLease) Renew(ctx context.Context, ttl time.Duration) (bool, error) {
+	result, err := l.client.Eval(ctx, renewScript, []string{l.key}, l.token, ttl.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.(int64)
+	return n == 1, nil
+}
+
+func (l *redisLease) Release(ctx context.Context) error {
+	_, err := l.client.Eval(ctx, releaseScript, []string{l.key}, l.token)
+	return err
+}
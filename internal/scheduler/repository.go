@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"context"
+
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	FindJob(ctx context.Context, name string) (*models.ScheduledJob, error)
+	SaveJob(ctx context.Context, job *models.ScheduledJob) error
+	ListJobs(ctx context.Context) ([]models.ScheduledJob, error)
+	SetPaused(ctx context.Context, name string, paused bool) error
+
+	CreateRun(ctx context.Context, run *models.JobRun) error
+	UpdateRun(ctx context.Context, run *models.JobRun) error
+	ListRuns(ctx context.Context, jobName string, limit int) ([]models.JobRun, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) FindJob(ctx context.Context, name string) (*models.ScheduledJob, error) {
+	var job models.ScheduledJob
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *repository) SaveJob(ctx context.Context, job *models.ScheduledJob) error {
+	return r.db.WithContext(ctx).Save(job).Error
+}
+
+func (r *repository) ListJobs(ctx context.Context) ([]models.ScheduledJob, error) {
+	var jobs []models.ScheduledJob
+	err := r.db.WithContext(ctx).Order("name").Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *repository) SetPaused(ctx context.Context, name string, paused bool) error {
+	result := r.db.WithContext(ctx).Model(&models.ScheduledJob{}).Where("name = ?", name).Update("paused", paused)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) CreateRun(ctx context.Context, run *models.JobRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *repository) UpdateRun(ctx context.Context, run *models.JobRun) error {
+	return r.db.WithContext(ctx).Save(run).Error
+}
+
+func (r *repository) ListRuns(ctx context.Context, jobName string, limit int) ([]models.JobRun, error) {
+	var runs []models.JobRun
+	err := r.db.WithContext(ctx).Where("job_name = ?", jobName).
+		Order("started_at desc").
+		Limit(limit).
+		Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,502 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrJobNotFound     = errors.New("job not found")
+	ErrJobRunning      = errors.New("job is already running")
+	ErrInvalidSchedule = errors.New("invalid cron schedule")
+)
+
+type CatchUp string
+
+const (
+	// CatchUpSkip runs only if the latest due time is recent; older missed
+	// runs are recorded as skipped.
+	CatchUpSkip CatchUp = "skip"
+	// CatchUpOnce runs a single time for the latest missed slot.
+	CatchUpOnce CatchUp = "once"
+	// CatchUpAll runs every missed slot, oldest first, up to maxCatchUpRuns.
+	CatchUpAll CatchUp = "all"
+)
+
+const (
+	tickInterval    = 5 * time.Second
+	leaseTTL        = 30 * time.Second
+	missGrace       = time.Minute
+	maxCatchUpRuns  = 24
+	maxCatchUpScan  = 10000
+	defaultTimeout  = 10 * time.Minute
+	triggerSchedule = "schedule"
+	triggerManual   = "manual"
+)
+
+type JobFunc func(ctx context.Context, scheduledFor time.Time) error
+
+type Job struct {
+	Name       string
+	Schedule   string
+	CatchUp    CatchUp
+	Timeout    time.Duration
+	RunOnStart bool
+	Run        JobFunc
+}
+
+type registeredJob struct {
+	Job
+	schedule cron.Schedule
+}
+
+type JobInfo struct {
+	models.ScheduledJob
+	Running bool `json:"running"`
+}
+
+type Service struct {
+	repo     Repository
+	locker   Locker
+	instance string
+
+	mu      sync.Mutex
+	jobs    map[string]*registeredJob
+	running map[string]bool
+
+	wg         sync.WaitGroup
+	stopping   chan struct{}
+	stopOnce   sync.Once
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+}
+
+func NewService(repo Repository, locker Locker) *Service {
+	hostname, _ := os.Hostname()
+	jobCtx, cancel := context.WithCancel(context.Background())
+	return &Service{
+		repo:       repo,
+		locker:     locker,
+		instance:   hostname + "-" + strconv.Itoa(os.Getpid()),
+		jobs:       make(map[string]*registeredJob),
+		running:    make(map[string]bool),
+		stopping:   make(chan struct{}),
+		jobCtx:     jobCtx,
+		cancelJobs: cancel,
+	}
+}
+
+func (s *Service) Register(ctx context.Context, job Job) error {
+	schedule, err := cron.ParseStandard(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidSchedule, job.Schedule, err)
+	}
+	if job.CatchUp == "" {
+		job.CatchUp = CatchUpSkip
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = defaultTimeout
+	}
+
+	now := time.Now()
+	state, err := s.repo.FindJob(ctx, job.Name)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		state = &models.ScheduledJob{
+			Name:      job.Name,
+			Schedule:  job.Schedule,
+			CatchUp:   string(job.CatchUp),
+			NextRunAt: schedule.Next(now),
+		}
+		if job.RunOnStart {
+			state.NextRunAt = now
+		}
+		if err := s.repo.SaveJob(ctx, state); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	case state.Schedule != job.Schedule || state.CatchUp != string(job.CatchUp):
+		state.Schedule = job.Schedule
+		state.CatchUp = string(job.CatchUp)
+		state.NextRunAt = schedule.Next(now)
+		if err := s.repo.SaveJob(ctx, state); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.jobs[job.Name] = &registeredJob{Job: job, schedule: schedule}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Service) Start(ctx context.Context) {
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	s.tick(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			s.tick(ctx)
+		case <-s.stopping:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop ends the tick loop and waits for running jobs. Jobs still running at
+// the ctx deadline are cancelled and Stop returns without waiting for them.
+func (s *Service) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stopping) })
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancelJobs()
+		return ctx.Err()
+	}
+}
+
+func (s *Service) ListJobs(ctx context.Context) ([]JobInfo, error) {
+	states, err := s.repo.ListJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]JobInfo, 0, len(states))
+	for _, state := range states {
+		if _, ok := s.jobs[state.Name]; !ok {
+			continue
+		}
+		jobs = append(jobs, JobInfo{ScheduledJob: state, Running: s.running[state.Name]})
+	}
+	return jobs, nil
+}
+
+func (s *Service) ListRuns(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	if _, ok := s.lookup(name); !ok {
+		return nil, ErrJobNotFound
+	}
+	return s.repo.ListRuns(ctx, name, limit)
+}
+
+func (s *Service) Pause(ctx context.Context, name string) error {
+	return s.setPaused(ctx, name, true)
+}
+
+func (s *Service) Resume(ctx context.Context, name string) error {
+	return s.setPaused(ctx, name, false)
+}
+
+// Trigger runs the job now on this instance, outside its schedule. It still
+// takes the job's lease, so it never overlaps a scheduled run elsewhere.
+func (s *Service) Trigger(name string) error {
+	job, ok := s.lookup(name)
+	if !ok {
+		return ErrJobNotFound
+	}
+	if !s.markRunning(name) {
+		return ErrJobRunning
+	}
+
+	lease, acquired, err := s.locker.Acquire(context.Background(), name, leaseTTL)
+	if err != nil || !acquired {
+		s.unmarkRunning(name)
+		if err != nil {
+			return err
+		}
+		return ErrJobRunning
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.unmarkRunning(name)
+		s.withLease(lease, func(ctx context.Context) {
+			now := time.Now()
+			s.execute(ctx, job, now, triggerManual)
+
+			if state, err := s.repo.FindJob(context.WithoutCancel(ctx), name); err == nil {
+				state.LastRunAt = &now
+				s.saveState(context.WithoutCancel(ctx), state)
+			}
+		})
+	}()
+	return nil
+}
+
+func (s *Service) tick(ctx context.Context) {
+	s.mu.Lock()
+	jobs := make([]*registeredJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		if !s.running[job.Name] {
+			jobs = append(jobs, job)
+		}
+	}
+	s.mu.Unlock()
+
+	now := time.Now()
+	for _, job := range jobs {
+		state, err := s.repo.FindJob(ctx, job.Name)
+		if err != nil {
+			slog.ErrorContext(ctx, "scheduler: load job failed", "job", job.Name, "error", err)
+			continue
+		}
+		if state.Paused || now.Before(state.NextRunAt) {
+			continue
+		}
+		s.launch(job)
+	}
+}
+
+func (s *Service) launch(job *registeredJob) {
+	if !s.markRunning(job.Name) {
+		return
+	}
+
+	lease, acquired, err := s.locker.Acquire(context.Background(), job.Name, leaseTTL)
+	if err != nil || !acquired {
+		if err != nil {
+			slog.Error("scheduler: acquire lease failed", "job", job.Name, "error", err)
+		}
+		s.unmarkRunning(job.Name)
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.unmarkRunning(job.Name)
+		s.withLease(lease, func(ctx context.Context) {
+			s.runDue(ctx, job)
+		})
+	}()
+}
+
+// runDue re-reads the job state under the lease: another instance may have
+// run it between our tick and the lease acquisition.
+func (s *Service) runDue(ctx context.Context, job *registeredJob) {
+	state, err := s.repo.FindJob(ctx, job.Name)
+	if err != nil {
+		slog.ErrorContext(ctx, "scheduler: reload job failed", "job", job.Name, "error", err)
+		return
+	}
+
+	now := time.Now()
+	if state.Paused || now.Before(state.NextRunAt) {
+		return
+	}
+
+	due, total := dueTimes(job.schedule, state.NextRunAt, now)
+	latest := due[len(due)-1]
+
+	switch job.CatchUp {
+	case CatchUpAll:
+		if total > len(due) {
+			s.recordSkipped(ctx, job.Name, state.NextRunAt, fmt.Sprintf("%d missed runs beyond catch-up limit", total-len(due)))
+		}
+		for _, scheduledFor := range due {
+			if ctx.Err() != nil {
+				break
+			}
+			s.execute(ctx, job, scheduledFor, triggerSchedule)
+		}
+	case CatchUpOnce:
+		s.execute(ctx, job, latest, triggerSchedule)
+	default:
+		if total > 1 {
+			s.recordSkipped(ctx, job.Name, state.NextRunAt, fmt.Sprintf("skipped %d missed runs", total-1))
+		}
+		if now.Sub(latest) <= missGrace {
+			s.execute(ctx, job, latest, triggerSchedule)
+		} else {
+			s.recordSkipped(ctx, job.Name, latest, "run is older than the catch-up grace period")
+		}
+	}
+
+	finished := time.Now()
+	state.LastRunAt = &finished
+	state.NextRunAt = job.schedule.Next(finished)
+	s.saveState(context.WithoutCancel(ctx), state)
+}
+
+func (s *Service) execute(ctx context.Context, job *registeredJob, scheduledFor time.Time, trigger string) {
+	run := &models.JobRun{
+		JobName:      job.Name,
+		Instance:     s.instance,
+		Trigger:      trigger,
+		ScheduledFor: scheduledFor,
+		StartedAt:    time.Now(),
+		Status:       models.JobRunRunning,
+	}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		slog.ErrorContext(ctx, "scheduler: record run failed", "job", job.Name, "error", err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	err := safeRun(runCtx, job.Run, scheduledFor)
+	cancel()
+
+	finished := time.Now()
+	run.FinishedAt = &finished
+	run.Status = models.JobRunSucceeded
+	if err != nil {
+		run.Status = models.JobRunFailed
+		run.Error = truncate(err.Error(), 1000)
+		slog.ErrorContext(ctx, "scheduler: job failed", "job", job.Name, "error", err)
+	}
+	if run.ID != 0 {
+		// Recorded even when the run was cancelled by shutdown or a lost
+		// lease.
+		if err := s.repo.UpdateRun(context.WithoutCancel(ctx), run); err != nil {
+			slog.ErrorContext(ctx, "scheduler: update run failed", "job", job.Name, "error", err)
+		}
+	}
+}
+
+// withLease keeps the lease alive while fn runs and cancels fn's context if
+// the lease is lost, so two instances never keep running the same job.
+func (s *Service) withLease(lease Lease, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(s.jobCtx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(leaseTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				renewed, err := lease.Renew(context.Background(), leaseTTL)
+				if err != nil || !renewed {
+					slog.WarnContext(ctx, "scheduler: lost lease, cancelling run")
+					cancel()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	fn(ctx)
+	close(done)
+
+	if err := lease.Release(context.Background()); err != nil {
+		slog.ErrorContext(ctx, "scheduler: release lease failed", "error", err)
+	}
+}
+
+func (s *Service) recordSkipped(ctx context.Context, name string, scheduledFor time.Time, reason string) {
+	now := time.Now()
+	run := &models.JobRun{
+		JobName:      name,
+		Instance:     s.instance,
+		Trigger:      triggerSchedule,
+		ScheduledFor: scheduledFor,
+		StartedAt:    now,
+		FinishedAt:   &now,
+		Status:       models.JobRunSkipped,
+		Error:        reason,
+	}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		slog.ErrorContext(ctx, "scheduler: record skipped run failed", "job", name, "error", err)
+	}
+}
+
+func (s *Service) saveState(ctx context.Context, state *models.ScheduledJob) {
+	if err := s.repo.SaveJob(ctx, state); err != nil {
+		slog.ErrorContext(ctx, "scheduler: save job failed", "job", state.Name, "error", err)
+	}
+}
+
+func (s *Service) setPaused(ctx context.Context, name string, paused bool) error {
+	if _, ok := s.lookup(name); !ok {
+		return ErrJobNotFound
+	}
+	if err := s.repo.SetPaused(ctx, name, paused); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrJobNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) lookup(name string) (*registeredJob, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[name]
+	return job, ok
+}
+
+func (s *Service) markRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running[name] {
+		return false
+	}
+	s.running[name] = true
+	return true
+}
+
+func (s *Service) unmarkRunning(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.running, name)
+}
+
+// dueTimes lists the slots from first up to now, keeping at most
+// maxCatchUpRuns of the most recent ones, and reports how many there were.
+func dueTimes(schedule cron.Schedule, first, now time.Time) ([]time.Time, int) {
+	var due []time.Time
+	total := 0
+	for t := first; !t.After(now) && total < maxCatchUpScan; t = schedule.Next(t) {
+		total++
+		due = append(due, t)
+		if len(due) > maxCatchUpRuns {
+			due = due[1:]
+		}
+	}
+	return due, total
+}
+
+func safeRun(ctx context.Context, fn JobFunc, scheduledFor time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return fn(ctx, scheduledFor)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
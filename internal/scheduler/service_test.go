@@ -0,0 +1,274 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+type fakeRepository struct {
+	Repository
+	mu   sync.Mutex
+	jobs map[string]models.ScheduledJob
+	runs []models.JobRun
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{jobs: map[string]models.ScheduledJob{}}
+}
+
+func (r *fakeRepository) FindJob(ctx context.Context, name string) (*models.ScheduledJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[name]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &job, nil
+}
+
+func (r *fakeRepository) SaveJob(ctx context.Context, job *models.ScheduledJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.Name] = *job
+	return nil
+}
+
+func (r *fakeRepository) CreateRun(ctx context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run.ID = uint(len(r.runs) + 1)
+	r.runs = append(r.runs, *run)
+	return nil
+}
+
+func (r *fakeRepository) UpdateRun(ctx context.Context, run *models.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[run.ID-1] = *run
+	return nil
+}
+
+func (r *fakeRepository) setNextRun(name string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := r.jobs[name]
+	job.NextRunAt = at
+	r.jobs[name] = job
+}
+
+func (r *fakeRepository) skipped() []models.JobRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var skipped []models.JobRun
+	for _, run := range r.runs {
+		if run.Status == models.JobRunSkipped {
+			skipped = append(skipped, run)
+		}
+	}
+	return skipped
+}
+
+func newLocker(t *testing.T) *RedisLocker {
+	t.Helper()
+	return NewRedisLocker(redis.NewRedisClient(miniredis.RunT(t).Addr(), "", 0))
+}
+
+func TestDueTimesKeepsTheMostRecentSlots(t *testing.T) {
+	schedule, err := cron.ParseStandard("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	due, total := dueTimes(schedule, first, first.Add(3*time.Hour+30*time.Minute))
+	if total != 4 || len(due) != 4 || !due[0].Equal(first) || !due[3].Equal(first.Add(3*time.Hour)) {
+		t.Fatalf("due = %v, total = %d, want the four hourly slots from %s", due, total, first)
+	}
+
+	due, total = dueTimes(schedule, first, first.Add(30*time.Hour))
+	if total != 31 || len(due) != maxCatchUpRuns {
+		t.Fatalf("%d due of %d, want the last %d of 31", len(due), total, maxCatchUpRuns)
+	}
+	if !due[0].Equal(first.Add(7*time.Hour)) || !due[len(due)-1].Equal(first.Add(30*time.Hour)) {
+		t.Fatalf("due runs from %s to %s, want 17:00 to the latest slot", due[0], due[len(due)-1])
+	}
+}
+
+func TestRunDueCatchesUpMissedSlots(t *testing.T) {
+	cases := []struct {
+		catchUp CatchUp
+		check   func(t *testing.T, first time.Time, ran []time.Time, skipped []models.JobRun)
+	}{
+		{CatchUpAll, func(t *testing.T, first time.Time, ran []time.Time, skipped []models.JobRun) {
+			if len(ran) < 4 || !ran[0].Equal(first) {
+				t.Fatalf("ran %v, want every minute from %s", ran, first)
+			}
+			for i := 1; i < len(ran); i++ {
+				if ran[i].Sub(ran[i-1]) != time.Minute {
+					t.Fatalf("ran %v, want consecutive slots oldest first", ran)
+				}
+			}
+			if len(skipped) != 0 {
+				t.Fatalf("skipped %v, want none", skipped)
+			}
+		}},
+		{CatchUpOnce, func(t *testing.T, first time.Time, ran []time.Time, skipped []models.JobRun) {
+			if len(ran) != 1 || !ran[0].After(first) || time.Since(ran[0]) > time.Minute {
+				t.Fatalf("ran %v, want only the latest slot", ran)
+			}
+			if len(skipped) != 0 {
+				t.Fatalf("skipped %v, want none", skipped)
+			}
+		}},
+		{CatchUpSkip, func(t *testing.T, first time.Time, ran []time.Time, skipped []models.JobRun) {
+			if len(ran) != 1 || time.Since(ran[0]) > time.Minute {
+				t.Fatalf("ran %v, want only the latest slot", ran)
+			}
+			if len(skipped) != 1 || !skipped[0].ScheduledFor.Equal(first) {
+				t.Fatalf("skipped %v, want one record for the missed runs from %s", skipped, first)
+			}
+		}},
+	}
+	for _, c := range cases {
+		t.Run(string(c.catchUp), func(t *testing.T) {
+			repo := newFakeRepository()
+			svc := NewService(repo, newLocker(t))
+			var (
+				mu  sync.Mutex
+				ran []time.Time
+			)
+			err := svc.Register(context.Background(), Job{
+				Name:     "report",
+				Schedule: "* * * * *",
+				CatchUp:  c.catchUp,
+				Run: func(ctx context.Context, scheduledFor time.Time) error {
+					mu.Lock()
+					ran = append(ran, scheduledFor)
+					mu.Unlock()
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			first := time.Now().Truncate(time.Minute).Add(-3 * time.Minute)
+			repo.setNextRun("report", first)
+
+			svc.tick(context.Background())
+			if err := svc.Stop(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			c.check(t, first, ran, repo.skipped())
+			state, _ := repo.FindJob(context.Background(), "report")
+			if !state.NextRunAt.After(time.Now()) || state.LastRunAt == nil {
+				t.Fatalf("state = %+v, want the next run in the future and the last run recorded", state)
+			}
+		})
+	}
+}
+
+func TestOnlyTheLeaseHolderRunsADueJob(t *testing.T) {
+	repo := newFakeRepository()
+	locker := newLocker(t)
+	release := make(chan struct{})
+	var calls atomic.Int32
+	job := Job{
+		Name:     "reconcile",
+		Schedule: "0 3 * * *",
+		Run: func(ctx context.Context, scheduledFor time.Time) error {
+			calls.Add(1)
+			<-release
+			return nil
+		},
+	}
+
+	instances := []*Service{NewService(repo, locker), NewService(repo, locker), NewService(repo, locker)}
+	for _, svc := range instances {
+		if err := svc.Register(context.Background(), job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo.setNextRun("reconcile", time.Now().Add(-time.Second))
+
+	var wg sync.WaitGroup
+	for _, svc := range instances {
+		wg.Add(1)
+		go func(svc *Service) {
+			defer wg.Done()
+			svc.tick(context.Background())
+		}(svc)
+	}
+	wg.Wait()
+	for i, svc := range instances {
+		if err := svc.Trigger("reconcile"); !errors.Is(err, ErrJobRunning) {
+			t.Fatalf("instance %d: Trigger = %v while another run holds the lease, want ErrJobRunning", i, err)
+		}
+	}
+
+	close(release)
+	for _, svc := range instances {
+		if err := svc.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("job ran %d times across three instances, want once", got)
+	}
+}
+
+func TestStopCancelsRunningJobsAtTheDeadline(t *testing.T) {
+	repo := newFakeRepository()
+	svc := NewService(repo, newLocker(t))
+	started, cancelled, release := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	defer close(release)
+	err := svc.Register(context.Background(), Job{
+		Name:     "slow",
+		Schedule: "0 3 * * *",
+		Run: func(ctx context.Context, scheduledFor time.Time) error {
+			close(started)
+			<-ctx.Done()
+			close(cancelled)
+			// Ignores the cancellation for a while, like a job stuck in a
+			// call that does not take a context.
+			<-release
+			return ctx.Err()
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Trigger("slow"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	stopped := make(chan error, 1)
+	go func() { stopped <- svc.Stop(ctx) }()
+
+	select {
+	case err := <-stopped:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Stop = %v, want the deadline error", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop waited for a job that ignores cancellation")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("running job was not cancelled")
+	}
+}
@@ -0,0 +1,213 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/internal/rbac"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+type ReasonRequest struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
+
+func (h *Handler) Search(c *gin.Context) {
+	filter := SearchFilter{
+		Query:     c.Query("q"),
+		KYCStatus: c.Query("kyc_status"),
+		Role:      c.Query("role"),
+		Status:    c.Query("status"),
+	}
+	filter.Page, _ = strconv.Atoi(c.Query("page"))
+	filter.PageSize, _ = strconv.Atoi(c.Query("page_size"))
+
+	var err error
+	if filter.CreatedFrom, err = parseDate(c.Query("created_from"), 0); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "created_from must be YYYY-MM-DD"})
+		return
+	}
+	// created_to is inclusive, so the bound is the start of the next day.
+	if filter.CreatedTo, err = parseDate(c.Query("created_to"), 1); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "created_to must be YYYY-MM-DD"})
+		return
+	}
+	for param, target := range map[string]**float64{
+		"min_balance": &filter.MinBalance,
+		"max_balance": &filter.MaxBalance,
+		"min_gold":    &filter.MinGold,
+		"max_gold":    &filter.MaxGold,
+	} {
+		if *target, err = parseFloat(c.Query(param)); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be a number"})
+			return
+		}
+	}
+
+	result, err := h.service.Search(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "user search failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.service.Get(c.Request.Context(), userID)
+	if err != nil {
+		writeError(c, err, "failed to fetch user")
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+func (h *Handler) RevealPII(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pii, err := h.service.RevealPII(c.Request.Context(), c.GetUint("user_id"), userID, req.Reason)
+	if err != nil {
+		writeError(c, err, "failed to reveal user details")
+		return
+	}
+
+	c.JSON(http.StatusOK, pii)
+}
+
+func (h *Handler) ForceLogout(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.ForceLogout(c.Request.Context(), c.GetUint("user_id"), userID); err != nil {
+		writeError(c, err, "forced logout failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "all sessions ended"})
+}
+
+func (h *Handler) TriggerPasswordReset(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.TriggerPasswordReset(c.Request.Context(), c.GetUint("user_id"), userID); err != nil {
+		writeError(c, err, "password reset failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password reset code sent"})
+}
+
+func (h *Handler) Deactivate(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Deactivate(c.Request.Context(), c.GetUint("user_id"), userID, req.Reason); err != nil {
+		writeError(c, err, "account deactivation failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account deactivated"})
+}
+
+func (h *Handler) Reactivate(c *gin.Context) {
+	userID, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	var req ReasonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Reactivate(c.Request.Context(), c.GetUint("user_id"), userID, req.Reason); err != nil {
+		writeError(c, err, "account reactivation failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "account reactivated"})
+}
+
+func parseUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func parseDate(value string, addDays int) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	date = date.AddDate(0, 0, addDays)
+	return &date, nil
+}
+
+func parseFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
+
+func writeError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrAlreadyDeactivated), errors.Is(err, ErrNotDeactivated):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrSelfAction), errors.Is(err, rbac.ErrOutranked):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,140 @@
+package users
+
+import (
+	"context"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+)
+
+type SearchFilter struct {
+	Query       string
+	KYCStatus   string
+	Role        string
+	Status      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MinBalance  *float64
+	MaxBalance  *float64
+	MinGold     *float64
+	MaxGold     *float64
+	Page        int
+	PageSize    int
+}
+
+// UserRow is a user joined with the balances of their wallet.
+type UserRow struct {
+	models.User `gorm:"embedded"`
+	FiatBalance float64
+	GoldGrams   float64
+}
+
+type Repository interface {
+	Search(ctx context.Context, filter SearchFilter) ([]UserRow, int64, error)
+	FindByID(ctx context.Context, id uint) (*UserRow, error)
+	RecentTransactions(ctx context.Context, userID uint, limit int) ([]models.Transaction, error)
+	KYCSubmissions(ctx context.Context, userID uint) ([]models.KYCSubmission, error)
+	SetDeactivated(ctx context.Context, userID uint, at *time.Time) (bool, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) withBalances(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Table("users").
+		Select("users.*, COALESCE(wallets.fiat_balance, 0) AS fiat_balance, COALESCE(wallets.gold_grams, 0) AS gold_grams").
+		Joins("LEFT JOIN wallets ON wallets.user_id = users.id")
+}
+
+func (r *repository) Search(ctx context.Context, filter SearchFilter) ([]UserRow, int64, error) {
+	query := r.withBalances(ctx)
+	if filter.Query != "" {
+		pattern := "%" + filter.Query + "%"
+		query = query.Where("users.full_name ILIKE ? OR users.email ILIKE ? OR users.phone LIKE ?", pattern, pattern, pattern)
+	}
+	if filter.KYCStatus != "" {
+		query = query.Where("users.kyc_status = ?", filter.KYCStatus)
+	}
+	if filter.Role != "" {
+		query = query.Where("users.role = ?", filter.Role)
+	}
+	switch filter.Status {
+	case "active":
+		query = query.Where("users.deactivated_at IS NULL")
+	case "deactivated":
+		query = query.Where("users.deactivated_at IS NOT NULL")
+	}
+	if filter.CreatedFrom != nil {
+		query = query.Where("users.created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		query = query.Where("users.created_at < ?", *filter.CreatedTo)
+	}
+	if filter.MinBalance != nil {
+		query = query.Where("COALESCE(wallets.fiat_balance, 0) >= ?", *filter.MinBalance)
+	}
+	if filter.MaxBalance != nil {
+		query = query.Where("COALESCE(wallets.fiat_balance, 0) <= ?", *filter.MaxBalance)
+	}
+	if filter.MinGold != nil {
+		query = query.Where("COALESCE(wallets.gold_grams, 0) >= ?", *filter.MinGold)
+	}
+	if filter.MaxGold != nil {
+		query = query.Where("COALESCE(wallets.gold_grams, 0) <= ?", *filter.MaxGold)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []UserRow
+	err := query.Order("users.id desc").
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Scan(&rows).Error
+	return rows, total, err
+}
+
+func (r *repository) FindByID(ctx context.Context, id uint) (*UserRow, error) {
+	var row UserRow
+	result := r.withBalances(ctx).Where("users.id = ?", id).Limit(1).Scan(&row)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &row, nil
+}
+
+func (r *repository) RecentTransactions(ctx context.Context, userID uint, limit int) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id desc").Limit(limit).Find(&transactions).Error
+	return transactions, err
+}
+
+func (r *repository) KYCSubmissions(ctx context.Context, userID uint) ([]models.KYCSubmission, error) {
+	var submissions []models.KYCSubmission
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id desc").Find(&submissions).Error
+	return submissions, err
+}
+
+// SetDeactivated sets or clears deactivated_at and reports whether the
+// account actually changed state.
+func (r *repository) SetDeactivated(ctx context.Context, userID uint, at *time.Time) (bool, error) {
+	query := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID)
+	if at != nil {
+		query = query.Where("deactivated_at IS NULL")
+	} else {
+		query = query.Where("deactivated_at IS NOT NULL")
+	}
+	result := query.Update("deactivated_at", at)
+	return result.RowsAffected == 1, result.Error
+}
@@ -0,0 +1,287 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/utils"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound       = errors.New("user not found")
+	ErrAlreadyDeactivated = errors.New("account is already deactivated")
+	ErrNotDeactivated     = errors.New("account is not deactivated")
+	ErrSelfAction         = errors.New("administrators cannot deactivate their own account")
+)
+
+const recentTransactionLimit = 20
+
+// AccountActions are the session and credential operations owned by the
+// auth service.
+type AccountActions interface {
+	LogoutAll(ctx context.Context, userID uint) error
+	RequestPasswordReset(ctx context.Context, email string) error
+}
+
+// RoleGuard decides whether an actor may act on an account with the given
+// role; it is implemented by the rbac service.
+type RoleGuard interface {
+	CheckCanManage(ctx context.Context, actorID uint, targetRole string) error
+}
+
+// Summary is a user as shown to staff, with contact details masked.
+type Summary struct {
+	ID            uint       `json:"id"`
+	FullName      string     `json:"full_name"`
+	Email         string     `json:"email"`
+	Phone         string     `json:"phone"`
+	KYCStatus     string     `json:"kyc_status"`
+	Role          string     `json:"role"`
+	EmailVerified bool       `json:"email_verified"`
+	PhoneVerified bool       `json:"phone_verified"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	FiatBalance   float64    `json:"fiat_balance"`
+	GoldGrams     float64    `json:"gold_grams"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// KYCSummary is a KYC submission without the identity details.
+type KYCSummary struct {
+	ID                uint       `json:"id"`
+	Status            string     `json:"status"`
+	CitizenshipNumber string     `json:"citizenship_number"`
+	Reason            string     `json:"reason,omitempty"`
+	ReviewerID        *uint      `json:"reviewer_id,omitempty"`
+	SubmittedAt       time.Time  `json:"submitted_at"`
+	ReviewedAt        *time.Time `json:"reviewed_at,omitempty"`
+}
+
+type Detail struct {
+	User               Summary              `json:"user"`
+	RecentTransactions []models.Transaction `json:"recent_transactions"`
+	KYCHistory         []KYCSummary         `json:"kyc_history"`
+}
+
+type SearchResult struct {
+	Users    []Summary `json:"users"`
+	Total    int64     `json:"total"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"page_size"`
+}
+
+// PII is the unmasked data returned by an audited reveal.
+type PII struct {
+	Email      string                 `json:"email"`
+	Phone      string                 `json:"phone"`
+	KYCHistory []models.KYCSubmission `json:"kyc_history"`
+}
+
+type Service interface {
+	Search(ctx context.Context, filter SearchFilter) (*SearchResult, error)
+	Get(ctx context.Context, userID uint) (*Detail, error)
+	RevealPII(ctx context.Context, actorID, userID uint, reason string) (*PII, error)
+	ForceLogout(ctx context.Context, actorID, userID uint) error
+	TriggerPasswordReset(ctx context.Context, actorID, userID uint) error
+	Deactivate(ctx context.Context, actorID, userID uint, reason string) error
+	Reactivate(ctx context.Context, actorID, userID uint, reason string) error
+}
+
+type service struct {
+	repo     Repository
+	accounts AccountActions
+	roles    RoleGuard
+	audit    audit.Service
+}
+
+func NewService(repo Repository, accounts AccountActions, roles RoleGuard, auditService audit.Service) Service {
+	return &service{repo: repo, accounts: accounts, roles: roles, audit: auditService}
+}
+
+func (s *service) Search(ctx context.Context, filter SearchFilter) (*SearchResult, error) {
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 || filter.PageSize > 100 {
+		filter.PageSize = 25
+	}
+
+	rows, total, err := s.repo.Search(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("user search failed: %w", err)
+	}
+
+	result := &SearchResult{Users: make([]Summary, 0, len(rows)), Total: total, Page: filter.Page, PageSize: filter.PageSize}
+	for _, row := range rows {
+		result.Users = append(result.Users, summarize(row))
+	}
+	return result, nil
+}
+
+func (s *service) Get(ctx context.Context, userID uint) (*Detail, error) {
+	row, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.repo.RecentTransactions(ctx, userID, recentTransactionLimit)
+	if err != nil {
+		return nil, err
+	}
+	submissions, err := s.repo.KYCSubmissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &Detail{
+		User:               summarize(*row),
+		RecentTransactions: transactions,
+		KYCHistory:         make([]KYCSummary, 0, len(submissions)),
+	}
+	for _, submission := range submissions {
+		detail.KYCHistory = append(detail.KYCHistory, KYCSummary{
+			ID:                submission.ID,
+			Status:            submission.Status,
+			CitizenshipNumber: utils.MaskTail(submission.CitizenshipNumber, 3),
+			Reason:            submission.Reason,
+			ReviewerID:        submission.ReviewerID,
+			SubmittedAt:       submission.SubmittedAt,
+			ReviewedAt:        submission.ReviewedAt,
+		})
+	}
+	return detail, nil
+}
+
+// RevealPII returns the unmasked contact and identity details. Every call is
+// audited together with the reason given.
+func (s *service) RevealPII(ctx context.Context, actorID, userID uint, reason string) (*PII, error) {
+	row, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	submissions, err := s.repo.KYCSubmissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, actorID, "user.pii_reveal", "user", strconv.FormatUint(uint64(userID), 10), map[string]interface{}{
+		"reason": reason,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &PII{Email: row.Email, Phone: row.Phone, KYCHistory: submissions}, nil
+}
+
+func (s *service) ForceLogout(ctx context.Context, actorID, userID uint) error {
+	if _, err := s.findManaged(ctx, actorID, userID); err != nil {
+		return err
+	}
+	if err := s.accounts.LogoutAll(ctx, userID); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, actorID, "user.force_logout", "user", strconv.FormatUint(uint64(userID), 10), nil)
+}
+
+func (s *service) TriggerPasswordReset(ctx context.Context, actorID, userID uint) error {
+	row, err := s.findManaged(ctx, actorID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.accounts.RequestPasswordReset(ctx, row.Email); err != nil {
+		return err
+	}
+	return s.audit.Record(ctx, actorID, "user.password_reset", "user", strconv.FormatUint(uint64(userID), 10), nil)
+}
+
+// Deactivate blocks new logins and ends every session of the account.
+func (s *service) Deactivate(ctx context.Context, actorID, userID uint, reason string) error {
+	if actorID == userID {
+		return ErrSelfAction
+	}
+	if _, err := s.findManaged(ctx, actorID, userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	changed, err := s.repo.SetDeactivated(ctx, userID, &now)
+	if err != nil {
+		return fmt.Errorf("account deactivation failed: %w", err)
+	}
+	if !changed {
+		return ErrAlreadyDeactivated
+	}
+	if err := s.accounts.LogoutAll(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.audit.Record(ctx, actorID, "user.deactivate", "user", strconv.FormatUint(uint64(userID), 10), map[string]interface{}{
+		"reason": reason,
+	})
+}
+
+func (s *service) Reactivate(ctx context.Context, actorID, userID uint, reason string) error {
+	if _, err := s.findManaged(ctx, actorID, userID); err != nil {
+		return err
+	}
+
+	changed, err := s.repo.SetDeactivated(ctx, userID, nil)
+	if err != nil {
+		return fmt.Errorf("account reactivation failed: %w", err)
+	}
+	if !changed {
+		return ErrNotDeactivated
+	}
+
+	return s.audit.Record(ctx, actorID, "user.reactivate", "user", strconv.FormatUint(uint64(userID), 10), map[string]interface{}{
+		"reason": reason,
+	})
+}
+
+func (s *service) find(ctx context.Context, userID uint) (*UserRow, error) {
+	row, err := s.repo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return row, nil
+}
+
+// findManaged is find for actions that change the account; staff cannot
+// act on anyone whose role outranks their own.
+func (s *service) findManaged(ctx context.Context, actorID, userID uint) (*UserRow, error) {
+	row, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.roles.CheckCanManage(ctx, actorID, row.Role); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+func summarize(row UserRow) Summary {
+	return Summary{
+		ID:            row.ID,
+		FullName:      row.FullName,
+		Email:         utils.MaskEmail(row.Email),
+		Phone:         utils.MaskTail(row.Phone, 3),
+		KYCStatus:     row.KYCStatus,
+		Role:          row.Role,
+		EmailVerified: row.EmailVerifiedAt != nil,
+		PhoneVerified: row.PhoneVerifiedAt != nil,
+		LockedUntil:   row.LockedUntil,
+		DeactivatedAt: row.DeactivatedAt,
+		FiatBalance:   row.FiatBalance,
+		GoldGrams:     row.GoldGrams,
+		CreatedAt:     row.CreatedAt,
+	}
+}
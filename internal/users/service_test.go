@@ -0,0 +1,83 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/internal/rbac"
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+)
+
+type fakeRepository struct {
+	Repository
+	users map[uint]*UserRow
+}
+
+func (r *fakeRepository) FindByID(ctx context.Context, id uint) (*UserRow, error) {
+	if row, ok := r.users[id]; ok {
+		return row, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// superAdminGuard lets the actor manage everyone except super admins.
+type superAdminGuard struct{}
+
+func (superAdminGuard) CheckCanManage(ctx context.Context, actorID uint, targetRole string) error {
+	if targetRole == rbac.RoleSuperAdmin {
+		return rbac.ErrOutranked
+	}
+	return nil
+}
+
+type recordingAccounts struct {
+	calls int
+}
+
+func (a *recordingAccounts) LogoutAll(ctx context.Context, userID uint) error {
+	a.calls++
+	return nil
+}
+
+func (a *recordingAccounts) RequestPasswordReset(ctx context.Context, email string) error {
+	a.calls++
+	return nil
+}
+
+type nopAudit struct{ audit.Service }
+
+func (nopAudit) Record(ctx context.Context, actorID uint, action, targetType, targetID string, details interface{}) error {
+	return nil
+}
+
+func TestAccountActionsRefuseTargetsThatOutrankTheActor(t *testing.T) {
+	repo := &fakeRepository{users: map[uint]*UserRow{
+		2: {User: models.User{ID: 2, Role: rbac.RoleSuperAdmin, Email: "root@example.com"}},
+		3: {User: models.User{ID: 3, Role: rbac.RoleUser, Email: "user@example.com"}},
+	}}
+	accounts := &recordingAccounts{}
+	svc := NewService(repo, accounts, superAdminGuard{}, nopAudit{})
+	ctx := context.Background()
+
+	actions := map[string]func(userID uint) error{
+		"force logout":   func(userID uint) error { return svc.ForceLogout(ctx, 1, userID) },
+		"password reset": func(userID uint) error { return svc.TriggerPasswordReset(ctx, 1, userID) },
+		"deactivate":     func(userID uint) error { return svc.Deactivate(ctx, 1, userID, "reason") },
+		"reactivate":     func(userID uint) error { return svc.Reactivate(ctx, 1, userID, "reason") },
+	}
+	for name, action := range actions {
+		if err := action(2); !errors.Is(err, rbac.ErrOutranked) {
+			t.Errorf("%s on a super admin: err = %v, want ErrOutranked", name, err)
+		}
+	}
+	if accounts.calls != 0 {
+		t.Fatalf("%d account actions ran against an outranking user", accounts.calls)
+	}
+
+	if err := svc.ForceLogout(ctx, 1, 3); err != nil {
+		t.Fatalf("force logout of a plain user: %v", err)
+	}
+}
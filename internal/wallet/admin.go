@@ -0,0 +1,283 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/uow"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWalletAlreadyLocked = errors.New("wallet is already frozen")
+	ErrWalletNotLocked     = errors.New("wallet is not frozen")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrAdjustmentNotFound  = errors.New("adjustment not found")
+	ErrAdjustmentReviewed  = errors.New("adjustment has already been reviewed")
+	ErrSelfApproval        = errors.New("adjustment must be reviewed by a different administrator")
+)
+
+func (s *service) Freeze(ctx context.Context, actorID, userID uint, reason string) error {
+	return s.setLocked(ctx, actorID, userID, true, reason)
+}
+
+func (s *service) Unfreeze(ctx context.Context, actorID, userID uint, reason string) error {
+	return s.setLocked(ctx, actorID, userID, false, reason)
+}
+
+func (s *service) setLocked(ctx context.Context, actorID, userID uint, locked bool, reason string) error {
+	exists, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	eventType, action := models.WalletEventFreeze, "wallet.freeze"
+	if !locked {
+		eventType, action = models.WalletEventUnfreeze, "wallet.unfreeze"
+	}
+
+	changed := false
+	err = s.work.Do(ctx, func(ctx context.Context, tx uow.Tx) error {
+		repo := s.repo.WithTx(tx)
+		wallet, err := repo.LockOrCreateByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if wallet.Locked == locked {
+			return nil
+		}
+		wallet.Locked = locked
+		if err := repo.Update(ctx, wallet); err != nil {
+			return err
+		}
+		changed = true
+		return repo.CreateEvent(ctx, &models.WalletEvent{
+			UserID:  userID,
+			ActorID: actorID,
+			Type:    eventType,
+			Reason:  reason,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update wallet: %w", err)
+	}
+	if !changed {
+		if locked {
+			return ErrWalletAlreadyLocked
+		}
+		return ErrWalletNotLocked
+	}
+
+	return s.audit.Record(ctx, actorID, action, "wallet", strconv.FormatUint(uint64(userID), 10), map[string]interface{}{
+		"reason": reason,
+	})
+}
+
+// RequestAdjustment records a manual credit (positive) or debit (negative).
+// The wallet is untouched until another administrator approves it.
+func (s *service) RequestAdjustment(ctx context.Context, actorID, userID uint, fiatAmount, goldGrams float64, reason string) (*models.BalanceAdjustment, error) {
+	if fiatAmount == 0 && goldGrams == 0 {
+		return nil, ErrInvalidAmount
+	}
+	exists, err := s.repo.UserExists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	adjustment := &models.BalanceAdjustment{
+		UserID:      userID,
+		FiatAmount:  fiatAmount,
+		GoldGrams:   goldGrams,
+		Reason:      reason,
+		Status:      models.AdjustmentPending,
+		RequestedBy: actorID,
+	}
+	err = s.work.Do(ctx, func(ctx context.Context, tx uow.Tx) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.CreateAdjustment(ctx, adjustment); err != nil {
+			return err
+		}
+		return repo.CreateEvent(ctx, &models.WalletEvent{
+			UserID:       userID,
+			ActorID:      actorID,
+			Type:         models.WalletEventAdjustmentRequest,
+			Reason:       reason,
+			AdjustmentID: &adjustment.ID,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create adjustment: %w", err)
+	}
+
+	if err := s.audit.Record(ctx, actorID, "wallet.adjustment_request", "wallet", strconv.FormatUint(uint64(userID), 10), map[string]interface{}{
+		"adjustment_id": adjustment.ID,
+		"fiat_amount":   fiatAmount,
+		"gold_grams":    goldGrams,
+		"reason":        reason,
+	}); err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+// ApproveAdjustment applies a pending adjustment to the wallet, writes the
+// adjustment transaction and closes the request in one unit of work.
+func (s *service) ApproveAdjustment(ctx context.Context, actorID, id uint, note string) (*models.BalanceAdjustment, *models.Transaction, error) {
+	var adjustment *models.BalanceAdjustment
+	var transaction *models.Transaction
+
+	err := s.work.Do(ctx, func(ctx context.Context, tx uow.Tx) error {
+		repo := s.repo.WithTx(tx)
+
+		var err error
+		adjustment, err = lockPendingAdjustment(ctx, repo, id, actorID)
+		if err != nil {
+			return err
+		}
+
+		wallet, err := repo.LockOrCreateByUserID(ctx, adjustment.UserID)
+		if err != nil {
+			return err
+		}
+		if wallet.FiatBalance+adjustment.FiatAmount < 0 || wallet.GoldGrams+adjustment.GoldGrams < 0 {
+			return ErrInsufficientBalance
+		}
+		wallet.FiatBalance += adjustment.FiatAmount
+		wallet.GoldGrams += adjustment.GoldGrams
+		if err := repo.Update(ctx, wallet); err != nil {
+			return err
+		}
+
+		transaction = &models.Transaction{
+			UserID:      adjustment.UserID,
+			Type:        models.TransactionTypeAdjustment,
+			Amount:      adjustment.FiatAmount,
+			GoldGrams:   adjustment.GoldGrams,
+			Status:      models.TransactionStatusSuccess,
+			ReferenceID: fmt.Sprintf("adjustment_%d", adjustment.ID),
+		}
+		if err := repo.CreateTransaction(ctx, transaction); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		adjustment.Status = models.AdjustmentApproved
+		adjustment.ReviewedBy = &actorID
+		adjustment.ReviewNote = note
+		adjustment.ReviewedAt = &now
+		adjustment.TransactionID = &transaction.ID
+		if err := repo.UpdateAdjustment(ctx, adjustment); err != nil {
+			return err
+		}
+
+		return repo.CreateEvent(ctx, &models.WalletEvent{
+			UserID:        adjustment.UserID,
+			ActorID:       actorID,
+			Type:          models.WalletEventAdjustmentApproved,
+			Reason:        note,
+			AdjustmentID:  &adjustment.ID,
+			TransactionID: &transaction.ID,
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.audit.Record(ctx, actorID, "wallet.adjustment_approve", "wallet", strconv.FormatUint(uint64(adjustment.UserID), 10), map[string]interface{}{
+		"adjustment_id":  adjustment.ID,
+		"transaction_id": transaction.ID,
+		"fiat_amount":    adjustment.FiatAmount,
+		"gold_grams":     adjustment.GoldGrams,
+		"note":           note,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	s.publish(ctx, models.WebhookEventAdjustment, transaction)
+	return adjustment, transaction, nil
+}
+
+func (s *service) RejectAdjustment(ctx context.Context, actorID, id uint, note string) (*models.BalanceAdjustment, error) {
+	var adjustment *models.BalanceAdjustment
+
+	err := s.work.Do(ctx, func(ctx context.Context, tx uow.Tx) error {
+		repo := s.repo.WithTx(tx)
+
+		var err error
+		adjustment, err = lockPendingAdjustment(ctx, repo, id, actorID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		adjustment.Status = models.AdjustmentRejected
+		adjustment.ReviewedBy = &actorID
+		adjustment.ReviewNote = note
+		adjustment.ReviewedAt = &now
+		if err := repo.UpdateAdjustment(ctx, adjustment); err != nil {
+			return err
+		}
+
+		return repo.CreateEvent(ctx, &models.WalletEvent{
+			UserID:       adjustment.UserID,
+			ActorID:      actorID,
+			Type:         models.WalletEventAdjustmentRejected,
+			Reason:       note,
+			AdjustmentID: &adjustment.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, actorID, "wallet.adjustment_reject", "wallet", strconv.FormatUint(uint64(adjustment.UserID), 10), map[string]interface{}{
+		"adjustment_id": adjustment.ID,
+		"note":          note,
+	}); err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+func (s *service) ListAdjustments(ctx context.Context, filter AdjustmentFilter) ([]models.BalanceAdjustment, error) {
+	if filter.Limit <= 0 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+	return s.repo.ListAdjustments(ctx, filter)
+}
+
+func (s *service) ListEvents(ctx context.Context, filter EventFilter) ([]models.WalletEvent, error) {
+	if filter.Limit <= 0 || filter.Limit > 200 {
+		filter.Limit = 50
+	}
+	return s.repo.ListEvents(ctx, filter)
+}
+
+// lockPendingAdjustment enforces maker-checker: only a pending request can be
+// reviewed, and never by the administrator who made it.
+func lockPendingAdjustment(ctx context.Context, repo Repository, id, reviewerID uint) (*models.BalanceAdjustment, error) {
+	adjustment, err := repo.LockAdjustment(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrAdjustmentNotFound
+		}
+		return nil, err
+	}
+	if adjustment.Status != models.AdjustmentPending {
+		return nil, ErrAdjustmentReviewed
+	}
+	if adjustment.RequestedBy == reviewerID {
+		return nil, ErrSelfApproval
+	}
+	return adjustment, nil
+}
@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/pkg/uow"
+	"github.com/919Umesh/gold_go/pkg/uow/uowtest"
+)
+
+// adjustmentDB answers the statements of an approval: a pending 500 NPR
+// credit requested by admin 7 for user 3, whose wallet holds 1000 NPR. Any
+// statement starting with failing returns errStep.
+func adjustmentDB(failing string) uowtest.Handler {
+	return func(query string, args []driver.NamedValue) (uowtest.Result, error) {
+		if failing != "" && strings.HasPrefix(query, failing) {
+			return uowtest.Result{}, errStep
+		}
+		switch {
+		case strings.HasPrefix(query, `SELECT * FROM "balance_adjustments"`):
+			return uowtest.Result{
+				Columns: []string{"id", "user_id", "fiat_amount", "status", "requested_by"},
+				Rows:    [][]driver.Value{{int64(11), int64(3), 500.0, "pending", int64(7)}},
+			}, nil
+		case strings.HasPrefix(query, `SELECT * FROM "wallets"`):
+			return uowtest.Result{
+				Columns: []string{"id", "user_id", "fiat_balance", "version"},
+				Rows:    [][]driver.Value{{int64(5), int64(3), 1000.0, int64(1)}},
+			}, nil
+		case strings.HasPrefix(query, "INSERT"):
+			return uowtest.Result{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(21)}}}, nil
+		}
+		return uowtest.Result{RowsAffected: 1}, nil
+	}
+}
+
+var errStep = errors.New("step failed")
+
+type nopAudit struct{ audit.Service }
+
+func (nopAudit) Record(ctx context.Context, actorID uint, action, targetType, targetID string, details interface{}) error {
+	return nil
+}
+
+func TestApproveAdjustmentCommitsEveryStep(t *testing.T) {
+	gormDB, db := uowtest.Open(t, adjustmentDB(""))
+	svc := NewService(NewRepository(gormDB), uow.New(gormDB, uow.Options{}), fixedPrice(6500), &config.Config{}, nil, nil, nopAudit{})
+
+	adjustment, transaction, err := svc.ApproveAdjustment(context.Background(), 8, 11, "checked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adjustment.Status != "approved" || transaction.ID != 21 || *adjustment.TransactionID != 21 {
+		t.Fatalf("adjustment = %+v, transaction = %+v", adjustment, transaction)
+	}
+	if db.Count("BEGIN") != 1 || db.Count("COMMIT") != 1 || db.Count("ROLLBACK") != 0 {
+		t.Fatalf("statements = %v, want one committed transaction", db.Statements())
+	}
+}
+
+func TestApproveAdjustmentRollsBackWhenAnyStepFails(t *testing.T) {
+	steps := []string{
+		`SELECT * FROM "balance_adjustments"`,
+		`SELECT * FROM "wallets"`,
+		`UPDATE "wallets"`,
+		`INSERT INTO "transactions"`,
+		`UPDATE "balance_adjustments"`,
+		`INSERT INTO "wallet_events"`,
+	}
+
+	for _, failing := range steps {
+		gormDB, db := uowtest.Open(t, adjustmentDB(failing))
+		svc := NewService(NewRepository(gormDB), uow.New(gormDB, uow.Options{}), fixedPrice(6500), &config.Config{}, nil, nil, nopAudit{})
+
+		if _, _, err := svc.ApproveAdjustment(context.Background(), 8, 11, "checked"); !errors.Is(err, errStep) {
+			t.Fatalf("%s: err = %v, want the step error", failing, err)
+		}
+		if db.Count(failing) != 1 {
+			t.Fatalf("%s never ran: %v", failing, db.Statements())
+		}
+		if db.Count("COMMIT") != 0 || db.Count("ROLLBACK") != 1 {
+			t.Fatalf("%s failing: statements = %v, want one rollback and no commit", failing, db.Statements())
+		}
+	}
+}
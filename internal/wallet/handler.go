@@ -1,18 +1,36 @@
 package wallet
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/limits"
+	"github.com/919Umesh/gold_go/pkg/clock"
+	"github.com/919Umesh/gold_go/pkg/middleware"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type Handler struct {
-	service Service
+	service            Service
+	prices             PriceSource
+	largeSellThreshold float64
+	stepUpMaxAge       time.Duration
+	clock              clock.Clock
 }
 
-func NewHandler(service Service) *Handler {
-	return &Handler{service: service}
+func NewHandler(service Service, prices PriceSource, cfg *config.Config, clk clock.Clock) *Handler {
+	return &Handler{
+		service:            service,
+		prices:             prices,
+		largeSellThreshold: cfg.Auth.LargeSellThreshold,
+		stepUpMaxAge:       time.Duration(cfg.Auth.StepUpMaxAgeMinutes) * time.Minute,
+		clock:              clk,
+	}
 }
 
 type TopUpRequest struct {
@@ -27,7 +45,7 @@ type BuyGoldRequest struct {
 func (h *Handler) GetWallet(c *gin.Context) {
 	userID := c.GetUint("user_id")
 
-	wallet, err := h.service.GetWallet(userID)
+	wallet, err := h.service.GetWallet(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -47,7 +65,7 @@ func (h *Handler) TopUp(c *gin.Context) {
 
 	referenceID := "topup_" + uuid.New().String()
 
-	wallet, transaction, err := h.service.TopUp(userID, req.Amount, referenceID)
+	wallet, transaction, err := h.service.TopUp(c.Request.Context(), userID, req.Amount, referenceID)
 	if err != nil {
 		switch err {
 		case ErrInvalidAmount:
@@ -55,6 +73,9 @@ func (h *Handler) TopUp(c *gin.Context) {
 		case ErrWalletLocked:
 			c.JSON(http.StatusLocked, gin.H{"error": "wallet is locked"})
 		default:
+			if writeLimitError(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "top-up failed"})
 		}
 		return
@@ -78,7 +99,7 @@ func (h *Handler) BuyGold(c *gin.Context) {
 
 	referenceID := "buy_" + uuid.New().String()
 
-	wallet, transaction, err := h.service.BuyGold(userID, req.Grams, req.PricePerGram, referenceID)
+	wallet, transaction, err := h.service.BuyGold(c.Request.Context(), userID, req.Grams, req.PricePerGram, referenceID)
 	if err != nil {
 		switch err {
 		case ErrInvalidAmount:
@@ -87,7 +108,14 @@ func (h *Handler) BuyGold(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient fiat balance"})
 		case ErrWalletLocked:
 			c.JSON(http.StatusLocked, gin.H{"error": "wallet is locked"})
+		case ErrPriceChanged:
+			c.JSON(http.StatusConflict, gin.H{"error": "gold price has changed; fetch the current price and retry"})
+		case ErrPriceUnavailable:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "price not available"})
 		default:
+			if writeLimitError(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
 		}
 		return
@@ -109,9 +137,20 @@ func (h *Handler) SellGold(c *gin.Context) {
 		return
 	}
 
+	price, _, err := h.prices.GetCurrentPrice(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "price not available"})
+		return
+	}
+	if req.Grams*price >= h.largeSellThreshold &&
+		!middleware.HasFreshStepUp(c, h.stepUpMaxAge, h.clock.Now()) {
+		middleware.AbortStepUpRequired(c)
+		return
+	}
+
 	referenceID := "sell_" + uuid.New().String()
 
-	wallet, transaction, err := h.service.SellGold(userID, req.Grams, req.PricePerGram, referenceID)
+	wallet, transaction, err := h.service.SellGold(c.Request.Context(), userID, req.Grams, req.PricePerGram, referenceID)
 	if err != nil {
 		switch err {
 		case ErrInvalidAmount:
@@ -120,7 +159,14 @@ func (h *Handler) SellGold(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient gold balance"})
 		case ErrWalletLocked:
 			c.JSON(http.StatusLocked, gin.H{"error": "wallet is locked"})
+		case ErrPriceChanged:
+			c.JSON(http.StatusConflict, gin.H{"error": "gold price has changed; fetch the current price and retry"})
+		case ErrPriceUnavailable:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "price not available"})
 		default:
+			if writeLimitError(c, err) {
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
 		}
 		return
@@ -141,7 +187,7 @@ func (h *Handler) GetUserTransaction(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.service.GetUserTransaction(userID)
+	transaction, err := h.service.GetUserTransaction(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch transactions"})
 		return
@@ -153,3 +199,231 @@ func (h *Handler) GetUserTransaction(c *gin.Context) {
 	})
 
 }
+
+func writeLimitError(c *gin.Context, err error) bool {
+	var limitErr *limits.LimitError
+	if !errors.As(err, &limitErr) {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error": limitErr.Error(),
+		"limit": limitErr,
+	})
+	return true
+}
+
+type FreezeRequest struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
+
+type AdjustmentRequest struct {
+	FiatAmount float64 `json:"fiat_amount"`
+	GoldGrams  float64 `json:"gold_grams"`
+	Reason     string  `json:"reason" binding:"required,min=3,max=500"`
+}
+
+type ReviewAdjustmentRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+func (h *Handler) Freeze(c *gin.Context) {
+	h.setLocked(c, true)
+}
+
+func (h *Handler) Unfreeze(c *gin.Context) {
+	h.setLocked(c, false)
+}
+
+func (h *Handler) setLocked(c *gin.Context, locked bool) {
+	userID, ok := parseID(c, "user_id")
+	if !ok {
+		return
+	}
+
+	var req FreezeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	update, message := h.service.Freeze, "wallet frozen"
+	if !locked {
+		update, message = h.service.Unfreeze, "wallet unfrozen"
+	}
+	if err := update(c.Request.Context(), c.GetUint("user_id"), userID, req.Reason); err != nil {
+		writeAdminError(c, err, "wallet update failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+func (h *Handler) GetHistory(c *gin.Context) {
+	userID, ok := parseID(c, "user_id")
+	if !ok {
+		return
+	}
+
+	wallet, err := h.service.GetWallet(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch wallet"})
+		return
+	}
+	transactions, err := h.service.GetUserTransaction(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch transactions"})
+		return
+	}
+	events, err := h.service.ListEvents(c.Request.Context(), EventFilter{UserID: userID, Limit: 200})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch wallet events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"wallet":       wallet,
+		"transactions": transactions,
+		"events":       events,
+	})
+}
+
+// Activity is the feed of administrative wallet actions across all users.
+func (h *Handler) Activity(c *gin.Context) {
+	filter := EventFilter{Type: c.Query("type")}
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		filter.UserID = uint(userID)
+	}
+	if actorID, err := strconv.ParseUint(c.Query("actor_id"), 10, 32); err == nil {
+		filter.ActorID = uint(actorID)
+	}
+	if beforeID, err := strconv.ParseUint(c.Query("before_id"), 10, 32); err == nil {
+		filter.BeforeID = uint(beforeID)
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil {
+		filter.Limit = limit
+	}
+
+	events, err := h.service.ListEvents(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch activity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+func (h *Handler) RequestAdjustment(c *gin.Context) {
+	userID, ok := parseID(c, "user_id")
+	if !ok {
+		return
+	}
+
+	var req AdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adjustment, err := h.service.RequestAdjustment(c.Request.Context(), c.GetUint("user_id"), userID, req.FiatAmount, req.GoldGrams, req.Reason)
+	if err != nil {
+		writeAdminError(c, err, "adjustment request failed")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "adjustment awaiting approval",
+		"adjustment": adjustment,
+	})
+}
+
+func (h *Handler) ListAdjustments(c *gin.Context) {
+	filter := AdjustmentFilter{Status: c.Query("status")}
+	if userID, err := strconv.ParseUint(c.Query("user_id"), 10, 32); err == nil {
+		filter.UserID = uint(userID)
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil {
+		filter.Limit = limit
+	}
+
+	adjustments, err := h.service.ListAdjustments(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch adjustments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"adjustments": adjustments})
+}
+
+func (h *Handler) ApproveAdjustment(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ReviewAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adjustment, transaction, err := h.service.ApproveAdjustment(c.Request.Context(), c.GetUint("user_id"), id, req.Note)
+	if err != nil {
+		writeAdminError(c, err, "adjustment approval failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "adjustment applied",
+		"adjustment":  adjustment,
+		"transaction": transaction,
+	})
+}
+
+func (h *Handler) RejectAdjustment(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req ReviewAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	adjustment, err := h.service.RejectAdjustment(c.Request.Context(), c.GetUint("user_id"), id, req.Note)
+	if err != nil {
+		writeAdminError(c, err, "adjustment rejection failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "adjustment rejected",
+		"adjustment": adjustment,
+	})
+}
+
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + strings.ReplaceAll(param, "_", " ")})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeAdminError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrAdjustmentNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrWalletAlreadyLocked), errors.Is(err, ErrWalletNotLocked),
+		errors.Is(err, ErrAdjustmentReviewed), errors.Is(err, ErrInsufficientBalance):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrSelfApproval):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidAmount):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fiat_amount or gold_grams must be non-zero"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,91 @@
+package wallet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/clock"
+	"github.com/919Umesh/gold_go/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type fixedPrice float64
+
+func (p fixedPrice) GetCurrentPrice(ctx context.Context) (float64, time.Time, error) {
+	return float64(p), time.Time{}, nil
+}
+
+type recordingService struct {
+	Service
+	sold bool
+}
+
+func (s *recordingService) SellGold(ctx context.Context, userID uint, grams, pricePerGram float64, referenceID string) (*models.Wallet, *models.Transaction, error) {
+	s.sold = true
+	return &models.Wallet{}, &models.Transaction{}, nil
+}
+
+func newSellRouter(service Service, clk clock.Clock, claims *utils.JWTClaims) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.Auth.LargeSellThreshold = 100000
+	cfg.Auth.StepUpMaxAgeMinutes = 5
+	handler := NewHandler(service, fixedPrice(10000), cfg, clk)
+
+	router := gin.New()
+	router.POST("/sell", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Set("claims", claims)
+	}, handler.SellGold)
+	return router
+}
+
+func sell(router *gin.Engine, body string) int {
+	req := httptest.NewRequest(http.MethodPost, "/sell", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestSellGoldValuesStepUpAtServerPrice(t *testing.T) {
+	service := &recordingService{}
+	router := newSellRouter(service, clock.System{}, &utils.JWTClaims{UserID: 1})
+
+	// 20g at the server's 10000/g is 200000; the client-supplied price must
+	// not talk it under the threshold.
+	if code := sell(router, `{"grams": 20, "price_per_gram": 0.01}`); code != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", code)
+	}
+	if service.sold {
+		t.Fatal("large sale went through without step-up")
+	}
+
+	if code := sell(router, `{"grams": 5, "price_per_gram": 10000}`); code != http.StatusOK {
+		t.Fatalf("small sale: status = %d, want 200", code)
+	}
+}
+
+func TestSellGoldAcceptsStepUpUntilItExpires(t *testing.T) {
+	clk := &clock.Fixed{Time: time.Unix(1_700_000_000, 0)}
+	claims := &utils.JWTClaims{UserID: 1, StepUpAt: clk.Now().Unix()}
+	service := &recordingService{}
+	router := newSellRouter(service, clk, claims)
+	body := `{"grams": 20, "price_per_gram": 10000}`
+
+	clk.Advance(5 * time.Minute)
+	if code := sell(router, body); code != http.StatusOK {
+		t.Fatalf("within max age: status = %d, want 200", code)
+	}
+
+	clk.Advance(time.Second)
+	if code := sell(router, body); code != http.StatusForbidden {
+		t.Fatalf("past max age: status = %d, want 403", code)
+	}
+}
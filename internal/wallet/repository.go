@@ -1,22 +1,51 @@
 package wallet
 
 import (
-	"log"
+	"context"
 
 	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/uow"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+type AdjustmentFilter struct {
+	Status string
+	UserID uint
+	Limit  int
+}
+
+type EventFilter struct {
+	UserID   uint
+	ActorID  uint
+	Type     string
+	BeforeID uint
+	Limit    int
+}
+
 type Repository interface {
-	GetByUserID(userID uint) (*models.Wallet, error)
-	Create(wallet *models.Wallet) error
-	Update(wallet *models.Wallet) error
-	WithLock(userID uint, fn func(*models.Wallet) error) error
+	WithTx(tx uow.Tx) Repository
+
+	GetByUserID(ctx context.Context, userID uint) (*models.Wallet, error)
+	LockByUserID(ctx context.Context, userID uint) (*models.Wallet, error)
+	LockOrCreateByUserID(ctx context.Context, userID uint) (*models.Wallet, error)
+	Create(ctx context.Context, wallet *models.Wallet) error
+	Update(ctx context.Context, wallet *models.Wallet) error
+
+	CreateTransaction(ctx context.Context, transaction *models.Transaction) error
+	UpdateTransaction(ctx context.Context, transaction *models.Transaction) error
 
-	CreateTransaction(transaction *models.Transaction) error
-	UpdateTransaction(transaction *models.Transaction) error
+	GetUserTransaction(ctx context.Context, userID uint) ([]models.Transaction, error)
 
-	GetUserTransaction(userID uint) ([]models.Transaction, error)
+	UserExists(ctx context.Context, userID uint) (bool, error)
+	CreateEvent(ctx context.Context, event *models.WalletEvent) error
+	ListEvents(ctx context.Context, filter EventFilter) ([]models.WalletEvent, error)
+
+	CreateAdjustment(ctx context.Context, adjustment *models.BalanceAdjustment) error
+	FindAdjustment(ctx context.Context, id uint) (*models.BalanceAdjustment, error)
+	LockAdjustment(ctx context.Context, id uint) (*models.BalanceAdjustment, error)
+	UpdateAdjustment(ctx context.Context, adjustment *models.BalanceAdjustment) error
+	ListAdjustments(ctx context.Context, filter AdjustmentFilter) ([]models.BalanceAdjustment, error)
 }
 
 type repository struct {
@@ -27,32 +56,36 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
-func (r *repository) GetByUserID(userID uint) (*models.Wallet, error) {
+func (r *repository) WithTx(tx uow.Tx) Repository {
+	return &repository{db: tx.DB()}
+}
+
+func (r *repository) GetByUserID(ctx context.Context, userID uint) (*models.Wallet, error) {
 	var wallet models.Wallet
-	err := r.db.Where("user_id = ?", userID).First(&wallet).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&wallet).Error
 	if err != nil {
 		return nil, err
 	}
 	return &wallet, nil
 }
 
-func (r *repository) Create(wallet *models.Wallet) error {
-	return r.db.Create(wallet).Error
+func (r *repository) Create(ctx context.Context, wallet *models.Wallet) error {
+	return r.db.WithContext(ctx).Create(wallet).Error
 }
 
-func (r *repository) Update(wallet *models.Wallet) error {
-	return r.db.Save(wallet).Error
+func (r *repository) Update(ctx context.Context, wallet *models.Wallet) error {
+	return r.db.WithContext(ctx).Save(wallet).Error
 }
 
-func (r *repository) CreateTransaction(transaction *models.Transaction) error {
-	return r.db.Create(transaction).Error
+func (r *repository) CreateTransaction(ctx context.Context, transaction *models.Transaction) error {
+	return r.db.WithContext(ctx).Create(transaction).Error
 }
 
-func (r *repository) UpdateTransaction(transaction *models.Transaction) error {
-	return r.db.Save(transaction).Error
+func (r *repository) UpdateTransaction(ctx context.Context, transaction *models.Transaction) error {
+	return r.db.WithContext(ctx).Save(transaction).Error
 }
 
-func (r *repository) GetUserTransaction(userID uint) ([]models.Transaction, error) {
+func (r *repository) GetUserTransaction(ctx context.Context, userID uint) ([]models.Transaction, error) {
 	var transaction []models.Transaction
 	query := ` 
 			SELECT * 
@@ -60,26 +93,101 @@ func (r *repository) GetUserTransaction(userID uint) ([]models.Transaction, erro
 			WHERE user_id = ? 
 			ORDER BY created_at DESC 
 		`
-	err := r.db.Raw(query, userID).Scan(&transaction).Error
+	err := r.db.WithContext(ctx).Raw(query, userID).Scan(&transaction).Error
 	if err != nil {
 		return nil, err
 	}
 	return transaction, nil
 }
 
-func (r *repository) WithLock(userID uint, fn func(*models.Wallet) error) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		var wallet models.Wallet
-		if err := tx.Set("gorm:query_option", "FOR UPDATE").
-			Where("user_id = ?", userID).First(&wallet).Error; err != nil {
-			return err
-		}
-
-		if err := fn(&wallet); err != nil {
-			return err
-		}
-		log.Print("Before saving the data")
-		log.Print(&wallet)
-		return tx.Save(&wallet).Error
-	})
+// LockByUserID reads the wallet with FOR UPDATE. It only holds the lock when
+// the repository is bound to a unit of work.
+func (r *repository) LockByUserID(ctx context.Context, userID uint) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).First(&wallet).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// LockOrCreateByUserID is LockByUserID for admin actions, which may reach a
+// user who never opened their wallet.
+func (r *repository) LockOrCreateByUserID(ctx context.Context, userID uint) (*models.Wallet, error) {
+	var wallet models.Wallet
+	err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where(models.Wallet{UserID: userID}).FirstOrCreate(&wallet).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+func (r *repository) UserExists(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *repository) CreateEvent(ctx context.Context, event *models.WalletEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *repository) ListEvents(ctx context.Context, filter EventFilter) ([]models.WalletEvent, error) {
+	var events []models.WalletEvent
+	query := r.db.WithContext(ctx).Model(&models.WalletEvent{})
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.ActorID != 0 {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+	err := query.Order("id desc").Limit(filter.Limit).Find(&events).Error
+	return events, err
+}
+
+func (r *repository) CreateAdjustment(ctx context.Context, adjustment *models.BalanceAdjustment) error {
+	return r.db.WithContext(ctx).Create(adjustment).Error
+}
+
+func (r *repository) FindAdjustment(ctx context.Context, id uint) (*models.BalanceAdjustment, error) {
+	var adjustment models.BalanceAdjustment
+	if err := r.db.WithContext(ctx).First(&adjustment, id).Error; err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+func (r *repository) ListAdjustments(ctx context.Context, filter AdjustmentFilter) ([]models.BalanceAdjustment, error) {
+	var adjustments []models.BalanceAdjustment
+	query := r.db.WithContext(ctx).Model(&models.BalanceAdjustment{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	err := query.Order("id desc").Limit(filter.Limit).Find(&adjustments).Error
+	return adjustments, err
+}
+
+// LockAdjustment reads the adjustment with FOR UPDATE, so two reviewers
+// cannot decide it at once.
+func (r *repository) LockAdjustment(ctx context.Context, id uint) (*models.BalanceAdjustment, error) {
+	var adjustment models.BalanceAdjustment
+	if err := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).First(&adjustment, id).Error; err != nil {
+		return nil, err
+	}
+	return &adjustment, nil
+}
+
+func (r *repository) UpdateAdjustment(ctx context.Context, adjustment *models.BalanceAdjustment) error {
+	return r.db.WithContext(ctx).Save(adjustment).Error
 }
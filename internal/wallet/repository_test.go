@@ -0,0 +1,40 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/919Umesh/gold_go/pkg/logging"
+	"github.com/919Umesh/gold_go/pkg/uow/uowtest"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestQueryLogsCarryTheRequestID(t *testing.T) {
+	gormDB, _ := uowtest.Open(t, func(query string, args []driver.NamedValue) (uowtest.Result, error) {
+		return uowtest.Result{}, errors.New("connection reset")
+	})
+	var out bytes.Buffer
+	slogger, err := logging.New(&out, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gormDB = gormDB.Session(&gorm.Session{Logger: logger.NewSlogLogger(slogger, logger.Config{LogLevel: logger.Warn})})
+
+	ctx := logging.WithRequestID(context.Background(), "req-42")
+	if _, err := NewRepository(gormDB).GetByUserID(ctx, 3); err == nil {
+		t.Fatal("GetByUserID succeeded, want the query error")
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("log output %q: %v", out.String(), err)
+	}
+	if record["request_id"] != "req-42" {
+		t.Fatalf("query log = %s, want request_id req-42", out.String())
+	}
+}
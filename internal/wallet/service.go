@@ -1,62 +1,118 @@
 package wallet
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
 	"time"
 
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/audit"
+	"github.com/919Umesh/gold_go/internal/limits"
 	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/metrics"
+	"github.com/919Umesh/gold_go/pkg/tracing"
+	"github.com/919Umesh/gold_go/pkg/uow"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	ErrInsufficientBalance = errors.New("insufficient balance")
 	ErrWalletLocked        = errors.New("wallet is locked")
 	ErrInvalidAmount       = errors.New("invalid amount")
+	ErrPriceChanged        = errors.New("gold price has changed")
+	ErrPriceUnavailable    = errors.New("gold price not available")
 )
 
 type Service interface {
-	GetWallet(userID uint) (*models.Wallet, error)
-	TopUp(userID uint, amount float64, referenceID string) (*models.Wallet, *models.Transaction, error)
-	BuyGold(userID uint, grams, pricePerGram float64, referenceID string) (*models.Wallet, *models.Transaction, error)
-	SellGold(userID uint, grams, pricePerGram float64, referenceID string) (*models.Wallet, *models.Transaction, error)
-	GetUserTransaction(userID uint) ([]models.Transaction, error)
+	GetWallet(ctx context.Context, userID uint) (*models.Wallet, error)
+	TopUp(ctx context.Context, userID uint, amount float64, referenceID string) (*models.Wallet, *models.Transaction, error)
+	BuyGold(ctx context.Context, userID uint, grams, quotedPrice float64, referenceID string) (*models.Wallet, *models.Transaction, error)
+	SellGold(ctx context.Context, userID uint, grams, quotedPrice float64, referenceID string) (*models.Wallet, *models.Transaction, error)
+	GetUserTransaction(ctx context.Context, userID uint) ([]models.Transaction, error)
+
+	Freeze(ctx context.Context, actorID, userID uint, reason string) error
+	Unfreeze(ctx context.Context, actorID, userID uint, reason string) error
+	RequestAdjustment(ctx context.Context, actorID, userID uint, fiatAmount, goldGrams float64, reason string) (*models.BalanceAdjustment, error)
+	ApproveAdjustment(ctx context.Context, actorID, id uint, note string) (*models.BalanceAdjustment, *models.Transaction, error)
+	RejectAdjustment(ctx context.Context, actorID, id uint, note string) (*models.BalanceAdjustment, error)
+	ListAdjustments(ctx context.Context, filter AdjustmentFilter) ([]models.BalanceAdjustment, error)
+	ListEvents(ctx context.Context, filter EventFilter) ([]models.WalletEvent, error)
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, eventType string, data interface{}) error
+}
+
+// Publishers fans an event out to several publishers. Every publisher is
+// tried; the failures are joined.
+type Publishers []EventPublisher
+
+func (p Publishers) Publish(ctx context.Context, eventType string, data interface{}) error {
+	var errs []error
+	for _, publisher := range p {
+		if err := publisher.Publish(ctx, eventType, data); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Limiter enforces transaction limits. Reserve records the usage up front;
+// the returned release function gives it back if the operation fails.
+type Limiter interface {
+	Reserve(ctx context.Context, userID uint, operation string, amountNPR, grams float64) (func(), error)
+}
+
+// PriceSource is the server-side gold price. Trades, limits and security
+// decisions are valued with it, never with a price from the request body.
+type PriceSource interface {
+	GetCurrentPrice(ctx context.Context) (float64, time.Time, error)
 }
 
 type service struct {
-	repo Repository
+	repo    Repository
+	work    *uow.UnitOfWork
+	prices  PriceSource
+	cfg     *config.Config
+	events  EventPublisher
+	limiter Limiter
+	audit   audit.Service
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(repo Repository, work *uow.UnitOfWork, prices PriceSource, cfg *config.Config, events EventPublisher, limiter Limiter, auditService audit.Service) Service {
+	return &service{repo: repo, work: work, prices: prices, cfg: cfg, events: events, limiter: limiter, audit: auditService}
 }
 
-func (s *service) GetWallet(userID uint) (*models.Wallet, error) {
-	wallet, err := s.repo.GetByUserID(userID)
+func (s *service) GetWallet(ctx context.Context, userID uint) (*models.Wallet, error) {
+	wallet, err := s.repo.GetByUserID(ctx, userID)
 	if err != nil {
 		wallet = &models.Wallet{UserID: userID}
-		if err := s.repo.Create(wallet); err != nil {
+		if err := s.repo.Create(ctx, wallet); err != nil {
 			return nil, fmt.Errorf("failed to create wallet: %w", err)
 		}
 	}
 	return wallet, nil
 }
 
-func (s *service) TopUp(userID uint, amount float64, referenceID string) (*models.Wallet, *models.Transaction, error) {
+func (s *service) TopUp(ctx context.Context, userID uint, amount float64, referenceID string) (updatedWallet *models.Wallet, transaction *models.Transaction, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "wallet.topup")
+	defer func() { recordOperation(span, models.TransactionTypeTopUp, transaction, err) }()
+
 	if amount <= 0 {
 		return nil, nil, ErrInvalidAmount
 	}
+	release, err := s.limiter.Reserve(ctx, userID, string(models.TransactionTypeTopUp), amount, 0)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	var updatedWallet *models.Wallet
-	var transaction *models.Transaction
-
-	err := s.repo.WithLock(userID, func(wallet *models.Wallet) error {
-		if wallet.Locked {
-			return ErrWalletLocked
-		}
+	updatedWallet, transaction, err = s.apply(ctx, userID, func(wallet *models.Wallet) (*models.Transaction, error) {
 		wallet.FiatBalance += amount
-		updatedWallet = wallet
 
-		transaction = &models.Transaction{
+		return &models.Transaction{
 			UserID:       userID,
 			Type:         models.TransactionTypeTopUp,
 			Amount:       amount,
@@ -66,102 +122,181 @@ func (s *service) TopUp(userID uint, amount float64, referenceID string) (*model
 			ReferenceID:  referenceID,
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
-		}
-
-		return s.repo.CreateTransaction(transaction)
+		}, nil
 	})
 	if err != nil {
+		release()
 		return nil, nil, err
 	}
+
+	s.publish(ctx, models.WebhookEventTopUp, transaction)
 	return updatedWallet, transaction, err
 }
 
-func (s *service) BuyGold(userID uint, grams, pricePerGram float64, referenceID string) (*models.Wallet, *models.Transaction, error) {
-	if grams <= 0 || pricePerGram <= 0 {
+func (s *service) BuyGold(ctx context.Context, userID uint, grams, quotedPrice float64, referenceID string) (updatedWallet *models.Wallet, transaction *models.Transaction, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "wallet.buy")
+	defer func() { recordOperation(span, models.TransactionTypeBuy, transaction, err) }()
+
+	if grams <= 0 || quotedPrice <= 0 {
 		return nil, nil, ErrInvalidAmount
 	}
+	pricePerGram, err := s.tradePrice(ctx, quotedPrice, true)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	totalCost := grams * pricePerGram
-	var updatedWallet *models.Wallet
-	var transaction *models.Transaction
+	value := grams * pricePerGram
+	fee := feeOn(value, s.cfg.Fees.BuyPercent)
+	totalCost := value + fee
+	release, err := s.limiter.Reserve(ctx, userID, string(models.TransactionTypeBuy), value, grams)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	err := s.repo.WithLock(userID, func(wallet *models.Wallet) error {
-		if wallet.Locked {
-			return ErrWalletLocked
-		}
+	updatedWallet, transaction, err = s.apply(ctx, userID, func(wallet *models.Wallet) (*models.Transaction, error) {
 		if wallet.FiatBalance < totalCost {
-			return ErrInsufficientBalance
+			return nil, ErrInsufficientBalance
 		}
 
 		wallet.FiatBalance -= totalCost
 		wallet.GoldGrams += grams
-		updatedWallet = wallet
 
-		transaction = &models.Transaction{
+		return &models.Transaction{
 			UserID:       userID,
 			Type:         models.TransactionTypeBuy,
 			Amount:       totalCost,
+			Fee:          fee,
 			GoldGrams:    grams,
 			PricePerGram: pricePerGram,
 			Status:       models.TransactionStatusSuccess,
 			ReferenceID:  referenceID,
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
-		}
-
-		return s.repo.CreateTransaction(transaction)
+		}, nil
 	})
 	if err != nil {
+		release()
 		return nil, nil, err
 	}
 
+	s.publish(ctx, models.WebhookEventBuy, transaction)
 	return updatedWallet, transaction, err
 }
 
-func (s *service) SellGold(userID uint, grams, pricePerGram float64, referenceID string) (*models.Wallet, *models.Transaction, error) {
-	if grams <= 0 || pricePerGram <= 0 {
+func (s *service) SellGold(ctx context.Context, userID uint, grams, quotedPrice float64, referenceID string) (updatedWallet *models.Wallet, transaction *models.Transaction, err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "wallet.sell")
+	defer func() { recordOperation(span, models.TransactionTypeSell, transaction, err) }()
+
+	if grams <= 0 || quotedPrice <= 0 {
 		return nil, nil, ErrInvalidAmount
 	}
+	pricePerGram, err := s.tradePrice(ctx, quotedPrice, false)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	totalValue := grams * pricePerGram
-	var updatedWallet *models.Wallet
-	var transaction *models.Transaction
+	value := grams * pricePerGram
+	fee := feeOn(value, s.cfg.Fees.SellPercent)
+	totalValue := value - fee
+	release, err := s.limiter.Reserve(ctx, userID, string(models.TransactionTypeSell), value, grams)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	err := s.repo.WithLock(userID, func(wallet *models.Wallet) error {
-		if wallet.Locked {
-			return ErrWalletLocked
-		}
+	updatedWallet, transaction, err = s.apply(ctx, userID, func(wallet *models.Wallet) (*models.Transaction, error) {
 		if wallet.GoldGrams < grams {
-			return ErrInsufficientBalance
+			return nil, ErrInsufficientBalance
 		}
 
 		wallet.GoldGrams -= grams
 		wallet.FiatBalance += totalValue
-		updatedWallet = wallet
 
-		transaction = &models.Transaction{
+		return &models.Transaction{
 			UserID:       userID,
 			Type:         models.TransactionTypeSell,
 			Amount:       totalValue,
+			Fee:          fee,
 			GoldGrams:    grams,
 			PricePerGram: pricePerGram,
 			Status:       models.TransactionStatusSuccess,
 			ReferenceID:  referenceID,
 			CreatedAt:    time.Now(),
 			UpdatedAt:    time.Now(),
-		}
-		return s.repo.CreateTransaction(transaction)
+		}, nil
 	})
 	if err != nil {
+		release()
 		return nil, nil, err
 	}
 
+	s.publish(ctx, models.WebhookEventSell, transaction)
 	return updatedWallet, transaction, err
 }
 
-func (s *service) GetUserTransaction(userID uint) ([]models.Transaction, error) {
+// feeOn returns percent of value, rounded to the paisa. Limits are reserved
+// on the value alone; the fee only changes what the wallet is charged or paid.
+func feeOn(value, percent float64) float64 {
+	return math.Round(value*percent) / 100
+}
+
+// tradePrice returns the server price a trade executes at. The client sends
+// the price it was shown; the trade is refused if the server price is worse
+// for the user by more than the quote tolerance, which absorbs cache age and
+// replicas a price update apart. A better price is always accepted.
+func (s *service) tradePrice(ctx context.Context, quoted float64, buying bool) (float64, error) {
+	price, _, err := s.prices.GetCurrentPrice(ctx)
+	if err != nil {
+		return 0, ErrPriceUnavailable
+	}
+	price, quoted = models.RoundPrice(price), models.RoundPrice(quoted)
+
+	worse := price - quoted
+	if !buying {
+		worse = quoted - price
+	}
+	if worse > quoted*s.cfg.Pricing.QuoteTolerancePercent/100 {
+		return 0, ErrPriceChanged
+	}
+	return price, nil
+}
+
+// apply locks the wallet, lets change update it and build the transaction,
+// then saves both in one unit of work. change may run more than once when
+// the unit of work is retried.
+func (s *service) apply(ctx context.Context, userID uint, change func(*models.Wallet) (*models.Transaction, error)) (*models.Wallet, *models.Transaction, error) {
+	var wallet *models.Wallet
+	var transaction *models.Transaction
+
+	err := s.work.Do(ctx, func(ctx context.Context, tx uow.Tx) error {
+		repo := s.repo.WithTx(tx)
+
+		var err error
+		wallet, err = repo.LockByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if wallet.Locked {
+			return ErrWalletLocked
+		}
+
+		transaction, err = change(wallet)
+		if err != nil {
+			return err
+		}
+		if err := repo.Update(ctx, wallet); err != nil {
+			return err
+		}
+		return repo.CreateTransaction(ctx, transaction)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return wallet, transaction, nil
+}
+
+func (s *service) GetUserTransaction(ctx context.Context, userID uint) ([]models.Transaction, error) {
 
-	transaction, err := s.repo.GetUserTransaction(userID)
+	transaction, err := s.repo.GetUserTransaction(ctx, userID)
 
 	if err != nil {
 		return nil, err
@@ -169,3 +304,48 @@ func (s *service) GetUserTransaction(userID uint) ([]models.Transaction, error)
 
 	return transaction, nil
 }
+
+func (s *service) publish(ctx context.Context, eventType string, transaction *models.Transaction) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Publish(ctx, eventType, transaction); err != nil {
+		slog.ErrorContext(ctx, "wallet: publish event failed", "event", eventType, "transaction_id", transaction.ID, "error", err)
+	}
+}
+
+// recordOperation counts the operation and ends its span.
+func recordOperation(span trace.Span, operation models.TransactionType, transaction *models.Transaction, err error) {
+	tracing.End(span, err)
+	if err != nil {
+		metrics.WalletOperations.WithLabelValues(string(operation), "failure").Inc()
+		metrics.WalletFailures.WithLabelValues(string(operation), failureReason(err)).Inc()
+		return
+	}
+	metrics.WalletOperations.WithLabelValues(string(operation), "success").Inc()
+	metrics.WalletVolumeNPR.WithLabelValues(string(operation)).Add(transaction.Amount)
+	metrics.WalletVolumeGrams.WithLabelValues(string(operation)).Add(transaction.GoldGrams)
+}
+
+// failureReason keeps the reason label to a fixed set of values.
+func failureReason(err error) string {
+	var limitErr *limits.LimitError
+	switch {
+	case errors.Is(err, ErrInvalidAmount):
+		return "invalid_amount"
+	case errors.Is(err, ErrInsufficientBalance):
+		return "insufficient_balance"
+	case errors.Is(err, ErrWalletLocked):
+		return "wallet_locked"
+	case errors.Is(err, ErrPriceChanged):
+		return "price_changed"
+	case errors.Is(err, ErrPriceUnavailable):
+		return "price_unavailable"
+	case errors.As(err, &limitErr):
+		return "limit_exceeded"
+	case uow.Retryable(err):
+		return "conflict"
+	default:
+		return "internal"
+	}
+}
@@ -0,0 +1,203 @@
+package wallet
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/internal/limits"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/919Umesh/gold_go/pkg/metrics"
+	"github.com/919Umesh/gold_go/pkg/uow"
+	"github.com/919Umesh/gold_go/pkg/uow/uowtest"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var errStop = errors.New("stop before touching the wallet")
+
+// recordingLimiter remembers what it was asked to reserve and refuses, so
+// the trade stops before it needs a database.
+type recordingLimiter struct {
+	operation string
+	amountNPR float64
+	grams     float64
+}
+
+func (l *recordingLimiter) Reserve(ctx context.Context, userID uint, operation string, amountNPR, grams float64) (func(), error) {
+	l.operation, l.amountNPR, l.grams = operation, amountNPR, grams
+	return nil, errStop
+}
+
+func TestTradesReserveLimitsAtServerPrice(t *testing.T) {
+	limiter := &recordingLimiter{}
+	svc := NewService(nil, nil, fixedPrice(6500), &config.Config{}, nil, limiter, nil)
+	ctx := context.Background()
+
+	if _, _, err := svc.BuyGold(ctx, 1, 2, 6500, "ref"); !errors.Is(err, errStop) {
+		t.Fatalf("buy: err = %v", err)
+	}
+	if limiter.operation != "buy" || limiter.amountNPR != 13000 || limiter.grams != 2 {
+		t.Fatalf("buy reserved %s %.2f NPR %.2f g, want buy 13000 NPR 2 g", limiter.operation, limiter.amountNPR, limiter.grams)
+	}
+
+	if _, _, err := svc.SellGold(ctx, 1, 3, 6500, "ref"); !errors.Is(err, errStop) {
+		t.Fatalf("sell: err = %v", err)
+	}
+	if limiter.operation != "sell" || limiter.amountNPR != 19500 {
+		t.Fatalf("sell reserved %s %.2f NPR, want sell 19500 NPR", limiter.operation, limiter.amountNPR)
+	}
+}
+
+func TestTradesRefuseAStaleQuote(t *testing.T) {
+	limiter := &recordingLimiter{}
+	svc := NewService(nil, nil, fixedPrice(6500), &config.Config{}, nil, limiter, nil)
+	ctx := context.Background()
+
+	if _, _, err := svc.BuyGold(ctx, 1, 1000, 0.01, "ref"); !errors.Is(err, ErrPriceChanged) {
+		t.Errorf("buy: err = %v, want ErrPriceChanged", err)
+	}
+	if _, _, err := svc.SellGold(ctx, 1, 1000, 9000, "ref"); !errors.Is(err, ErrPriceChanged) {
+		t.Errorf("sell: err = %v, want ErrPriceChanged", err)
+	}
+	if limiter.operation != "" {
+		t.Fatalf("limits reserved for a refused trade: %s", limiter.operation)
+	}
+}
+
+func TestTradesAcceptQuotesWithinTolerance(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Pricing.QuoteTolerancePercent = 0.5
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name    string
+		buy     bool
+		current float64
+		quoted  float64
+		want    error
+	}{
+		// The provider price carries more decimals than the stored one.
+		{"buy, rounding only", true, 6500.00004, 6500, errStop},
+		{"sell, rounding only", false, 6499.99996, 6500, errStop},
+		// Another replica or the /gold/price cache is one update behind.
+		{"buy, cached quote slightly lower", true, 6510, 6500, errStop},
+		{"sell, cached quote slightly higher", false, 6490, 6500, errStop},
+		{"buy, price fell", true, 6000, 6500, errStop},
+		{"sell, price rose", false, 7000, 6500, errStop},
+		{"buy, beyond tolerance", true, 6533, 6500, ErrPriceChanged},
+		{"sell, beyond tolerance", false, 6467, 6500, ErrPriceChanged},
+	} {
+		limiter := &recordingLimiter{}
+		svc := NewService(nil, nil, fixedPrice(tc.current), cfg, nil, limiter, nil)
+		trade := svc.SellGold
+		if tc.buy {
+			trade = svc.BuyGold
+		}
+
+		if _, _, err := trade(ctx, 1, 2, tc.quoted, "ref"); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+		// Accepted trades are valued at the rounded server price.
+		if tc.want == errStop && limiter.amountNPR != 2*models.RoundPrice(tc.current) {
+			t.Fatalf("%s: reserved %v NPR, want 2 g at the server price %v", tc.name, limiter.amountNPR, tc.current)
+		}
+	}
+}
+
+// allowingLimiter remembers what it was asked to reserve and allows it.
+type allowingLimiter struct {
+	amountNPR float64
+}
+
+func (l *allowingLimiter) Reserve(ctx context.Context, userID uint, operation string, amountNPR, grams float64) (func(), error) {
+	l.amountNPR = amountNPR
+	return func() {}, nil
+}
+
+// tradeDB holds the wallet of user 1: 20000 NPR and 5 g of gold.
+func tradeDB(query string, args []driver.NamedValue) (uowtest.Result, error) {
+	switch {
+	case strings.HasPrefix(query, `SELECT * FROM "wallets"`):
+		return uowtest.Result{
+			Columns: []string{"id", "user_id", "fiat_balance", "gold_grams", "version"},
+			Rows:    [][]driver.Value{{int64(4), int64(1), 20000.0, 5.0, int64(1)}},
+		}, nil
+	case strings.HasPrefix(query, "INSERT"):
+		return uowtest.Result{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(31)}}}, nil
+	}
+	return uowtest.Result{RowsAffected: 1}, nil
+}
+
+func TestTradesChargeTheConfiguredFees(t *testing.T) {
+	fees := &config.Config{Fees: config.FeesConfig{BuyPercent: 1.5, SellPercent: 2}}
+	ctx := context.Background()
+
+	gormDB, _ := uowtest.Open(t, tradeDB)
+	limiter := &allowingLimiter{}
+	svc := NewService(NewRepository(gormDB), uow.New(gormDB, uow.Options{}), fixedPrice(6500), fees, nil, limiter, nil)
+	wallet, transaction, err := svc.BuyGold(ctx, 1, 2, 6500, "ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 g at 6500 is 13000 NPR, plus 1.5%.
+	if transaction.Fee != 195 || transaction.Amount != 13195 || wallet.FiatBalance != 6805 || wallet.GoldGrams != 7 {
+		t.Fatalf("buy: transaction = %+v, wallet = %+v, want 13195 NPR charged with a 195 NPR fee", transaction, wallet)
+	}
+	if limiter.amountNPR != 13000 {
+		t.Fatalf("buy reserved %.2f NPR, want the 13000 NPR trade value", limiter.amountNPR)
+	}
+
+	gormDB, _ = uowtest.Open(t, tradeDB)
+	svc = NewService(NewRepository(gormDB), uow.New(gormDB, uow.Options{}), fixedPrice(6500), fees, nil, limiter, nil)
+	wallet, transaction, err = svc.SellGold(ctx, 1, 3, 6500, "ref")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 3 g at 6500 is 19500 NPR, less 2%.
+	if transaction.Fee != 390 || transaction.Amount != 19110 || wallet.FiatBalance != 39110 || wallet.GoldGrams != 2 {
+		t.Fatalf("sell: transaction = %+v, wallet = %+v, want 19110 NPR paid after a 390 NPR fee", transaction, wallet)
+	}
+	if limiter.amountNPR != 19500 {
+		t.Fatalf("sell reserved %.2f NPR, want the 19500 NPR trade value", limiter.amountNPR)
+	}
+}
+
+func TestFailedOperationsAreCountedByReason(t *testing.T) {
+	metrics.WalletOperations.Reset()
+	metrics.WalletFailures.Reset()
+	_, span := noop.NewTracerProvider().Tracer("test").Start(context.Background(), "wallet")
+
+	recordOperation(span, models.TransactionTypeBuy, nil, fmt.Errorf("buy: %w", ErrInsufficientBalance))
+	recordOperation(span, models.TransactionTypeBuy, nil, &limits.LimitError{Operation: "buy"})
+	recordOperation(span, models.TransactionTypeBuy, nil, ErrPriceChanged)
+	recordOperation(span, models.TransactionTypeSell, nil, ErrWalletLocked)
+	recordOperation(span, models.TransactionTypeSell, nil, fmt.Errorf("sell: %w", ErrPriceUnavailable))
+	recordOperation(span, models.TransactionTypeSell, nil, &pgconn.PgError{Code: "40001"})
+	recordOperation(span, models.TransactionTypeTopUp, nil, ErrInvalidAmount)
+	recordOperation(span, models.TransactionTypeTopUp, nil, errors.New("connection reset"))
+
+	expected := `
+# HELP gold_wallet_operation_failures_total Failed wallet operations, by operation and reason.
+# TYPE gold_wallet_operation_failures_total counter
+gold_wallet_operation_failures_total{operation="buy",reason="insufficient_balance"} 1
+gold_wallet_operation_failures_total{operation="buy",reason="limit_exceeded"} 1
+gold_wallet_operation_failures_total{operation="buy",reason="price_changed"} 1
+gold_wallet_operation_failures_total{operation="sell",reason="conflict"} 1
+gold_wallet_operation_failures_total{operation="sell",reason="price_unavailable"} 1
+gold_wallet_operation_failures_total{operation="sell",reason="wallet_locked"} 1
+gold_wallet_operation_failures_total{operation="topup",reason="internal"} 1
+gold_wallet_operation_failures_total{operation="topup",reason="invalid_amount"} 1
+`
+	if err := testutil.CollectAndCompare(metrics.WalletFailures, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(metrics.WalletOperations.WithLabelValues("buy", "failure")); got != 3 {
+		t.Fatalf("buy failures = %v, want 3", got)
+	}
+}
@@ -0,0 +1,264 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+type CreatePartnerRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=100"`
+}
+
+type CreateSubscriptionRequest struct {
+	PartnerID uint     `json:"partner_id" binding:"required"`
+	Name      string   `json:"name" binding:"required,min=2,max=100"`
+	URL       string   `json:"url" binding:"required,url,max=500"`
+	Events    []string `json:"events" binding:"required,min=1"`
+	Secret    string   `json:"secret,omitempty" binding:"omitempty,min=16,max=128"`
+}
+
+type UpdateSubscriptionRequest struct {
+	PartnerID *uint    `json:"partner_id,omitempty"`
+	URL       *string  `json:"url,omitempty" binding:"omitempty,url,max=500"`
+	Events    []string `json:"events,omitempty" binding:"omitempty,min=1"`
+	Active    *bool    `json:"active,omitempty"`
+}
+
+func (h *Handler) CreatePartner(c *gin.Context) {
+	var req CreatePartnerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	partner, err := h.service.CreatePartner(c.Request.Context(), req.Name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "partner creation failed"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "partner created",
+		"partner": partner,
+	})
+}
+
+func (h *Handler) ListPartners(c *gin.Context) {
+	partners, err := h.service.ListPartners(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch partners"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"partners": partners})
+}
+
+// AssignUser makes the user's transactions visible to the partner's webhook
+// subscriptions. A user belongs to at most one partner.
+func (h *Handler) AssignUser(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := parseID(c, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.AssignUser(c.Request.Context(), id, userID); err != nil {
+		switch err {
+		case ErrPartnerNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "partner not found"})
+		case ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user assigned to partner"})
+}
+
+func (h *Handler) RemoveUser(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	userID, ok := parseID(c, "user_id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveUser(c.Request.Context(), id, userID); err != nil {
+		if err == ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not assigned to this partner"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user removed from partner"})
+}
+
+func (h *Handler) CreateSubscription(c *gin.Context) {
+	var req CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, secret, err := h.service.CreateSubscription(c.Request.Context(), req.PartnerID, req.Name, req.URL, req.Events, req.Secret)
+	if err != nil {
+		switch err {
+		case ErrUnknownEvent:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown event type"})
+		case ErrUnsafeURL:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case ErrPartnerNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "partner not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "subscription creation failed"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "webhook subscription created",
+		"subscription": subscription,
+		"secret":       secret,
+	})
+}
+
+func (h *Handler) ListSubscriptions(c *gin.Context) {
+	subscriptions, err := h.service.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subscriptions})
+}
+
+func (h *Handler) UpdateSubscription(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var req UpdateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, err := h.service.UpdateSubscription(c.Request.Context(), id, req.PartnerID, req.URL, req.Events, req.Active)
+	if err != nil {
+		switch err {
+		case ErrSubscriptionNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+		case ErrPartnerNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "partner not found"})
+		case ErrUnknownEvent:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown event type"})
+		case ErrUnsafeURL:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "subscription update failed"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "webhook subscription updated",
+		"subscription": subscription,
+	})
+}
+
+func (h *Handler) DeleteSubscription(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubscription(c.Request.Context(), id); err != nil {
+		if err == ErrSubscriptionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "subscription deletion failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook subscription deleted"})
+}
+
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), id, c.Query("status"), limit)
+	if err != nil {
+		if err == ErrSubscriptionNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "subscription not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
+
+func (h *Handler) ReplayDelivery(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.ReplayDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		if err == ErrDeliveryNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "delivery replay failed"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  "delivery scheduled for replay",
+		"delivery": delivery,
+	})
+}
+
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + param + " format"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	CreatePartner(ctx context.Context, partner *models.Partner) error
+	FindPartner(ctx context.Context, id uint) (*models.Partner, error)
+	ListPartners(ctx context.Context) ([]models.Partner, error)
+	AssignUser(ctx context.Context, partnerID, userID uint) error
+	RemoveUser(ctx context.Context, partnerID, userID uint) error
+
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	DeleteSubscription(ctx context.Context, id uint) error
+	FindSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	ListUserSubscriptions(ctx context.Context, userID uint) ([]models.WebhookSubscription, error)
+
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	FindDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit int) ([]models.WebhookDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreatePartner(ctx context.Context, partner *models.Partner) error {
+	return r.db.WithContext(ctx).Create(partner).Error
+}
+
+func (r *repository) FindPartner(ctx context.Context, id uint) (*models.Partner, error) {
+	var partner models.Partner
+	if err := r.db.WithContext(ctx).First(&partner, id).Error; err != nil {
+		return nil, err
+	}
+	return &partner, nil
+}
+
+func (r *repository) ListPartners(ctx context.Context) ([]models.Partner, error) {
+	var partners []models.Partner
+	err := r.db.WithContext(ctx).Order("id").Find(&partners).Error
+	return partners, err
+}
+
+func (r *repository) AssignUser(ctx context.Context, partnerID, userID uint) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("partner_id", partnerID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) RemoveUser(ctx context.Context, partnerID, userID uint) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND partner_id = ?", userID, partnerID).
+		Update("partner_id", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(subscription).Error
+}
+
+func (r *repository) UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Save(subscription).Error
+}
+
+func (r *repository) DeleteSubscription(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&models.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) FindSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := r.db.WithContext(ctx).First(&subscription, id).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *repository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.WithContext(ctx).Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// ListUserSubscriptions returns the active subscriptions of the partner the
+// user belongs to, and none for users without a partner.
+func (r *repository) ListUserSubscriptions(ctx context.Context, userID uint) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.WithContext(ctx).Joins("JOIN users ON users.partner_id = webhook_subscriptions.partner_id").
+		Where("users.id = ? AND webhook_subscriptions.active = ?", userID, true).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *repository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&deliveries).Error
+}
+
+func (r *repository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
+
+func (r *repository) FindDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.WithContext(ctx).First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *repository) ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	query := r.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at desc").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDueDeliveries pushes next_attempt_at forward by lease for the rows it
+// returns, so other replicas skip them while this one is sending.
+func (r *repository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= ?",
+				[]models.WebhookDeliveryStatus{models.WebhookDeliveryPending, models.WebhookDeliveryRetrying}, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return deliveries, err
+}
@@ -0,0 +1,433 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrUnknownEvent         = errors.New("unknown webhook event")
+	ErrPartnerNotFound      = errors.New("partner not found")
+	ErrUserNotFound         = errors.New("user not found")
+)
+
+const (
+	dispatchInterval = 5 * time.Second
+	dispatchBatch    = 50
+	claimLease       = 2 * time.Minute
+	baseBackoff      = 30 * time.Second
+	maxBackoff       = 6 * time.Hour
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, eventType string, data interface{}) error
+}
+
+type Service interface {
+	Publisher
+	CreatePartner(ctx context.Context, name string) (*models.Partner, error)
+	ListPartners(ctx context.Context) ([]models.Partner, error)
+	AssignUser(ctx context.Context, partnerID, userID uint) error
+	RemoveUser(ctx context.Context, partnerID, userID uint) error
+	CreateSubscription(ctx context.Context, partnerID uint, name, url string, events []string, secret string) (*models.WebhookSubscription, string, error)
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	UpdateSubscription(ctx context.Context, id uint, partnerID *uint, url *string, events []string, active *bool) (*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uint) error
+	ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit int) ([]models.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, subscriptionID, deliveryID uint) (*models.WebhookDelivery, error)
+	StartDispatcher(ctx context.Context)
+}
+
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+type service struct {
+	repo        Repository
+	client      *http.Client
+	maxAttempts int
+}
+
+func NewService(repo Repository, cfg *config.Config) Service {
+	return &service{
+		repo:        repo,
+		client:      newClient(time.Duration(cfg.Webhooks.TimeoutSeconds) * time.Second),
+		maxAttempts: cfg.Webhooks.MaxAttempts,
+	}
+}
+
+func (s *service) CreatePartner(ctx context.Context, name string) (*models.Partner, error) {
+	partner := &models.Partner{Name: name}
+	if err := s.repo.CreatePartner(ctx, partner); err != nil {
+		return nil, fmt.Errorf("partner creation failed: %w", err)
+	}
+	return partner, nil
+}
+
+func (s *service) ListPartners(ctx context.Context) ([]models.Partner, error) {
+	return s.repo.ListPartners(ctx)
+}
+
+func (s *service) AssignUser(ctx context.Context, partnerID, userID uint) error {
+	if err := s.findPartner(ctx, partnerID); err != nil {
+		return err
+	}
+	if err := s.repo.AssignUser(ctx, partnerID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *service) RemoveUser(ctx context.Context, partnerID, userID uint) error {
+	if err := s.repo.RemoveUser(ctx, partnerID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *service) CreateSubscription(ctx context.Context, partnerID uint, name, url string, events []string, secret string) (*models.WebhookSubscription, string, error) {
+	if err := validateEvents(events); err != nil {
+		return nil, "", err
+	}
+	if err := validateURL(ctx, url); err != nil {
+		return nil, "", err
+	}
+	if err := s.findPartner(ctx, partnerID); err != nil {
+		return nil, "", err
+	}
+
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			return nil, "", fmt.Errorf("secret generation failed: %w", err)
+		}
+		secret = generated
+	}
+
+	subscription := &models.WebhookSubscription{
+		PartnerID: partnerID,
+		Name:      name,
+		URL:       url,
+		Events:    events,
+		Secret:    secret,
+		Active:    true,
+	}
+
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return nil, "", fmt.Errorf("subscription creation failed: %w", err)
+	}
+
+	return subscription, secret, nil
+}
+
+func (s *service) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+func (s *service) UpdateSubscription(ctx context.Context, id uint, partnerID *uint, url *string, events []string, active *bool) (*models.WebhookSubscription, error) {
+	subscription, err := s.findSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if partnerID != nil {
+		if err := s.findPartner(ctx, *partnerID); err != nil {
+			return nil, err
+		}
+		subscription.PartnerID = *partnerID
+	}
+	if url != nil {
+		if err := validateURL(ctx, *url); err != nil {
+			return nil, err
+		}
+		subscription.URL = *url
+	}
+	if events != nil {
+		if err := validateEvents(events); err != nil {
+			return nil, err
+		}
+		subscription.Events = events
+	}
+	if active != nil {
+		subscription.Active = *active
+	}
+
+	if err := s.repo.UpdateSubscription(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("subscription update failed: %w", err)
+	}
+	return subscription, nil
+}
+
+func (s *service) DeleteSubscription(ctx context.Context, id uint) error {
+	if err := s.repo.DeleteSubscription(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSubscriptionNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *service) ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.findSubscription(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, subscriptionID, status, limit)
+}
+
+func (s *service) ReplayDelivery(ctx context.Context, subscriptionID, deliveryID uint) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.FindDelivery(ctx, deliveryID)
+	if err != nil || delivery.SubscriptionID != subscriptionID {
+		return nil, ErrDeliveryNotFound
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.LastError = ""
+
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("delivery replay failed: %w", err)
+	}
+	return delivery, nil
+}
+
+// Publish records one delivery per matching subscription of the partner the
+// transaction's user belongs to. Sending happens in StartDispatcher so that a
+// slow partner never blocks a trade.
+func (s *service) Publish(ctx context.Context, eventType string, data interface{}) error {
+	transaction, ok := data.(*models.Transaction)
+	if !ok {
+		return fmt.Errorf("webhook event %s has no transaction", eventType)
+	}
+	subscriptions, err := s.repo.ListUserSubscriptions(ctx, transaction.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load subscriptions: %w", err)
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	event := Event{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(eventType) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  event.CreatedAt,
+		})
+	}
+
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+func (s *service) StartDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(dispatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.dispatchDue(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *service) dispatchDue(ctx context.Context) {
+	deliveries, err := s.repo.ClaimDueDeliveries(ctx, time.Now(), claimLease, dispatchBatch)
+	if err != nil {
+		slog.ErrorContext(ctx, "webhook: claim deliveries failed", "error", err)
+		return
+	}
+
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return
+		}
+		s.attempt(ctx, &deliveries[i])
+	}
+}
+
+func (s *service) attempt(ctx context.Context, delivery *models.WebhookDelivery) {
+	subscription, err := s.repo.FindSubscription(ctx, delivery.SubscriptionID)
+	if err != nil {
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.LastError = "subscription no longer exists"
+		s.saveDelivery(ctx, delivery)
+		return
+	}
+	// Deliveries queued before the subscription was switched off are not
+	// sent; they can be replayed once it is active again.
+	if !subscription.Active {
+		delivery.Status = models.WebhookDeliveryCancelled
+		delivery.LastError = "subscription is inactive"
+		s.saveDelivery(ctx, delivery)
+		return
+	}
+
+	delivery.Attempts++
+	code, sendErr := s.send(ctx, subscription, delivery)
+	delivery.ResponseCode = code
+
+	if sendErr == nil {
+		now := time.Now()
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		s.saveDelivery(ctx, delivery)
+		return
+	}
+
+	delivery.LastError = truncate(sendErr.Error(), 500)
+	if delivery.Attempts >= s.maxAttempts {
+		delivery.Status = models.WebhookDeliveryDead
+		slog.WarnContext(ctx, "webhook: delivery moved to dead-letter", "delivery_id", delivery.ID, "attempts", delivery.Attempts)
+	} else {
+		delivery.Status = models.WebhookDeliveryRetrying
+		delivery.NextAttemptAt = time.Now().Add(backoff(delivery.Attempts))
+	}
+	s.saveDelivery(ctx, delivery)
+}
+
+func (s *service) send(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.EventID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (s *service) saveDelivery(ctx context.Context, delivery *models.WebhookDelivery) {
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		slog.ErrorContext(ctx, "webhook: save delivery failed", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+func (s *service) findSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	subscription, err := s.repo.FindSubscription(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (s *service) findPartner(ctx context.Context, id uint) error {
+	if _, err := s.repo.FindPartner(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrPartnerNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+func validateEvents(events []string) error {
+	if len(events) == 0 {
+		return ErrUnknownEvent
+	}
+	for _, event := range events {
+		known := false
+		for _, candidate := range models.WebhookEvents {
+			if event == candidate {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return ErrUnknownEvent
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,457 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/models"
+	"gorm.io/gorm"
+)
+
+// fakeRepository keeps subscriptions and deliveries in memory. users maps a
+// user ID to its partner.
+type fakeRepository struct {
+	mu            sync.Mutex
+	partners      map[uint]*models.Partner
+	users         map[uint]uint
+	subscriptions map[uint]*models.WebhookSubscription
+	deliveries    map[uint]*models.WebhookDelivery
+	nextID        uint
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		partners:      map[uint]*models.Partner{},
+		users:         map[uint]uint{},
+		subscriptions: map[uint]*models.WebhookSubscription{},
+		deliveries:    map[uint]*models.WebhookDelivery{},
+	}
+}
+
+func (r *fakeRepository) id() uint {
+	r.nextID++
+	return r.nextID
+}
+
+func (r *fakeRepository) CreatePartner(ctx context.Context, partner *models.Partner) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	partner.ID = r.id()
+	r.partners[partner.ID] = partner
+	return nil
+}
+
+func (r *fakeRepository) FindPartner(ctx context.Context, id uint) (*models.Partner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if partner, ok := r.partners[id]; ok {
+		return partner, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepository) ListPartners(ctx context.Context) ([]models.Partner, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var partners []models.Partner
+	for _, partner := range r.partners {
+		partners = append(partners, *partner)
+	}
+	return partners, nil
+}
+
+func (r *fakeRepository) AssignUser(ctx context.Context, partnerID, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[userID] = partnerID
+	return nil
+}
+
+func (r *fakeRepository) RemoveUser(ctx context.Context, partnerID, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.users[userID] != partnerID {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.users, userID)
+	return nil
+}
+
+func (r *fakeRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subscription.ID = r.id()
+	r.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (r *fakeRepository) UpdateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (r *fakeRepository) DeleteSubscription(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.subscriptions, id)
+	return nil
+}
+
+func (r *fakeRepository) FindSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if subscription, ok := r.subscriptions[id]; ok {
+		return subscription, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var subscriptions []models.WebhookSubscription
+	for _, subscription := range r.subscriptions {
+		subscriptions = append(subscriptions, *subscription)
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeRepository) ListUserSubscriptions(ctx context.Context, userID uint) ([]models.WebhookSubscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	partnerID, ok := r.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	var subscriptions []models.WebhookSubscription
+	for _, subscription := range r.subscriptions {
+		if subscription.PartnerID == partnerID && subscription.Active {
+			subscriptions = append(subscriptions, *subscription)
+		}
+	}
+	return subscriptions, nil
+}
+
+func (r *fakeRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range deliveries {
+		delivery := deliveries[i]
+		delivery.ID = r.id()
+		r.deliveries[delivery.ID] = &delivery
+	}
+	return nil
+}
+
+func (r *fakeRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *delivery
+	r.deliveries[delivery.ID] = &stored
+	return nil
+}
+
+func (r *fakeRepository) FindDelivery(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if delivery, ok := r.deliveries[id]; ok {
+		copied := *delivery
+		return &copied, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRepository) ListDeliveries(ctx context.Context, subscriptionID uint, status string, limit int) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.SubscriptionID == subscriptionID && (status == "" || string(delivery.Status) == status) {
+			deliveries = append(deliveries, *delivery)
+		}
+	}
+	return deliveries, nil
+}
+
+func (r *fakeRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		due := delivery.Status == models.WebhookDeliveryPending || delivery.Status == models.WebhookDeliveryRetrying
+		if due && !delivery.NextAttemptAt.After(now) {
+			deliveries = append(deliveries, *delivery)
+			delivery.NextAttemptAt = now.Add(lease)
+		}
+	}
+	return deliveries, nil
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver answers with the given statuses in turn, repeating the last one.
+func receiver(t *testing.T, statuses ...int) (*httptest.Server, <-chan receivedRequest) {
+	t.Helper()
+	requests := make(chan receivedRequest, 16)
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- receivedRequest{header: r.Header.Clone(), body: body}
+
+		mu.Lock()
+		status := statuses[len(statuses)-1]
+		if calls < len(statuses) {
+			status = statuses[calls]
+		}
+		calls++
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// setup creates a partner with one subscription pointing at url and one user,
+// and publishes a buy by that user.
+func setup(t *testing.T, url string, maxAttempts int) (*service, *fakeRepository, *models.WebhookDelivery) {
+	t.Helper()
+	repo := newFakeRepository()
+	svc := &service{repo: repo, client: http.DefaultClient, maxAttempts: maxAttempts}
+
+	partner, err := svc.CreatePartner(context.Background(), "Jeweller")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.CreateSubscription(context.Background(), &models.WebhookSubscription{
+		PartnerID: partner.ID,
+		URL:       url,
+		Events:    []string{models.WebhookEventBuy},
+		Secret:    "whsec_test",
+		Active:    true,
+	})
+	repo.AssignUser(context.Background(), partner.ID, 7)
+
+	if err := svc.Publish(context.Background(), models.WebhookEventBuy, &models.Transaction{ID: 1, UserID: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("published %d deliveries, want 1", len(repo.deliveries))
+	}
+	for _, delivery := range repo.deliveries {
+		return svc, repo, delivery
+	}
+	return nil, nil, nil
+}
+
+func TestDeliveryIsSignedWithSubscriptionSecret(t *testing.T) {
+	server, requests := receiver(t, http.StatusOK)
+	svc, repo, delivery := setup(t, server.URL, 3)
+
+	svc.dispatchDue(context.Background())
+
+	req := <-requests
+	err := VerifySignature("whsec_test", req.header.Get(HeaderTimestamp), req.header.Get(HeaderSignature), req.body, time.Minute, time.Now())
+	if err != nil {
+		t.Fatalf("signature did not verify: %v", err)
+	}
+	if got := req.header.Get(HeaderEvent); got != models.WebhookEventBuy {
+		t.Errorf("event header = %q", got)
+	}
+	if err := VerifySignature("wrong-secret", req.header.Get(HeaderTimestamp), req.header.Get(HeaderSignature), req.body, time.Minute, time.Now()); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("signature verified with the wrong secret")
+	}
+
+	stored, _ := repo.FindDelivery(context.Background(), delivery.ID)
+	if stored.Status != models.WebhookDeliverySucceeded || stored.DeliveredAt == nil {
+		t.Errorf("status = %s, want succeeded", stored.Status)
+	}
+}
+
+func TestFailedDeliveryIsRetriedWithBackoff(t *testing.T) {
+	server, _ := receiver(t, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK)
+	svc, repo, delivery := setup(t, server.URL, 5)
+
+	for attempt, wantDelay := range []time.Duration{30 * time.Second, time.Minute} {
+		before := time.Now()
+		stored, _ := repo.FindDelivery(context.Background(), delivery.ID)
+		svc.attempt(context.Background(), stored)
+
+		stored, _ = repo.FindDelivery(context.Background(), delivery.ID)
+		if stored.Status != models.WebhookDeliveryRetrying || stored.Attempts != attempt+1 {
+			t.Fatalf("attempt %d: status %s attempts %d", attempt+1, stored.Status, stored.Attempts)
+		}
+		if stored.ResponseCode != http.StatusInternalServerError {
+			t.Errorf("response code = %d", stored.ResponseCode)
+		}
+		if delay := stored.NextAttemptAt.Sub(before); delay < wantDelay || delay > wantDelay+time.Second {
+			t.Errorf("attempt %d: next attempt in %s, want %s", attempt+1, delay, wantDelay)
+		}
+	}
+
+	stored, _ := repo.FindDelivery(context.Background(), delivery.ID)
+	svc.attempt(context.Background(), stored)
+	stored, _ = repo.FindDelivery(context.Background(), delivery.ID)
+	if stored.Status != models.WebhookDeliverySucceeded || stored.Attempts != 3 {
+		t.Errorf("status %s attempts %d, want succeeded after 3", stored.Status, stored.Attempts)
+	}
+}
+
+func TestBackoffDoublesUpToMax(t *testing.T) {
+	cases := map[int]time.Duration{1: 30 * time.Second, 2: time.Minute, 3: 2 * time.Minute, 20: maxBackoff}
+	for attempts, want := range cases {
+		if got := backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestDeliveryIsDeadLetteredAfterMaxAttempts(t *testing.T) {
+	server, _ := receiver(t, http.StatusBadGateway)
+	svc, repo, delivery := setup(t, server.URL, 2)
+
+	for i := 0; i < 2; i++ {
+		stored, _ := repo.FindDelivery(context.Background(), delivery.ID)
+		svc.attempt(context.Background(), stored)
+	}
+
+	stored, _ := repo.FindDelivery(context.Background(), delivery.ID)
+	if stored.Status != models.WebhookDeliveryDead || stored.Attempts != 2 {
+		t.Fatalf("status %s attempts %d, want dead after 2", stored.Status, stored.Attempts)
+	}
+
+	due, _ := repo.ClaimDueDeliveries(context.Background(), time.Now().Add(24*time.Hour), claimLease, dispatchBatch)
+	if len(due) != 0 {
+		t.Errorf("dead delivery was claimed again")
+	}
+}
+
+func TestReplayRedeliversDeadDelivery(t *testing.T) {
+	server, requests := receiver(t, http.StatusServiceUnavailable, http.StatusOK)
+	svc, repo, delivery := setup(t, server.URL, 1)
+
+	svc.dispatchDue(context.Background())
+	<-requests
+	stored, _ := repo.FindDelivery(context.Background(), delivery.ID)
+	if stored.Status != models.WebhookDeliveryDead {
+		t.Fatalf("status = %s, want dead", stored.Status)
+	}
+
+	replayed, err := svc.ReplayDelivery(context.Background(), delivery.SubscriptionID, delivery.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Status != models.WebhookDeliveryPending || replayed.Attempts != 0 {
+		t.Fatalf("replayed status %s attempts %d", replayed.Status, replayed.Attempts)
+	}
+
+	svc.dispatchDue(context.Background())
+	req := <-requests
+	if req.header.Get(HeaderDelivery) != delivery.EventID {
+		t.Errorf("replay sent event %q, want %q", req.header.Get(HeaderDelivery), delivery.EventID)
+	}
+	stored, _ = repo.FindDelivery(context.Background(), delivery.ID)
+	if stored.Status != models.WebhookDeliverySucceeded {
+		t.Errorf("status after replay = %s, want succeeded", stored.Status)
+	}
+
+	if _, err := svc.ReplayDelivery(context.Background(), delivery.SubscriptionID+100, delivery.ID); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("replay through another subscription: err = %v", err)
+	}
+}
+
+func TestPublishOnlyReachesTheUsersPartner(t *testing.T) {
+	repo := newFakeRepository()
+	svc := &service{repo: repo, client: http.DefaultClient, maxAttempts: 3}
+
+	jeweller, _ := svc.CreatePartner(context.Background(), "Jeweller")
+	employer, _ := svc.CreatePartner(context.Background(), "Employer")
+	for _, partner := range []*models.Partner{jeweller, employer} {
+		repo.CreateSubscription(context.Background(), &models.WebhookSubscription{
+			PartnerID: partner.ID,
+			URL:       "https://example.com/hook",
+			Events:    []string{models.WebhookEventBuy},
+			Active:    true,
+		})
+	}
+	repo.AssignUser(context.Background(), jeweller.ID, 1)
+
+	svc.Publish(context.Background(), models.WebhookEventBuy, &models.Transaction{UserID: 1})
+	svc.Publish(context.Background(), models.WebhookEventBuy, &models.Transaction{UserID: 2})
+
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("got %d deliveries, want 1", len(repo.deliveries))
+	}
+	for _, delivery := range repo.deliveries {
+		subscription, _ := repo.FindSubscription(context.Background(), delivery.SubscriptionID)
+		if subscription.PartnerID != jeweller.ID {
+			t.Errorf("delivery went to partner %d, want %d", subscription.PartnerID, jeweller.ID)
+		}
+	}
+}
+
+func TestValidateURLRejectsInternalTargets(t *testing.T) {
+	unsafe := []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://100.64.0.1/hook",
+		"http://[::1]/hook",
+		"http://0.0.0.0/hook",
+		"ftp://93.184.216.34/hook",
+		"not a url",
+	}
+	for _, raw := range unsafe {
+		if err := validateURL(context.Background(), raw); !errors.Is(err, ErrUnsafeURL) {
+			t.Errorf("validateURL(%q) = %v, want ErrUnsafeURL", raw, err)
+		}
+	}
+
+	if err := validateURL(context.Background(), "https://93.184.216.34/hook"); err != nil {
+		t.Errorf("public address rejected: %v", err)
+	}
+}
+
+func TestClientRefusesToDialPrivateAddresses(t *testing.T) {
+	server, _ := receiver(t, http.StatusOK)
+
+	_, err := newClient(time.Second).Get(server.URL)
+	if !errors.Is(err, ErrUnsafeURL) {
+		t.Fatalf("dial to %s: err = %v, want ErrUnsafeURL", server.URL, err)
+	}
+}
+
+func TestDeliveryToInactiveSubscriptionIsCancelled(t *testing.T) {
+	server, requests := receiver(t, http.StatusOK)
+	svc, repo, delivery := setup(t, server.URL, 3)
+	repo.subscriptions[delivery.SubscriptionID].Active = false
+
+	svc.dispatchDue(context.Background())
+
+	select {
+	case <-requests:
+		t.Fatal("delivery was sent to an inactive subscription")
+	default:
+	}
+	stored, _ := repo.FindDelivery(context.Background(), delivery.ID)
+	if stored.Status != models.WebhookDeliveryCancelled || stored.Attempts != 0 {
+		t.Fatalf("status %s attempts %d, want cancelled without an attempt", stored.Status, stored.Attempts)
+	}
+	due, _ := repo.ClaimDueDeliveries(context.Background(), time.Now().Add(24*time.Hour), claimLease, dispatchBatch)
+	if len(due) != 0 {
+		t.Errorf("cancelled delivery was claimed again")
+	}
+}
@@ -0,0 +1,29 @@
+DROP TABLE IF EXISTS
+    balance_mismatches,
+    reconciliation_runs,
+    coverage_checks,
+    custody_movements,
+    gold_bars,
+    daily_snapshots,
+    balance_adjustments,
+    wallet_events,
+    aml_case_comments,
+    aml_alerts,
+    aml_cases,
+    aml_rules,
+    transaction_limits,
+    kyc_documents,
+    kyc_submissions,
+    recovery_codes,
+    two_factors,
+    audit_logs,
+    roles,
+    refresh_tokens,
+    job_runs,
+    scheduled_jobs,
+    webhook_deliveries,
+    webhook_subscriptions,
+    gold_prices,
+    transactions,
+    wallets,
+    users;
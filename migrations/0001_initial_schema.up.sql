@@ -0,0 +1,446 @@
+-- Schema as previously created by GORM AutoMigrate. Every statement is
+-- guarded so that databases created by AutoMigrate can adopt migrations
+-- without being rebuilt.
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial,
+    full_name varchar(150) NOT NULL,
+    email text NOT NULL,
+    phone text NOT NULL,
+    password_hash text NOT NULL,
+    kyc_status varchar(20) DEFAULT 'pending',
+    role varchar(20) DEFAULT 'user',
+    email_verified_at timestamptz,
+    phone_verified_at timestamptz,
+    locked_until timestamptz,
+    deactivated_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone ON users (phone);
+
+CREATE TABLE IF NOT EXISTS wallets (
+    id bigserial,
+    user_id bigint NOT NULL,
+    fiat_balance numeric(14,2) DEFAULT 0,
+    gold_grams numeric(14,4) DEFAULT 0,
+    locked boolean DEFAULT false,
+    version bigint DEFAULT 1,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_users_wallet FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallets_user_id ON wallets (user_id);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id bigserial,
+    user_id bigint NOT NULL,
+    type varchar(20) NOT NULL,
+    amount numeric(14,2),
+    gold_grams numeric(14,4),
+    price_per_gram numeric(10,4),
+    status varchar(20) DEFAULT 'pending',
+    reference_id varchar(100),
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_transactions_user FOREIGN KEY (user_id) REFERENCES users (id)
+);
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions (user_id);
+
+CREATE TABLE IF NOT EXISTS gold_prices (
+    id bigserial,
+    price_per_gram numeric(10,4) NOT NULL,
+    source varchar(50),
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id bigserial,
+    name varchar(100) NOT NULL,
+    url varchar(500) NOT NULL,
+    events text NOT NULL,
+    secret varchar(128) NOT NULL,
+    active boolean NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial,
+    subscription_id bigint NOT NULL,
+    event_id varchar(64) NOT NULL,
+    event_type varchar(50) NOT NULL,
+    payload text NOT NULL,
+    status varchar(20) NOT NULL,
+    attempts bigint DEFAULT 0,
+    next_attempt_at timestamptz,
+    last_error varchar(500),
+    response_code bigint,
+    delivered_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries (event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_next_attempt_at ON webhook_deliveries (next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+    name varchar(100),
+    schedule varchar(100) NOT NULL,
+    catch_up varchar(20) NOT NULL,
+    paused boolean NOT NULL,
+    last_run_at timestamptz,
+    next_run_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (name)
+);
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    id bigserial,
+    job_name varchar(100) NOT NULL,
+    instance varchar(100),
+    trigger varchar(20),
+    scheduled_for timestamptz,
+    started_at timestamptz,
+    finished_at timestamptz,
+    status varchar(20) NOT NULL,
+    error varchar(1000),
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_name ON job_runs (job_name);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id bigserial,
+    user_id bigint NOT NULL,
+    family_id varchar(64) NOT NULL,
+    token_hash varchar(64) NOT NULL,
+    access_token_id varchar(64),
+    device_name varchar(100),
+    user_agent varchar(255),
+    ip_address varchar(45),
+    expires_at timestamptz NOT NULL,
+    rotated_at timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name varchar(50),
+    description varchar(255),
+    permissions text NOT NULL,
+    system boolean NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (name)
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id bigserial,
+    actor_id bigint,
+    action varchar(100) NOT NULL,
+    target_type varchar(50),
+    target_id varchar(100),
+    details text,
+    prev_hash varchar(64),
+    hash varchar(64) NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_target_id ON audit_logs (target_id);
+
+CREATE TABLE IF NOT EXISTS two_factors (
+    user_id bigserial,
+    encrypted_secret varchar(255) NOT NULL,
+    enabled_at timestamptz,
+    last_used_step bigint NOT NULL DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id bigserial,
+    user_id bigint NOT NULL,
+    code_hash varchar(64) NOT NULL,
+    used_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+    id bigserial,
+    user_id bigint NOT NULL,
+    citizenship_number varchar(30) NOT NULL,
+    date_of_birth date NOT NULL,
+    address varchar(300) NOT NULL,
+    status varchar(20) NOT NULL,
+    reason varchar(500),
+    reviewer_id bigint,
+    submitted_at timestamptz,
+    review_started_at timestamptz,
+    reviewed_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_reviewer_id ON kyc_submissions (reviewer_id);
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_status ON kyc_submissions (status);
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_user_id ON kyc_submissions (user_id);
+
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id bigserial,
+    submission_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    kind varchar(20) NOT NULL,
+    blob_key varchar(300) NOT NULL,
+    content_type varchar(50) NOT NULL,
+    size bigint NOT NULL,
+    sha256 varchar(64) NOT NULL,
+    purged_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_kyc_submissions_documents FOREIGN KEY (submission_id) REFERENCES kyc_submissions (id)
+);
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_submission_id ON kyc_documents (submission_id);
+CREATE INDEX IF NOT EXISTS idx_kyc_documents_user_id ON kyc_documents (user_id);
+
+CREATE TABLE IF NOT EXISTS transaction_limits (
+    id bigserial,
+    tier varchar(20) NOT NULL,
+    operation varchar(20) NOT NULL,
+    per_transaction_npr numeric(14,2),
+    daily_npr numeric(14,2),
+    monthly_npr numeric(14,2),
+    per_transaction_grams numeric(14,4),
+    daily_grams numeric(14,4),
+    monthly_grams numeric(14,4),
+    updated_by bigint,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_limit_tier_operation ON transaction_limits (tier, operation);
+
+CREATE TABLE IF NOT EXISTS aml_rules (
+    name varchar(50),
+    type varchar(20) NOT NULL,
+    description varchar(255),
+    enabled boolean NOT NULL DEFAULT true,
+    severity bigint NOT NULL,
+    transaction_types text,
+    params text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (name)
+);
+
+CREATE TABLE IF NOT EXISTS aml_cases (
+    id bigserial,
+    user_id bigint NOT NULL,
+    status varchar(20) NOT NULL,
+    severity bigint NOT NULL,
+    assignee_id bigint,
+    resolution varchar(30),
+    closed_by bigint,
+    closed_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_aml_cases_assignee_id ON aml_cases (assignee_id);
+CREATE INDEX IF NOT EXISTS idx_aml_cases_status ON aml_cases (status);
+CREATE INDEX IF NOT EXISTS idx_aml_cases_user_id ON aml_cases (user_id);
+
+CREATE TABLE IF NOT EXISTS aml_alerts (
+    id bigserial,
+    case_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    transaction_id bigint NOT NULL,
+    rule_name varchar(50) NOT NULL,
+    severity bigint NOT NULL,
+    details text,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_aml_cases_alerts FOREIGN KEY (case_id) REFERENCES aml_cases (id)
+);
+CREATE INDEX IF NOT EXISTS idx_aml_alerts_case_id ON aml_alerts (case_id);
+CREATE INDEX IF NOT EXISTS idx_aml_alerts_user_id ON aml_alerts (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_aml_alert_rule_transaction ON aml_alerts (transaction_id, rule_name);
+
+CREATE TABLE IF NOT EXISTS aml_case_comments (
+    id bigserial,
+    case_id bigint NOT NULL,
+    author_id bigint NOT NULL,
+    body text NOT NULL,
+    created_at timestamptz,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_aml_cases_comments FOREIGN KEY (case_id) REFERENCES aml_cases (id)
+);
+CREATE INDEX IF NOT EXISTS idx_aml_case_comments_case_id ON aml_case_comments (case_id);
+
+CREATE TABLE IF NOT EXISTS wallet_events (
+    id bigserial,
+    user_id bigint NOT NULL,
+    actor_id bigint,
+    type varchar(30) NOT NULL,
+    reason varchar(500),
+    adjustment_id bigint,
+    transaction_id bigint,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_wallet_events_actor_id ON wallet_events (actor_id);
+CREATE INDEX IF NOT EXISTS idx_wallet_events_created_at ON wallet_events (created_at);
+CREATE INDEX IF NOT EXISTS idx_wallet_events_user_id ON wallet_events (user_id);
+
+CREATE TABLE IF NOT EXISTS balance_adjustments (
+    id bigserial,
+    user_id bigint NOT NULL,
+    fiat_amount numeric(14,2) NOT NULL DEFAULT 0,
+    gold_grams numeric(14,4) NOT NULL DEFAULT 0,
+    reason varchar(500) NOT NULL,
+    status varchar(20) NOT NULL,
+    requested_by bigint NOT NULL,
+    reviewed_by bigint,
+    review_note varchar(500),
+    reviewed_at timestamptz,
+    transaction_id bigint,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_balance_adjustments_status ON balance_adjustments (status);
+CREATE INDEX IF NOT EXISTS idx_balance_adjustments_user_id ON balance_adjustments (user_id);
+
+CREATE TABLE IF NOT EXISTS daily_snapshots (
+    date date,
+    buy_count bigint NOT NULL DEFAULT 0,
+    buy_grams numeric(16,4) NOT NULL DEFAULT 0,
+    buy_npr numeric(16,2) NOT NULL DEFAULT 0,
+    sell_count bigint NOT NULL DEFAULT 0,
+    sell_grams numeric(16,4) NOT NULL DEFAULT 0,
+    sell_npr numeric(16,2) NOT NULL DEFAULT 0,
+    top_up_npr numeric(16,2) NOT NULL DEFAULT 0,
+    gold_liability_grams numeric(16,4) NOT NULL DEFAULT 0,
+    fiat_liability_npr numeric(16,2) NOT NULL DEFAULT 0,
+    new_signups bigint NOT NULL DEFAULT 0,
+    kyc_submitted bigint NOT NULL DEFAULT 0,
+    kyc_verified bigint NOT NULL DEFAULT 0,
+    kyc_rejected bigint NOT NULL DEFAULT 0,
+    active_savers bigint NOT NULL DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (date)
+);
+
+CREATE TABLE IF NOT EXISTS gold_bars (
+    id bigserial,
+    serial_number varchar(100) NOT NULL,
+    refiner varchar(100),
+    weight_grams numeric(14,4) NOT NULL,
+    purity numeric(6,5) NOT NULL,
+    fine_grams numeric(14,4) NOT NULL,
+    vault varchar(100) NOT NULL,
+    status varchar(20) NOT NULL,
+    received_at timestamptz,
+    withdrawn_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_gold_bars_status ON gold_bars (status);
+CREATE INDEX IF NOT EXISTS idx_gold_bars_vault ON gold_bars (vault);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gold_bars_serial_number ON gold_bars (serial_number);
+
+CREATE TABLE IF NOT EXISTS custody_movements (
+    id bigserial,
+    bar_id bigint NOT NULL,
+    direction varchar(20) NOT NULL,
+    from_vault varchar(100),
+    to_vault varchar(100),
+    reference varchar(100),
+    note varchar(500),
+    actor_id bigint,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_custody_movements_bar_id ON custody_movements (bar_id);
+CREATE INDEX IF NOT EXISTS idx_custody_movements_created_at ON custody_movements (created_at);
+
+CREATE TABLE IF NOT EXISTS coverage_checks (
+    id bigserial,
+    customer_grams numeric(16,4) NOT NULL,
+    vaulted_grams numeric(16,4) NOT NULL,
+    coverage decimal NOT NULL,
+    threshold decimal NOT NULL,
+    status varchar(20) NOT NULL,
+    checked_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_coverage_checks_checked_at ON coverage_checks (checked_at);
+
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id bigserial,
+    status varchar(20) NOT NULL,
+    wallets_checked bigint,
+    mismatches bigint,
+    error text,
+    started_at timestamptz,
+    finished_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_runs_started_at ON reconciliation_runs (started_at);
+
+CREATE TABLE IF NOT EXISTS balance_mismatches (
+    id bigserial,
+    run_id bigint NOT NULL,
+    user_id bigint NOT NULL,
+    wallet_fiat numeric(14,2),
+    expected_fiat numeric(14,2),
+    fiat_diff numeric(14,2),
+    wallet_gold numeric(14,4),
+    expected_gold numeric(14,4),
+    gold_diff numeric(14,4),
+    status varchar(20) NOT NULL,
+    resolved_by bigint,
+    transaction_id bigint,
+    resolved_at timestamptz,
+    created_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_balance_mismatches_run_id ON balance_mismatches (run_id);
+CREATE INDEX IF NOT EXISTS idx_balance_mismatches_status ON balance_mismatches (status);
+CREATE INDEX IF NOT EXISTS idx_balance_mismatches_user_id ON balance_mismatches (user_id);
+
+-- Existing rows must satisfy these; fix any that do not before migrating.
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS chk_wallets_fiat_balance_nonnegative;
+ALTER TABLE wallets ADD CONSTRAINT chk_wallets_fiat_balance_nonnegative CHECK (fiat_balance >= 0);
+ALTER TABLE wallets DROP CONSTRAINT IF EXISTS chk_wallets_gold_grams_nonnegative;
+ALTER TABLE wallets ADD CONSTRAINT chk_wallets_gold_grams_nonnegative CHECK (gold_grams >= 0);
+
+-- Adjustments are signed; every other transaction records positive amounts.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS chk_transactions_amounts_nonnegative;
+ALTER TABLE transactions ADD CONSTRAINT chk_transactions_amounts_nonnegative
+    CHECK (type = 'adjustment' OR (amount >= 0 AND gold_grams >= 0 AND price_per_gram >= 0));
+
+ALTER TABLE gold_prices DROP CONSTRAINT IF EXISTS chk_gold_prices_price_positive;
+ALTER TABLE gold_prices ADD CONSTRAINT chk_gold_prices_price_positive CHECK (price_per_gram > 0);
+
+ALTER TABLE gold_bars DROP CONSTRAINT IF EXISTS chk_gold_bars_weight_positive;
+ALTER TABLE gold_bars ADD CONSTRAINT chk_gold_bars_weight_positive CHECK (weight_grams > 0 AND fine_grams > 0);
+ALTER TABLE gold_bars DROP CONSTRAINT IF EXISTS chk_gold_bars_purity_range;
+ALTER TABLE gold_bars ADD CONSTRAINT chk_gold_bars_purity_range CHECK (purity > 0 AND purity <= 1);
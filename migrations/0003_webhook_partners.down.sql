@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_webhook_subscriptions_partner_id;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS partner_id;
+DROP INDEX IF EXISTS idx_users_partner_id;
+ALTER TABLE users DROP COLUMN IF EXISTS partner_id;
+DROP TABLE IF EXISTS partners;
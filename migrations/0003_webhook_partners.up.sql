@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS partners (
+    id bigserial,
+    name varchar(100) NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_partners_name ON partners (name);
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS partner_id bigint REFERENCES partners (id);
+CREATE INDEX IF NOT EXISTS idx_users_partner_id ON users (partner_id);
+
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS partner_id bigint NOT NULL REFERENCES partners (id);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_partner_id ON webhook_subscriptions (partner_id);
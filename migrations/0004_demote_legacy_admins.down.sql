@@ -0,0 +1,2 @@
+-- Demoted accounts cannot be told apart from ordinary users, so there is
+-- nothing to restore.
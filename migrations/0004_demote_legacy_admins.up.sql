@@ -0,0 +1,6 @@
+-- Registration used to accept role 'admin' from anyone. The permission
+-- bundle for platform operations is now called 'operations', and every
+-- remaining 'admin' account is demoted. Operators who need the bundle must
+-- be granted it again by a super admin.
+UPDATE users SET role = 'user' WHERE role = 'admin';
+DELETE FROM roles WHERE name = 'admin';
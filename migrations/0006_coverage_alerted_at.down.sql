@@ -0,0 +1 @@
+ALTER TABLE coverage_checks DROP COLUMN IF EXISTS alerted_at;
@@ -0,0 +1 @@
+ALTER TABLE coverage_checks ADD COLUMN IF NOT EXISTS alerted_at timestamptz;
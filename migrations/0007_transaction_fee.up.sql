@@ -0,0 +1 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee numeric(14,2) NOT NULL DEFAULT 0;
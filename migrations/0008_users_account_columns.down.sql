@@ -0,0 +1 @@
+-- On new databases these columns come from 0001, so they are left in place.
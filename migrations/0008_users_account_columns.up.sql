@@ -0,0 +1,6 @@
+-- Databases created by AutoMigrate before these columns existed already have
+-- the users table, so 0001 skipped creating them.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified_at timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS locked_until timestamptz;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deactivated_at timestamptz;
@@ -0,0 +1,9 @@
+package migrations
+
+import "embed"
+
+// FS holds the numbered SQL migrations compiled into the binary. Add new ones
+// with `gold_go migrate create <name>`.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AMLRuleThreshold   = "threshold"
+	AMLRuleVelocity    = "velocity"
+	AMLRuleStructuring = "structuring"
+	AMLRuleRoundTrip   = "round_trip"
+)
+
+const (
+	AMLCaseOpen          = "open"
+	AMLCaseInvestigating = "investigating"
+	AMLCaseClosed        = "closed"
+)
+
+// AMLRule is a monitoring rule. Params hold the numeric settings of the
+// rule type, for example min_amount for a threshold rule.
+type AMLRule struct {
+	Name             string             `gorm:"primaryKey;size:50" json:"name"`
+	Type             string             `gorm:"size:20;not null" json:"type"`
+	Description      string             `gorm:"size:255" json:"description"`
+	Enabled          bool               `gorm:"not null;default:true" json:"enabled"`
+	Severity         int                `gorm:"not null" json:"severity"`
+	TransactionTypes []string           `gorm:"type:text;serializer:json" json:"transaction_types"`
+	Params           map[string]float64 `gorm:"type:text;serializer:json;not null" json:"params"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+func (r *AMLRule) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *AMLRule) BeforeUpdate(tx *gorm.DB) error {
+	r.UpdatedAt = time.Now()
+	return nil
+}
+
+type AMLCase struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"user_id"`
+	Status     string     `gorm:"size:20;index;not null" json:"status"`
+	Severity   int        `gorm:"not null" json:"severity"`
+	AssigneeID *uint      `gorm:"index" json:"assignee_id,omitempty"`
+	Resolution string     `gorm:"size:30" json:"resolution,omitempty"`
+	ClosedBy   *uint      `json:"closed_by,omitempty"`
+	ClosedAt   *time.Time `json:"closed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Alerts   []AMLAlert       `gorm:"foreignKey:CaseID" json:"alerts,omitempty"`
+	Comments []AMLCaseComment `gorm:"foreignKey:CaseID" json:"comments,omitempty"`
+}
+
+func (c *AMLCase) BeforeCreate(tx *gorm.DB) error {
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+func (c *AMLCase) BeforeUpdate(tx *gorm.DB) error {
+	c.UpdatedAt = time.Now()
+	return nil
+}
+
+type AMLAlert struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	CaseID        uint      `gorm:"index;not null" json:"case_id"`
+	UserID        uint      `gorm:"index;not null" json:"user_id"`
+	TransactionID uint      `gorm:"not null;uniqueIndex:idx_aml_alert_rule_transaction" json:"transaction_id"`
+	RuleName      string    `gorm:"size:50;not null;uniqueIndex:idx_aml_alert_rule_transaction" json:"rule_name"`
+	Severity      int       `gorm:"not null" json:"severity"`
+	Details       string    `gorm:"type:text" json:"details"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (a *AMLAlert) BeforeCreate(tx *gorm.DB) error {
+	a.CreatedAt = time.Now()
+	return nil
+}
+
+type AMLCaseComment struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CaseID    uint      `gorm:"index;not null" json:"case_id"`
+	AuthorID  uint      `gorm:"not null" json:"author_id"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (c *AMLCaseComment) BeforeCreate(tx *gorm.DB) error {
+	c.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ActorID    uint      `gorm:"index" json:"actor_id"`
+	Action     string    `gorm:"size:100;index;not null" json:"action"`
+	TargetType string    `gorm:"size:50" json:"target_type"`
+	TargetID   string    `gorm:"size:100;index" json:"target_id"`
+	Details    string    `gorm:"type:text" json:"details"`
+	RequestID  string    `gorm:"size:64;index" json:"request_id,omitempty"`
+	PrevHash   string    `gorm:"size:64" json:"prev_hash"`
+	Hash       string    `gorm:"size:64;not null" json:"hash"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	GoldBarInVault   = "in_vault"
+	GoldBarWithdrawn = "withdrawn"
+)
+
+const (
+	CustodyInbound  = "inbound"
+	CustodyOutbound = "outbound"
+	CustodyTransfer = "transfer"
+)
+
+const (
+	CoverageOK             = "ok"
+	CoverageBelowThreshold = "below_threshold"
+)
+
+// GoldBar is one physical bar held for customers. FineGrams is the pure gold
+// content, WeightGrams x Purity, and is what backs customer balances.
+type GoldBar struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	SerialNumber string     `gorm:"size:100;uniqueIndex;not null" json:"serial_number"`
+	Refiner      string     `gorm:"size:100" json:"refiner"`
+	WeightGrams  float64    `gorm:"type:numeric(14,4);not null" json:"weight_grams"`
+	Purity       float64    `gorm:"type:numeric(6,5);not null" json:"purity"`
+	FineGrams    float64    `gorm:"type:numeric(14,4);not null" json:"fine_grams"`
+	Vault        string     `gorm:"size:100;index;not null" json:"vault"`
+	Status       string     `gorm:"size:20;index;not null" json:"status"`
+	ReceivedAt   time.Time  `json:"received_at"`
+	WithdrawnAt  *time.Time `json:"withdrawn_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (b *GoldBar) BeforeCreate(tx *gorm.DB) error {
+	b.CreatedAt = time.Now()
+	b.UpdatedAt = time.Now()
+	return nil
+}
+
+func (b *GoldBar) BeforeUpdate(tx *gorm.DB) error {
+	b.UpdatedAt = time.Now()
+	return nil
+}
+
+type CustodyMovement struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	BarID     uint      `gorm:"index;not null" json:"bar_id"`
+	Direction string    `gorm:"size:20;not null" json:"direction"`
+	FromVault string    `gorm:"size:100" json:"from_vault,omitempty"`
+	ToVault   string    `gorm:"size:100" json:"to_vault,omitempty"`
+	Reference string    `gorm:"size:100" json:"reference"`
+	Note      string    `gorm:"size:500" json:"note"`
+	ActorID   uint      `json:"actor_id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (m *CustodyMovement) BeforeCreate(tx *gorm.DB) error {
+	m.CreatedAt = time.Now()
+	return nil
+}
+
+// CoverageCheck records one comparison of vaulted fine gold against the
+// gold customers hold in their wallets.
+type CoverageCheck struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CustomerGrams float64    `gorm:"type:numeric(16,4);not null" json:"customer_grams"`
+	VaultedGrams  float64    `gorm:"type:numeric(16,4);not null" json:"vaulted_grams"`
+	Coverage      float64    `gorm:"not null" json:"coverage"`
+	Threshold     float64    `gorm:"not null" json:"threshold"`
+	Status        string     `gorm:"size:20;not null" json:"status"`
+	CheckedAt     time.Time  `gorm:"index" json:"checked_at"`
+	AlertedAt     *time.Time `json:"alerted_at,omitempty"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,7 +14,16 @@ type GoldPrice struct {
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// RoundPrice rounds a price per gram to the 4 decimals it is stored with, so
+// a price read back from the database equals the one that was cached.
+func RoundPrice(price float64) float64 {
+	return math.Round(price*10000) / 10000
+}
+
 func (g *GoldPrice) BeforeCreate(tx *gorm.DB) error {
-	g.UpdatedAt = time.Now()
+	g.PricePerGram = RoundPrice(g.PricePerGram)
+	if g.UpdatedAt.IsZero() {
+		g.UpdatedAt = time.Now()
+	}
 	return nil
 }
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	KYCStatusPending     = "pending"
+	KYCStatusSubmitted   = "submitted"
+	KYCStatusUnderReview = "under_review"
+	KYCStatusVerified    = "verified"
+	KYCStatusRejected    = "rejected"
+)
+
+type KYCDocumentKind string
+
+const (
+	KYCDocumentFront  KYCDocumentKind = "document_front"
+	KYCDocumentBack   KYCDocumentKind = "document_back"
+	KYCDocumentSelfie KYCDocumentKind = "selfie"
+)
+
+var KYCDocumentKinds = []KYCDocumentKind{
+	KYCDocumentFront,
+	KYCDocumentBack,
+	KYCDocumentSelfie,
+}
+
+type KYCSubmission struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	UserID            uint       `gorm:"index;not null" json:"user_id"`
+	CitizenshipNumber string     `gorm:"size:30;not null" json:"citizenship_number"`
+	DateOfBirth       time.Time  `gorm:"type:date;not null" json:"date_of_birth"`
+	Address           string     `gorm:"size:300;not null" json:"address"`
+	Status            string     `gorm:"size:20;index;not null" json:"status"`
+	Reason            string     `gorm:"size:500" json:"reason,omitempty"`
+	ReviewerID        *uint      `gorm:"index" json:"reviewer_id,omitempty"`
+	SubmittedAt       time.Time  `json:"submitted_at"`
+	ReviewStartedAt   *time.Time `json:"review_started_at,omitempty"`
+	ReviewedAt        *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	Documents []KYCDocument `gorm:"foreignKey:SubmissionID" json:"documents,omitempty"`
+}
+
+func (k *KYCSubmission) BeforeCreate(tx *gorm.DB) error {
+	k.CreatedAt = time.Now()
+	k.UpdatedAt = time.Now()
+	return nil
+}
+
+func (k *KYCSubmission) BeforeUpdate(tx *gorm.DB) error {
+	k.UpdatedAt = time.Now()
+	return nil
+}
+
+type KYCDocument struct {
+	ID           uint            `gorm:"primaryKey" json:"id"`
+	SubmissionID uint            `gorm:"index;not null" json:"submission_id"`
+	UserID       uint            `gorm:"index;not null" json:"user_id"`
+	Kind         KYCDocumentKind `gorm:"size:20;not null" json:"kind"`
+	BlobKey      string          `gorm:"size:300;not null" json:"-"`
+	ContentType  string          `gorm:"size:50;not null" json:"content_type"`
+	Size         int64           `gorm:"not null" json:"size"`
+	SHA256       string          `gorm:"size:64;not null" json:"sha256"`
+	PurgedAt     *time.Time      `json:"purged_at,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+func (d *KYCDocument) BeforeCreate(tx *gorm.DB) error {
+	d.CreatedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TransactionLimit caps one operation for one KYC tier. A nil cap means no
+// limit; a zero cap blocks the operation.
+type TransactionLimit struct {
+	ID                  uint      `gorm:"primaryKey" json:"id"`
+	Tier                string    `gorm:"size:20;not null;uniqueIndex:idx_limit_tier_operation" json:"tier"`
+	Operation           string    `gorm:"size:20;not null;uniqueIndex:idx_limit_tier_operation" json:"operation"`
+	PerTransactionNPR   *float64  `gorm:"type:numeric(14,2)" json:"per_transaction_npr"`
+	DailyNPR            *float64  `gorm:"type:numeric(14,2)" json:"daily_npr"`
+	MonthlyNPR          *float64  `gorm:"type:numeric(14,2)" json:"monthly_npr"`
+	PerTransactionGrams *float64  `gorm:"type:numeric(14,4)" json:"per_transaction_grams"`
+	DailyGrams          *float64  `gorm:"type:numeric(14,4)" json:"daily_grams"`
+	MonthlyGrams        *float64  `gorm:"type:numeric(14,4)" json:"monthly_grams"`
+	UpdatedBy           *uint     `json:"updated_by,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+func (l *TransactionLimit) BeforeCreate(tx *gorm.DB) error {
+	l.CreatedAt = time.Now()
+	l.UpdatedAt = time.Now()
+	return nil
+}
+
+func (l *TransactionLimit) BeforeUpdate(tx *gorm.DB) error {
+	l.UpdatedAt = time.Now()
+	return nil
+}
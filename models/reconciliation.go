@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	ReconciliationRunning   = "running"
+	ReconciliationCompleted = "completed"
+	ReconciliationFailed    = "failed"
+)
+
+const (
+	MismatchOpen     = "open"
+	MismatchResolved = "resolved"
+)
+
+type ReconciliationRun struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Status         string     `gorm:"size:20;not null" json:"status"`
+	WalletsChecked int64      `json:"wallets_checked"`
+	Mismatches     int64      `json:"mismatches"`
+	Error          string     `gorm:"type:text" json:"error,omitempty"`
+	StartedAt      time.Time  `gorm:"index" json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// BalanceMismatch is a wallet whose balances differ from the sum of its
+// successful transactions. A positive difference means the wallet holds more
+// than its history explains.
+type BalanceMismatch struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	RunID         uint       `gorm:"index;not null" json:"run_id"`
+	UserID        uint       `gorm:"index;not null" json:"user_id"`
+	WalletFiat    float64    `gorm:"type:numeric(14,2)" json:"wallet_fiat"`
+	ExpectedFiat  float64    `gorm:"type:numeric(14,2)" json:"expected_fiat"`
+	FiatDiff      float64    `gorm:"type:numeric(14,2)" json:"fiat_diff"`
+	WalletGold    float64    `gorm:"type:numeric(14,4)" json:"wallet_gold"`
+	ExpectedGold  float64    `gorm:"type:numeric(14,4)" json:"expected_gold"`
+	GoldDiff      float64    `gorm:"type:numeric(14,4)" json:"gold_diff"`
+	Status        string     `gorm:"size:20;index;not null" json:"status"`
+	ResolvedBy    *uint      `json:"resolved_by,omitempty"`
+	TransactionID *uint      `json:"transaction_id,omitempty"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RefreshToken struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID      string     `gorm:"size:64;index;not null" json:"family_id"`
+	TokenHash     string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	AccessTokenID string     `gorm:"size:64" json:"-"`
+	DeviceName    string     `gorm:"size:100" json:"device_name"`
+	UserAgent     string     `gorm:"size:255" json:"user_agent"`
+	IPAddress     string     `gorm:"size:45" json:"ip_address"`
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	RotatedAt     *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	t.CreatedAt = time.Now()
+	return nil
+}
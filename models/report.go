@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DailySnapshot holds the business figures of one calendar day in the
+// reporting timezone. Liabilities and active savers are as of the end of
+// the day.
+type DailySnapshot struct {
+	Date               time.Time `gorm:"primaryKey;type:date" json:"date"`
+	BuyCount           int64     `gorm:"not null;default:0" json:"buy_count"`
+	BuyGrams           float64   `gorm:"type:numeric(16,4);not null;default:0" json:"buy_grams"`
+	BuyNPR             float64   `gorm:"type:numeric(16,2);not null;default:0" json:"buy_npr"`
+	SellCount          int64     `gorm:"not null;default:0" json:"sell_count"`
+	SellGrams          float64   `gorm:"type:numeric(16,4);not null;default:0" json:"sell_grams"`
+	SellNPR            float64   `gorm:"type:numeric(16,2);not null;default:0" json:"sell_npr"`
+	TopUpNPR           float64   `gorm:"type:numeric(16,2);not null;default:0" json:"topup_npr"`
+	GoldLiabilityGrams float64   `gorm:"type:numeric(16,4);not null;default:0" json:"gold_liability_grams"`
+	FiatLiabilityNPR   float64   `gorm:"type:numeric(16,2);not null;default:0" json:"fiat_liability_npr"`
+	NewSignups         int64     `gorm:"not null;default:0" json:"new_signups"`
+	KYCSubmitted       int64     `gorm:"not null;default:0" json:"kyc_submitted"`
+	KYCVerified        int64     `gorm:"not null;default:0" json:"kyc_verified"`
+	KYCRejected        int64     `gorm:"not null;default:0" json:"kyc_rejected"`
+	ActiveSavers       int64     `gorm:"not null;default:0" json:"active_savers"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func (s *DailySnapshot) BeforeCreate(tx *gorm.DB) error {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = time.Now()
+	return nil
+}
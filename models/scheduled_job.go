@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ScheduledJob struct {
+	Name      string     `gorm:"primaryKey;size:100" json:"name"`
+	Schedule  string     `gorm:"size:100;not null" json:"schedule"`
+	CatchUp   string     `gorm:"size:20;not null" json:"catch_up"`
+	Paused    bool       `gorm:"not null" json:"paused"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (j *ScheduledJob) BeforeSave(tx *gorm.DB) error {
+	j.UpdatedAt = time.Now()
+	return nil
+}
+
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "running"
+	JobRunSucceeded JobRunStatus = "succeeded"
+	JobRunFailed    JobRunStatus = "failed"
+	JobRunSkipped   JobRunStatus = "skipped"
+)
+
+type JobRun struct {
+	ID           uint         `gorm:"primaryKey" json:"id"`
+	JobName      string       `gorm:"size:100;index;not null" json:"job_name"`
+	Instance     string       `gorm:"size:100" json:"instance"`
+	Trigger      string       `gorm:"size:20" json:"trigger"`
+	ScheduledFor time.Time    `json:"scheduled_for"`
+	StartedAt    time.Time    `json:"started_at"`
+	FinishedAt   *time.Time   `json:"finished_at,omitempty"`
+	Status       JobRunStatus `gorm:"size:20;not null" json:"status"`
+	Error        string       `gorm:"size:1000" json:"error,omitempty"`
+}
@@ -13,6 +13,10 @@ const (
 	TransactionTypeSell   TransactionType = "sell"
 	TransactionTypeTopUp  TransactionType = "topup"
 	TransactionTypeRefund TransactionType = "refund"
+
+	// TransactionTypeAdjustment is a manual correction; Amount and GoldGrams
+	// are signed.
+	TransactionTypeAdjustment TransactionType = "adjustment"
 )
 
 type TransactionStatus string
@@ -28,6 +32,7 @@ type Transaction struct {
 	UserID       uint              `gorm:"index;not null" json:"user_id"`
 	Type         TransactionType   `gorm:"size:20;not null" json:"type"`
 	Amount       float64           `gorm:"type:numeric(14,2)" json:"amount"`
+	Fee          float64           `gorm:"type:numeric(14,2);not null;default:0" json:"fee"`
 	GoldGrams    float64           `gorm:"type:numeric(14,4)" json:"gold_grams"`
 	PricePerGram float64           `gorm:"type:numeric(10,4)" json:"price_per_gram"`
 	Status       TransactionStatus `gorm:"size:20;default:pending" json:"status"`
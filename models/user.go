@@ -7,15 +7,22 @@ import (
 )
 
 type User struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	FullName     string    `gorm:"size:150;not null" json:"full_name"`
-	Email        string    `gorm:"uniqueIndex;not null" json:"email"`
-	Phone        string    `gorm:"uniqueIndex;not null" json:"phone"`
-	PasswordHash string    `gorm:"not null" json:"-"`
-	KYCStatus    string    `gorm:"size:20;default:pending" json:"kyc_status"`
-	Role         string    `gorm:"size:20;default:user" json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	FullName     string `gorm:"size:150;not null" json:"full_name"`
+	Email        string `gorm:"uniqueIndex;not null" json:"email"`
+	Phone        string `gorm:"uniqueIndex;not null" json:"phone"`
+	PasswordHash string `gorm:"not null" json:"-"`
+	KYCStatus    string `gorm:"size:20;default:pending" json:"kyc_status"`
+	Role         string `gorm:"size:20;default:user" json:"role"`
+	PartnerID    *uint  `gorm:"index" json:"partner_id,omitempty"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
+	LockedUntil     *time.Time `json:"locked_until,omitempty"`
+	DeactivatedAt   *time.Time `json:"deactivated_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 
 	Wallet *Wallet `gorm:"foreignKey:UserID" json:"wallet,omitempty"`
 }
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WalletEventFreeze             = "freeze"
+	WalletEventUnfreeze           = "unfreeze"
+	WalletEventAdjustmentRequest  = "adjustment_requested"
+	WalletEventAdjustmentApproved = "adjustment_approved"
+	WalletEventAdjustmentRejected = "adjustment_rejected"
+)
+
+const (
+	AdjustmentPending  = "pending"
+	AdjustmentApproved = "approved"
+	AdjustmentRejected = "rejected"
+)
+
+// WalletEvent is one administrative action on a wallet. ActorID is 0 for
+// actions taken by the system, such as an AML auto-freeze.
+type WalletEvent struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	UserID        uint      `gorm:"index;not null" json:"user_id"`
+	ActorID       uint      `gorm:"index" json:"actor_id"`
+	Type          string    `gorm:"size:30;not null" json:"type"`
+	Reason        string    `gorm:"size:500" json:"reason"`
+	AdjustmentID  *uint     `json:"adjustment_id,omitempty"`
+	TransactionID *uint     `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+}
+
+func (e *WalletEvent) BeforeCreate(tx *gorm.DB) error {
+	e.CreatedAt = time.Now()
+	return nil
+}
+
+// BalanceAdjustment is a manual credit or debit. Amounts are signed; it only
+// touches the wallet once a second administrator approves it.
+type BalanceAdjustment struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"index;not null" json:"user_id"`
+	FiatAmount    float64    `gorm:"type:numeric(14,2);not null;default:0" json:"fiat_amount"`
+	GoldGrams     float64    `gorm:"type:numeric(14,4);not null;default:0" json:"gold_grams"`
+	Reason        string     `gorm:"size:500;not null" json:"reason"`
+	Status        string     `gorm:"size:20;index;not null" json:"status"`
+	RequestedBy   uint       `gorm:"not null" json:"requested_by"`
+	ReviewedBy    *uint      `json:"reviewed_by,omitempty"`
+	ReviewNote    string     `gorm:"size:500" json:"review_note,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	TransactionID *uint      `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (a *BalanceAdjustment) BeforeCreate(tx *gorm.DB) error {
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = time.Now()
+	return nil
+}
+
+func (a *BalanceAdjustment) BeforeUpdate(tx *gorm.DB) error {
+	a.UpdatedAt = time.Now()
+	return nil
+}
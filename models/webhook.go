@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookEventTopUp      = "transaction.topup"
+	WebhookEventBuy        = "transaction.buy"
+	WebhookEventSell       = "transaction.sell"
+	WebhookEventAdjustment = "transaction.adjustment"
+)
+
+var WebhookEvents = []string{
+	WebhookEventTopUp,
+	WebhookEventBuy,
+	WebhookEventSell,
+	WebhookEventAdjustment,
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryRetrying  WebhookDeliveryStatus = "retrying"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"
+	WebhookDeliveryCancelled WebhookDeliveryStatus = "cancelled"
+)
+
+// Partner is a B2B customer, such as a jeweller or an employer running a
+// savings scheme. Its webhook subscriptions only receive events about the
+// users assigned to it.
+type Partner struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:100;uniqueIndex;not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (p *Partner) BeforeCreate(tx *gorm.DB) error {
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+type WebhookSubscription struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PartnerID uint      `gorm:"index;not null" json:"partner_id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	URL       string    `gorm:"size:500;not null" json:"url"`
+	Events    []string  `gorm:"type:text;serializer:json;not null" json:"events"`
+	Secret    string    `gorm:"size:128;not null" json:"-"`
+	Active    bool      `gorm:"not null" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (s *WebhookSubscription) BeforeCreate(tx *gorm.DB) error {
+	s.CreatedAt = time.Now()
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *WebhookSubscription) BeforeUpdate(tx *gorm.DB) error {
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, event := range s.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDelivery struct {
+	ID             uint                  `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint                  `gorm:"index;not null" json:"subscription_id"`
+	EventID        string                `gorm:"size:64;index;not null" json:"event_id"`
+	EventType      string                `gorm:"size:50;not null" json:"event_type"`
+	Payload        string                `gorm:"type:text;not null" json:"payload"`
+	Status         WebhookDeliveryStatus `gorm:"size:20;index;not null" json:"status"`
+	Attempts       int                   `gorm:"default:0" json:"attempts"`
+	NextAttemptAt  time.Time             `gorm:"index" json:"next_attempt_at"`
+	LastError      string                `gorm:"size:500" json:"last_error,omitempty"`
+	ResponseCode   int                   `json:"response_code,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = time.Now()
+	return nil
+}
+
+func (d *WebhookDelivery) BeforeUpdate(tx *gorm.DB) error {
+	d.UpdatedAt = time.Now()
+	return nil
+}
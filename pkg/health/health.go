@@ -0,0 +1,116 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Check is one dependency. Run returns a short detail for the report; it is
+// abandoned when Timeout passes even if it ignores its context.
+type Check struct {
+	Name    string
+	Timeout time.Duration
+	Run     func(ctx context.Context) (string, error)
+}
+
+type Result struct {
+	Status     string `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+type Report struct {
+	Status     string            `json:"status"`
+	Components map[string]Result `json:"components"`
+	CheckedAt  time.Time         `json:"checked_at"`
+}
+
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs every check in parallel and reuses the report for ttl, so
+// frequent probes from several load balancers do not hammer the
+// dependencies.
+type Checker struct {
+	checks []Check
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	cached *Report
+}
+
+func NewChecker(ttl time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, ttl: ttl}
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cached != nil && time.Since(c.cached.CheckedAt) < c.ttl {
+		return *c.cached
+	}
+
+	// The report is shared, so a probe that hangs up must not fail it.
+	ctx = context.WithoutCancel(ctx)
+
+	report := Report{Status: StatusOK, Components: make(map[string]Result, len(c.checks)), CheckedAt: time.Now()}
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for i, check := range c.checks {
+		report.Components[check.Name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	c.cached = &report
+	return report
+}
+
+func run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	type outcome struct {
+		detail string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	started := time.Now()
+	go func() {
+		detail, err := check.Run(ctx)
+		done <- outcome{detail, err}
+	}()
+
+	var result outcome
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result.err = fmt.Errorf("timed out after %s", check.Timeout)
+	}
+
+	status := Result{Status: StatusOK, Detail: result.detail, DurationMS: time.Since(started).Milliseconds()}
+	if result.err != nil {
+		status.Status = StatusFail
+		status.Error = result.err.Error()
+	}
+	return status
+}
@@ -0,0 +1,52 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type hook struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Manager stops the parts of the process in the order they were registered,
+// all within the deadline of the context given to Shutdown.
+type Manager struct {
+	mu    sync.Mutex
+	hooks []hook
+}
+
+func New() *Manager {
+	return &Manager{}
+}
+
+func (m *Manager) OnStop(name string, stop func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, stop: stop})
+}
+
+// Shutdown runs every hook even after one fails or the deadline passes, so
+// connections are still closed; the failures are joined.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	var errs []error
+	for _, h := range hooks {
+		started := time.Now()
+		if err := h.stop(ctx); err != nil {
+			slog.ErrorContext(ctx, "lifecycle: stop failed", "component", h.name, "elapsed", time.Since(started).Round(time.Millisecond), "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		slog.InfoContext(ctx, "lifecycle: stopped", "component", h.name, "elapsed", time.Since(started).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,139 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/919Umesh/gold_go/pkg/utils"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute and field names whose values never reach the
+// log, whatever their type. Keys are compared lower-cased.
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"new_password":  true,
+	"password_hash": true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"authorization": true,
+	"secret":        true,
+	"otp":           true,
+	"code":          true,
+	"recovery_code": true,
+	"balance":       true,
+	"fiat_balance":  true,
+	"gold_grams":    true,
+	"amount":        true,
+	"fee":           true,
+}
+
+// sensitiveSuffixes catch the variants of those keys, such as mfa_token or
+// amount_npr.
+var sensitiveSuffixes = []string{"_password", "_token", "_secret", "_balance", "_amount", "amount_npr"}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+?\b(?:977[- ]?)?9[678]\d{8}\b`)
+	jwtPattern   = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+)
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	if sensitiveKeys[key] {
+		return true
+	}
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact is the ReplaceAttr hook. Sensitive keys are dropped to a
+// placeholder; in every other string, the message included, emails and
+// phone numbers are masked and tokens removed. The last part catches the
+// log.Printf calls that go through slog. Errors are logged as their scrubbed
+// message, and structs, maps and slices are redacted field by field.
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if sensitive(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+	switch attr.Key {
+	case "email":
+		return slog.String(attr.Key, utils.MaskEmail(attr.Value.String()))
+	case "phone":
+		return slog.String(attr.Key, utils.MaskTail(attr.Value.String(), 3))
+	}
+
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Scrub(value.String()))
+	case slog.KindAny:
+		return slog.Any(attr.Key, redactAny(value.Any()))
+	}
+	return attr
+}
+
+// redactAny turns v into its JSON form, so the field names the log would
+// show are the ones checked, and redacts that.
+func redactAny(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return Scrub(v.Error())
+	case []byte:
+		return Scrub(string(v))
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return Scrub(fmt.Sprint(v))
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return Scrub(string(encoded))
+	}
+	return redactJSON(decoded)
+}
+
+func redactJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			switch {
+			case sensitive(key):
+				v[key] = redacted
+			case key == "email":
+				v[key] = utils.MaskEmail(fmt.Sprint(field))
+			case key == "phone":
+				v[key] = utils.MaskTail(fmt.Sprint(field), 3)
+			default:
+				v[key] = redactJSON(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSON(v[i])
+		}
+	case string:
+		return Scrub(v)
+	}
+	return v
+}
+
+// Scrub masks emails and phone numbers and removes bearer tokens from free
+// text.
+func Scrub(text string) string {
+	text = jwtPattern.ReplaceAllString(text, redacted)
+	text = emailPattern.ReplaceAllStringFunc(text, utils.MaskEmail)
+	return phonePattern.ReplaceAllStringFunc(text, func(phone string) string {
+		return utils.MaskTail(phone, 3)
+	})
+}
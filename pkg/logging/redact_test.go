@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// record logs one message through a JSON logger built by New and returns
+// the decoded record next to the raw output.
+func record(t *testing.T, msg string, args ...any) (map[string]any, string) {
+	t.Helper()
+	var out bytes.Buffer
+	logger, err := New(&out, "debug", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info(msg, args...)
+
+	var decoded map[string]any
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("log output %q: %v", out.String(), err)
+	}
+	return decoded, out.String()
+}
+
+func TestRedactDropsSensitiveKeysWhateverTheirType(t *testing.T) {
+	got, raw := record(t, "trade",
+		"password", 12345,
+		"amount", 2500.5,
+		"amount_npr", 99.25,
+		"fiat_balance", 10000,
+		"mfa_token", []byte("eyJabc.def.ghi"),
+		"Refresh_Token", "opaque",
+		slog.Group("request", "code", 654321, "secret", true),
+		"user_id", 7,
+	)
+
+	for _, key := range []string{"password", "amount", "amount_npr", "fiat_balance", "mfa_token", "Refresh_Token"} {
+		if got[key] != redacted {
+			t.Errorf("%s = %v, want it redacted", key, got[key])
+		}
+	}
+	request, _ := got["request"].(map[string]any)
+	if request["code"] != redacted || request["secret"] != redacted {
+		t.Errorf("request group = %v, want code and secret redacted", request)
+	}
+	if got["user_id"] != float64(7) {
+		t.Errorf("user_id = %v, want 7 kept", got["user_id"])
+	}
+	for _, leaked := range []string{"12345", "2500.5", "654321", "eyJabc"} {
+		if strings.Contains(raw, leaked) {
+			t.Errorf("log output %s contains %q", raw, leaked)
+		}
+	}
+}
+
+func TestRedactWalksStructsMapsAndErrors(t *testing.T) {
+	type wallet struct {
+		UserID      uint    `json:"user_id"`
+		Email       string  `json:"email"`
+		FiatBalance float64 `json:"fiat_balance"`
+		Note        string  `json:"note"`
+	}
+	got, raw := record(t, "call +9779812345678 about it",
+		"wallet", wallet{UserID: 3, Email: "ram@example.com", FiatBalance: 5000, Note: "from 9812345678"},
+		"transactions", []map[string]any{{"id": 1, "amount": 250, "fee": 2.5}},
+		"error", errors.New("sending to sita@example.com failed"),
+	)
+
+	w, _ := got["wallet"].(map[string]any)
+	if w["user_id"] != float64(3) || w["fiat_balance"] != redacted || w["email"] != "r***@example.com" || w["note"] != "from *******678" {
+		t.Errorf("wallet = %v, want the balance redacted and contact details masked", w)
+	}
+	transactions, _ := got["transactions"].([]any)
+	if len(transactions) != 1 {
+		t.Fatalf("transactions = %v", got["transactions"])
+	}
+	if tx := transactions[0].(map[string]any); tx["amount"] != redacted || tx["fee"] != redacted || tx["id"] != float64(1) {
+		t.Errorf("transaction = %v, want amount and fee redacted", tx)
+	}
+	if got["error"] != "sending to s***@example.com failed" {
+		t.Errorf("error = %v, want the email masked", got["error"])
+	}
+	for _, leaked := range []string{"ram@", "sita@", "9812345678", "5000"} {
+		if strings.Contains(raw, leaked) {
+			t.Errorf("log output %s contains %q", raw, leaked)
+		}
+	}
+}
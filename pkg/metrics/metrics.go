@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gold"
+
+// Registry holds every metric the service exposes. It is separate from the
+// client library's global registry so nothing registers into it by accident.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	RateLimitRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Requests rejected by the rate limiter, by route template.",
+	}, []string{"route"})
+
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_cache_requests_total",
+		Help:      "Response cache lookups, by route template and result (hit or miss).",
+	}, []string{"route", "result"})
+
+	WalletOperations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wallet_operations_total",
+		Help:      "Wallet operations attempted, by operation and result.",
+	}, []string{"operation", "result"})
+
+	WalletFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wallet_operation_failures_total",
+		Help:      "Failed wallet operations, by operation and reason.",
+	}, []string{"operation", "reason"})
+
+	WalletVolumeNPR = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wallet_volume_npr_total",
+		Help:      "NPR moved by successful wallet operations.",
+	}, []string{"operation"})
+
+	WalletVolumeGrams = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "wallet_volume_grams_total",
+		Help:      "Gold grams moved by successful wallet operations.",
+	}, []string{"operation"})
+
+	PriceFetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "price_fetch_duration_seconds",
+		Help:      "Gold price fetch latency by provider.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"provider"})
+
+	PriceFetchErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "price_fetch_errors_total",
+		Help:      "Failed gold price fetches by provider.",
+	}, []string{"provider"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		RateLimitRejections,
+		CacheRequests,
+		WalletOperations,
+		WalletFailures,
+		WalletVolumeNPR,
+		WalletVolumeGrams,
+		PriceFetchDuration,
+		PriceFetchErrors,
+	)
+}
+
+// RegisterDB exposes the connection pool stats of db. Call it once per pool.
+func RegisterDB(name string, db *sql.DB) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
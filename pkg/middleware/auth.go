@@ -5,11 +5,12 @@ import (
 	"strings"
 
 	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/pkg/tokenstore"
 	"github.com/919Umesh/gold_go/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
 
-func JWTAuth(cfg *config.Config) gin.HandlerFunc {
+func JWTAuth(cfg *config.Config, denylist *tokenstore.Denylist) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,14 +26,27 @@ func JWTAuth(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		claims, err := utils.ParseToken(tokenString, cfg.JWTSecret)
-		if err != nil {
+		claims, err := utils.ParseToken(tokenString, cfg.Auth.JWTSecret)
+		if err != nil || claims.Purpose != "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
 
+		revoked, err := denylist.IsRevoked(c.Request.Context(), claims)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			c.Abort()
+			return
+		}
+		if revoked {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			c.Abort()
+			return
+		}
+
 		c.Set("user_id", claims.UserID)
+		c.Set("claims", claims)
 		c.Next()
 	}
 }
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/919Umesh/gold_go/pkg/metrics"
 	"github.com/919Umesh/gold_go/pkg/redis"
 	"github.com/gin-gonic/gin"
 )
@@ -32,12 +33,14 @@ func (cm *CacheMiddleware) Cache(duration time.Duration) gin.HandlerFunc {
 
 		cached, err := cm.redisClient.Get(ctx.Request.Context(), cacheKey)
 		if err == nil && cached != "" {
+			metrics.CacheRequests.WithLabelValues(routeLabel(ctx), "hit").Inc()
 			ctx.Header("X-Cache", "HIT")
 			ctx.Data(200, "application/json", []byte(cached))
 			ctx.Abort()
 			return
 		}
 
+		metrics.CacheRequests.WithLabelValues(routeLabel(ctx), "miss").Inc()
 		ctx.Header("X-Cache", "MISS")
 		blw := &bodyLogWriter{body: []byte{}, ResponseWriter: ctx.Writer}
 		ctx.Writer = blw
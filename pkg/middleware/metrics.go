@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/pkg/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records request latency by route template rather than raw path,
+// so IDs in URLs don't explode the number of series.
+func Metrics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		metrics.HTTPRequestDuration.
+			WithLabelValues(ctx.Request.Method, routeLabel(ctx), strconv.Itoa(ctx.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+func routeLabel(ctx *gin.Context) string {
+	if route := ctx.FullPath(); route != "" {
+		return route
+	}
+	return "unmatched"
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/pkg/metrics"
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var requestCountDesc = prometheus.NewDesc("gold_http_requests_observed", "Observations per series of gold_http_request_duration_seconds.", []string{"method", "route", "status"}, nil)
+
+// requestCounts exposes how many requests each latency series observed, so
+// the labels can be compared without the latencies, which vary by run.
+type requestCounts struct{}
+
+func (requestCounts) Describe(ch chan<- *prometheus.Desc) {
+	ch <- requestCountDesc
+}
+
+func (requestCounts) Collect(ch chan<- prometheus.Metric) {
+	series := make(chan prometheus.Metric)
+	go func() {
+		metrics.HTTPRequestDuration.Collect(series)
+		close(series)
+	}()
+	for metric := range series {
+		var m dto.Metric
+		if err := metric.Write(&m); err != nil {
+			panic(err)
+		}
+		values := map[string]string{}
+		for _, label := range m.GetLabel() {
+			values[label.GetName()] = label.GetValue()
+		}
+		ch <- prometheus.MustNewConstMetric(requestCountDesc, prometheus.CounterValue, float64(m.GetHistogram().GetSampleCount()),
+			values["method"], values["route"], values["status"])
+	}
+}
+
+func serve(engine *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+func TestMetricsLabelRequestsByRouteTemplate(t *testing.T) {
+	metrics.HTTPRequestDuration.Reset()
+	engine := gin.New()
+	engine.Use(Metrics())
+	engine.GET("/api/v1/admin/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	engine.POST("/api/v1/admin/users/:id/unlock", func(c *gin.Context) { c.Status(http.StatusForbidden) })
+
+	serve(engine, http.MethodGet, "/api/v1/admin/users/41")
+	serve(engine, http.MethodGet, "/api/v1/admin/users/42")
+	serve(engine, http.MethodPost, "/api/v1/admin/users/42/unlock")
+	serve(engine, http.MethodGet, "/api/v1/admin/users/42/secrets")
+
+	expected := `
+# HELP gold_http_requests_observed Observations per series of gold_http_request_duration_seconds.
+# TYPE gold_http_requests_observed counter
+gold_http_requests_observed{method="GET",route="/api/v1/admin/users/:id",status="200"} 2
+gold_http_requests_observed{method="GET",route="unmatched",status="404"} 1
+gold_http_requests_observed{method="POST",route="/api/v1/admin/users/:id/unlock",status="403"} 1
+`
+	if err := testutil.CollectAndCompare(requestCounts{}, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRateLimitCountsRejectionsByRouteTemplate(t *testing.T) {
+	metrics.RateLimitRejections.Reset()
+	server := miniredis.RunT(t)
+	limiter := NewRateLimiter(redis.NewRedisClient(server.Addr(), "", 0))
+	engine := gin.New()
+	engine.POST("/api/v1/auth/register", limiter.RateLimit(), func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	for i := 0; i < 5; i++ {
+		serve(engine, http.MethodPost, "/api/v1/auth/register")
+	}
+
+	// register allows 3 requests an hour.
+	expected := `
+# HELP gold_rate_limit_rejections_total Requests rejected by the rate limiter, by route template.
+# TYPE gold_rate_limit_rejections_total counter
+gold_rate_limit_rejections_total{route="/api/v1/auth/register"} 2
+`
+	if err := testutil.CollectAndCompare(metrics.RateLimitRejections, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheCountsHitsAndMissesByRouteTemplate(t *testing.T) {
+	metrics.CacheRequests.Reset()
+	server := miniredis.RunT(t)
+	cache := NewCacheMiddleware(redis.NewRedisClient(server.Addr(), "", 0))
+	engine := gin.New()
+	engine.GET("/api/v1/gold/history/:days", cache.Cache(time.Minute), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"prices": []float64{}})
+	})
+
+	serve(engine, http.MethodGet, "/api/v1/gold/history/7")
+	// The response is stored in the background.
+	deadline := time.Now().Add(2 * time.Second)
+	for len(server.Keys()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := serve(engine, http.MethodGet, "/api/v1/gold/history/7").Header().Get("X-Cache"); got != "HIT" {
+		t.Fatalf("X-Cache = %q on the second request, want HIT", got)
+	}
+	serve(engine, http.MethodGet, "/api/v1/gold/history/30")
+
+	expected := `
+# HELP gold_http_cache_requests_total Response cache lookups, by route template and result (hit or miss).
+# TYPE gold_http_cache_requests_total counter
+gold_http_cache_requests_total{result="hit",route="/api/v1/gold/history/:days"} 1
+gold_http_cache_requests_total{result="miss",route="/api/v1/gold/history/:days"} 2
+`
+	if err := testutil.CollectAndCompare(metrics.CacheRequests, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+}
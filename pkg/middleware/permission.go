@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, userID uint, permission string) (bool, error)
+}
+
+func RequirePermission(checker PermissionChecker, permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, exists := ctx.Get("user_id")
+		if !exists {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			ctx.Abort()
+			return
+		}
+
+		allowed, err := checker.HasPermission(ctx.Request.Context(), userID.(uint), permission)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			ctx.Abort()
+			return
+		}
+
+		if !allowed {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "permission required: " + permission})
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
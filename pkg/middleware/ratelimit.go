@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/919Umesh/gold_go/pkg/metrics"
 	"github.com/919Umesh/gold_go/pkg/redis"
 	"github.com/gin-gonic/gin"
 )
@@ -25,15 +26,24 @@ type RateLimitConfig struct {
 }
 
 var endpointLimits = map[string]RateLimitConfig{
-	"/api/v1/auth/login":         {Requests: 5, Window: 300},
-	"/api/v1/gold/history":       {Requests: 50, Window: 60},
-	"/api/v1/auth/profile":       {Requests: 60, Window: 60},
-	"/api/v1/auth/register":      {Requests: 3, Window: 3600},
-	"/api/v1/wallet/topup":       {Requests: 100, Window: 3600},
-	"/api/v1/wallet/buy":         {Requests: 30, Window: 3600},
-	"/api/v1/wallet/sell":        {Requests: 30, Window: 3600},
-	"/api/v1/wallet/transaction": {Requests: 30, Window: 3600},
-	"/auth/profile/update":       {Requests: 30, Window: 3600},
+	"/api/v1/auth/login":           {Requests: 5, Window: 300},
+	"/api/v1/auth/login/2fa":       {Requests: 5, Window: 300},
+	"/api/v1/auth/refresh":         {Requests: 30, Window: 3600},
+	"/api/v1/auth/step-up":         {Requests: 5, Window: 300},
+	"/api/v1/auth/verify/email":    {Requests: 10, Window: 3600},
+	"/api/v1/auth/verify/phone":    {Requests: 10, Window: 3600},
+	"/api/v1/auth/password/forgot": {Requests: 5, Window: 3600},
+	"/api/v1/auth/password/reset":  {Requests: 10, Window: 3600},
+	"/api/v1/auth/2fa/confirm":     {Requests: 5, Window: 300},
+	"/api/v1/auth/2fa/disable":     {Requests: 5, Window: 300},
+	"/api/v1/gold/history":         {Requests: 50, Window: 60},
+	"/api/v1/auth/profile":         {Requests: 60, Window: 60},
+	"/api/v1/auth/register":        {Requests: 3, Window: 3600},
+	"/api/v1/wallet/topup":         {Requests: 100, Window: 3600},
+	"/api/v1/wallet/buy":           {Requests: 30, Window: 3600},
+	"/api/v1/wallet/sell":          {Requests: 30, Window: 3600},
+	"/api/v1/wallet/transaction":   {Requests: 30, Window: 3600},
+	"/auth/profile/update":         {Requests: 30, Window: 3600},
 }
 
 func (rl *RateLimiter) RateLimit() gin.HandlerFunc {
@@ -62,6 +72,7 @@ func (rl *RateLimiter) RateLimit() gin.HandlerFunc {
 		}
 
 		if count > int64(config.Requests) {
+			metrics.RateLimitRejections.WithLabelValues(routeLabel(ctx)).Inc()
 			ctx.JSON(http.StatusTooManyRequests, gin.H{
 				"error":   "rate limit exceeded",
 				"message": "Too many requests. Please try again later.",
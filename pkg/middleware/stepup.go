@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/919Umesh/gold_go/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func HasFreshStepUp(c *gin.Context, maxAge time.Duration, now time.Time) bool {
+	value, exists := c.Get("claims")
+	if !exists {
+		return false
+	}
+	claims, ok := value.(*utils.JWTClaims)
+	if !ok || claims.StepUpAt == 0 {
+		return false
+	}
+
+	stepUpAt := time.Unix(claims.StepUpAt, 0)
+	return !stepUpAt.After(now) && now.Sub(stepUpAt) <= maxAge
+}
+
+func AbortStepUpRequired(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":            "recent re-authentication required",
+		"step_up_required": true,
+	})
+	c.Abort()
+}
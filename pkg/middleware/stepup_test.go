@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+	"github.com/919Umesh/gold_go/pkg/clock"
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/919Umesh/gold_go/pkg/tokenstore"
+	"github.com/919Umesh/gold_go/pkg/utils"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func TestHasFreshStepUpHonoursMaxAge(t *testing.T) {
+	clk := &clock.Fixed{Time: time.Unix(1_700_000_000, 0)}
+	stepUpAt := clk.Now().Unix()
+	maxAge := 5 * time.Minute
+
+	cases := []struct {
+		name    string
+		advance time.Duration
+		fresh   bool
+	}{
+		{"just issued", 0, true},
+		{"at max age", maxAge, true},
+		{"past max age", time.Second, false},
+	}
+	for _, tc := range cases {
+		clk.Advance(tc.advance)
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set("claims", &utils.JWTClaims{UserID: 1, StepUpAt: stepUpAt})
+		if got := HasFreshStepUp(c, maxAge, clk.Now()); got != tc.fresh {
+			t.Errorf("%s: fresh = %v, want %v", tc.name, got, tc.fresh)
+		}
+	}
+}
+
+func TestHasFreshStepUpRejectsMissingOrFutureStepUp(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	if HasFreshStepUp(c, time.Minute, now) {
+		t.Error("request without claims counted as stepped up")
+	}
+
+	c.Set("claims", &utils.JWTClaims{UserID: 1})
+	if HasFreshStepUp(c, time.Minute, now) {
+		t.Error("plain access token counted as stepped up")
+	}
+
+	c.Set("claims", &utils.JWTClaims{UserID: 1, StepUpAt: now.Add(time.Minute).Unix()})
+	if HasFreshStepUp(c, time.Minute, now) {
+		t.Error("step-up from the future accepted")
+	}
+}
+
+func TestJWTAuthRefusesMFAToken(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Auth.JWTSecret = "secret"
+	denylist := tokenstore.NewDenylist(redis.NewRedisClient(miniredis.RunT(t).Addr(), "", 0))
+
+	router := gin.New()
+	router.GET("/", JWTAuth(cfg, denylist), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	send := func(claims *utils.JWTClaims) int {
+		token, err := utils.SignToken(claims, cfg.Auth.JWTSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	mfa := utils.NewClaims(1, time.Now(), time.Minute)
+	mfa.Purpose = utils.PurposeMFA
+	if code := send(mfa); code != http.StatusUnauthorized {
+		t.Errorf("mfa token: status = %d, want 401", code)
+	}
+	if code := send(utils.NewClaims(1, time.Now(), time.Minute)); code != http.StatusNoContent {
+		t.Errorf("access token: status = %d, want 204", code)
+	}
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VerificationChecker interface {
+	IsVerified(ctx context.Context, userID uint) (bool, error)
+}
+
+func RequireVerified(checker VerificationChecker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userID, exists := ctx.Get("user_id")
+		if !exists {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "user not authenticated"})
+			ctx.Abort()
+			return
+		}
+
+		verified, err := checker.IsVerified(ctx.Request.Context(), userID.(uint))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+			ctx.Abort()
+			return
+		}
+
+		if !verified {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "verify your email and phone before trading"})
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
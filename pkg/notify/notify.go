@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/919Umesh/gold_go/config"
+)
+
+// ErrSMSUnavailable is returned for every SMS outside development until a
+// provider is integrated, so codes are never silently dropped into a log.
+var ErrSMSUnavailable = errors.New("sms delivery is not available")
+
+type SMSSender interface {
+	SendSMS(ctx context.Context, to, message string) error
+}
+
+type EmailSender interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
+}
+
+// NewEmailSender uses SMTP when SMTP_HOST is set and the log sender
+// otherwise. Config validation refuses production without SMTP.
+func NewEmailSender(cfg *config.Config) EmailSender {
+	if cfg.Notify.SMTPHost != "" {
+		return NewSMTPSender(cfg.Notify.SMTPHost, cfg.Notify.SMTPPort, cfg.Notify.SMTPUsername, cfg.Notify.SMTPPassword, cfg.Notify.SMTPFrom)
+	}
+	return NewLogSender(cfg.Notify.LogFile)
+}
+
+// NewSMSSender returns the log sender in development. No SMS provider is
+// integrated yet, so elsewhere every send fails with ErrSMSUnavailable.
+func NewSMSSender(cfg *config.Config) SMSSender {
+	if cfg.Environment == "development" {
+		return NewLogSender(cfg.Notify.LogFile)
+	}
+	return unavailableSMS{}
+}
+
+type unavailableSMS struct{}
+
+func (unavailableSMS) SendSMS(ctx context.Context, to, message string) error {
+	return ErrSMSUnavailable
+}
+
+// LogSender is the development sender. Only the masked recipient is logged;
+// when a path is set, the full message is appended to that file so codes can
+// be read back without a real SMS or mail provider.
+type LogSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogSender(path string) *LogSender {
+	return &LogSender{path: path}
+}
+
+func (s *LogSender) SendSMS(ctx context.Context, to, message string) error {
+	slog.InfoContext(ctx, "notify: sms sent", "phone", to)
+	return s.write(fmt.Sprintf("SMS to=%s message=%q", to, message))
+}
+
+func (s *LogSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	slog.InfoContext(ctx, "notify: email sent", "email", to, "subject", subject)
+	return s.write(fmt.Sprintf("EMAIL to=%s subject=%q body=%q", to, subject, body))
+}
+
+// write appends line to the file only; it holds the codes, so it must never
+// reach the shared log.
+func (s *LogSender) write(line string) error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s %s\n", time.Now().Format(time.RFC3339), line)
+	return err
+}
+
+type SMTPSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPSender(host string, port int, username, password, from string) *SMTPSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPSender{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		from: from,
+		auth: auth,
+	}
+}
+
+func (s *SMTPSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return fmt.Errorf("invalid email header")
+	}
+
+	msg := "From: " + s.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body + "\r\n"
+
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{to}, []byte(msg))
+}
@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/919Umesh/gold_go/config"
+)
+
+func TestNewSMSSenderOnlyLogsInDevelopment(t *testing.T) {
+	cfg := &config.Config{Environment: "development"}
+	if err := NewSMSSender(cfg).SendSMS(context.Background(), "+9770000000000", "code"); err != nil {
+		t.Fatalf("development: %v", err)
+	}
+
+	for _, env := range []string{"staging", "production"} {
+		cfg.Environment = env
+		err := NewSMSSender(cfg).SendSMS(context.Background(), "+9770000000000", "code")
+		if !errors.Is(err, ErrSMSUnavailable) {
+			t.Errorf("%s: err = %v, want ErrSMSUnavailable", env, err)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package queue
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	task      Task
+	visibleAt time.Time
+	inFlight  bool
+}
+
+// MemoryBackend keeps tasks in process. It follows the same visibility and
+// dead-letter rules as RedisBackend, so it is the backend to use in tests
+// and local development.
+type MemoryBackend struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	order   []string
+	dead    []Task
+}
+
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{entries: make(map[string]*memoryEntry)}
+}
+
+func (b *MemoryBackend) Enqueue(ctx context.Context, task *Task, at time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[task.ID] = &memoryEntry{task: *task, visibleAt: at}
+	b.order = append(b.order, task.ID)
+	return nil
+}
+
+func (b *MemoryBackend) Dequeue(ctx context.Context, visibility time.Duration) (*Task, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	var next *memoryEntry
+	for _, id := range b.order {
+		entry := b.entries[id]
+		if entry.visibleAt.After(now) {
+			continue
+		}
+		if next == nil || entry.visibleAt.Before(next.visibleAt) {
+			next = entry
+		}
+	}
+	if next == nil {
+		return nil, nil
+	}
+
+	next.task.Attempts++
+	next.inFlight = true
+	next.visibleAt = now.Add(visibility)
+	task := next.task
+	return &task, nil
+}
+
+func (b *MemoryBackend) Ack(ctx context.Context, task *Task) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(task.ID)
+	return nil
+}
+
+func (b *MemoryBackend) Retry(ctx context.Context, task *Task, at time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry, ok := b.entries[task.ID]
+	if !ok {
+		return nil
+	}
+	entry.task = *task
+	entry.inFlight = false
+	entry.visibleAt = at
+	return nil
+}
+
+func (b *MemoryBackend) Bury(ctx context.Context, task *Task) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(task.ID)
+	b.dead = append([]Task{*task}, b.dead...)
+	return nil
+}
+
+func (b *MemoryBackend) DeadLetters(ctx context.Context, limit int) ([]Task, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if limit > len(b.dead) {
+		limit = len(b.dead)
+	}
+	tasks := make([]Task, limit)
+	copy(tasks, b.dead[:limit])
+	return tasks, nil
+}
+
+func (b *MemoryBackend) Stats(ctx context.Context) (Stats, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	stats := Stats{Dead: int64(len(b.dead))}
+	for _, entry := range b.entries {
+		switch {
+		case !entry.visibleAt.After(now):
+			stats.Ready++
+		case entry.inFlight:
+			stats.InFlight++
+		default:
+			stats.Delayed++
+		}
+	}
+	return stats, nil
+}
+
+func (b *MemoryBackend) remove(id string) {
+	delete(b.entries, id)
+	for i, candidate := range b.order {
+		if candidate == id {
+			b.order = append(b.order[:i], b.order[i+1:]...)
+			break
+		}
+	}
+}
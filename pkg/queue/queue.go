@@ -0,0 +1,342 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/919Umesh/gold_go/pkg/logging"
+	"github.com/919Umesh/gold_go/pkg/tracing"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrUnknownJobType = errors.New("unknown job type")
+	ErrStopped        = errors.New("queue is stopped")
+)
+
+type Task struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	Timeout     time.Duration   `json:"timeout"`
+	EnqueuedAt  time.Time       `json:"enqueued_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	RequestID   string          `json:"request_id,omitempty"`
+	// TraceContext carries the enqueuing request's trace to the worker.
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+}
+
+// Stats counts tasks by state. Ready tasks can be taken now; delayed ones
+// are waiting for a backoff or WithDelay to pass.
+type Stats struct {
+	Ready    int64 `json:"ready"`
+	Delayed  int64 `json:"delayed"`
+	InFlight int64 `json:"in_flight"`
+	Dead     int64 `json:"dead"`
+}
+
+// Backend stores tasks between Enqueue and Ack. Dequeue increments
+// Task.Attempts and hides the task for the visibility timeout; a task that is
+// neither acked, retried nor buried within that window becomes ready again,
+// which is how work held by a crashed worker is recovered.
+type Backend interface {
+	Enqueue(ctx context.Context, task *Task, at time.Time) error
+	Dequeue(ctx context.Context, visibility time.Duration) (*Task, error)
+	Ack(ctx context.Context, task *Task) error
+	Retry(ctx context.Context, task *Task, at time.Time) error
+	Bury(ctx context.Context, task *Task) error
+	DeadLetters(ctx context.Context, limit int) ([]Task, error)
+	Stats(ctx context.Context) (Stats, error)
+}
+
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying; the task goes straight to the
+// dead-letter queue.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+type Options struct {
+	Workers            int
+	Visibility         time.Duration
+	PollInterval       time.Duration
+	DefaultMaxAttempts int
+	DefaultTimeout     time.Duration
+	BaseBackoff        time.Duration
+	MaxBackoff         time.Duration
+}
+
+func (o *Options) setDefaults() {
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+	if o.Visibility <= 0 {
+		o.Visibility = 5 * time.Minute
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.DefaultMaxAttempts <= 0 {
+		o.DefaultMaxAttempts = 5
+	}
+	if o.DefaultTimeout <= 0 {
+		o.DefaultTimeout = time.Minute
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = 5 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+}
+
+type EnqueueOption func(task *Task, at *time.Time)
+
+func WithMaxAttempts(n int) EnqueueOption {
+	return func(task *Task, at *time.Time) { task.MaxAttempts = n }
+}
+
+func WithTimeout(d time.Duration) EnqueueOption {
+	return func(task *Task, at *time.Time) { task.Timeout = d }
+}
+
+func WithDelay(d time.Duration) EnqueueOption {
+	return func(task *Task, at *time.Time) { *at = at.Add(d) }
+}
+
+type Queue struct {
+	backend  Backend
+	opts     Options
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+
+	wg         sync.WaitGroup
+	stopping   chan struct{}
+	stopOnce   sync.Once
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+}
+
+func New(backend Backend, opts Options) *Queue {
+	opts.setDefaults()
+	jobCtx, cancel := context.WithCancel(context.Background())
+	return &Queue{
+		backend:    backend,
+		opts:       opts,
+		handlers:   make(map[string]HandlerFunc),
+		stopping:   make(chan struct{}),
+		jobCtx:     jobCtx,
+		cancelJobs: cancel,
+	}
+}
+
+func (q *Queue) Register(jobType string, handler HandlerFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[jobType] = handler
+}
+
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...EnqueueOption) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	now := time.Now()
+	at := now
+	task := &Task{
+		ID:           uuid.New().String(),
+		Type:         jobType,
+		Payload:      data,
+		MaxAttempts:  q.opts.DefaultMaxAttempts,
+		Timeout:      q.opts.DefaultTimeout,
+		EnqueuedAt:   now,
+		RequestID:    logging.RequestID(ctx),
+		TraceContext: tracing.Inject(ctx),
+	}
+	for _, opt := range opts {
+		opt(task, &at)
+	}
+
+	if err := q.backend.Enqueue(ctx, task, at); err != nil {
+		return "", err
+	}
+	return task.ID, nil
+}
+
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	return q.backend.Stats(ctx)
+}
+
+func (q *Queue) DeadLetters(ctx context.Context, limit int) ([]Task, error) {
+	return q.backend.DeadLetters(ctx, limit)
+}
+
+func (q *Queue) Start() {
+	for i := 0; i < q.opts.Workers; i++ {
+		q.wg.Add(1)
+		go q.worker(i)
+	}
+}
+
+// Stop stops taking new tasks and waits for in-flight ones to finish. If ctx
+// expires first the running handlers are cancelled and Stop returns at once.
+// Their tasks are handed back as ready without using up an attempt; if that
+// handback does not reach the backend, they become visible again after the
+// visibility timeout.
+func (q *Queue) Stop(ctx context.Context) error {
+	q.stopOnce.Do(func() { close(q.stopping) })
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		q.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		q.cancelJobs()
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) worker(id int) {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.stopping:
+			return
+		default:
+		}
+
+		task, err := q.backend.Dequeue(q.jobCtx, q.opts.Visibility)
+		if err != nil {
+			slog.ErrorContext(q.jobCtx, "queue: dequeue failed", "worker", id, "error", err)
+		}
+		if task == nil {
+			select {
+			case <-q.stopping:
+				return
+			case <-time.After(q.opts.PollInterval):
+			}
+			continue
+		}
+
+		q.process(task)
+	}
+}
+
+func (q *Queue) process(task *Task) {
+	// Bookkeeping must still reach the backend while a forced Stop is
+	// cancelling job contexts.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if task.Attempts > task.MaxAttempts {
+		task.LastError = "max attempts exceeded"
+		q.bury(ctx, task)
+		return
+	}
+
+	err := q.run(task)
+	if err == nil {
+		if ackErr := q.backend.Ack(ctx, task); ackErr != nil {
+			slog.ErrorContext(ctx, "queue: ack failed", "task_id", task.ID, "error", ackErr)
+		}
+		return
+	}
+
+	if q.jobCtx.Err() != nil {
+		// Cancelled by a forced Stop, not failed: give the attempt back.
+		task.Attempts--
+		if retryErr := q.backend.Retry(ctx, task, time.Now()); retryErr != nil {
+			slog.ErrorContext(ctx, "queue: hand back failed", "task_id", task.ID, "error", retryErr)
+		}
+		return
+	}
+
+	task.LastError = err.Error()
+	var permanent *permanentError
+	if errors.As(err, &permanent) || errors.Is(err, ErrUnknownJobType) || task.Attempts >= task.MaxAttempts {
+		q.bury(ctx, task)
+		return
+	}
+
+	delay := q.backoff(task.Attempts)
+	if retryErr := q.backend.Retry(ctx, task, time.Now().Add(delay)); retryErr != nil {
+		slog.ErrorContext(ctx, "queue: schedule retry failed", "task_id", task.ID, "error", retryErr)
+	}
+}
+
+func (q *Queue) run(task *Task) (err error) {
+	q.mu.RLock()
+	handler, ok := q.handlers[task.Type]
+	q.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownJobType, task.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(q.jobCtx, task.Timeout)
+	defer cancel()
+	if task.RequestID != "" {
+		ctx = logging.WithRequestID(ctx, task.RequestID)
+	}
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, task.TraceContext), "queue.process "+task.Type,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("queue.task_id", task.ID),
+			attribute.Int("queue.attempt", task.Attempts),
+		))
+	defer func() { tracing.End(span, err) }()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return handler(ctx, task.Payload)
+}
+
+func (q *Queue) bury(ctx context.Context, task *Task) {
+	slog.WarnContext(ctx, "queue: task moved to dead-letter", "task_id", task.ID, "type", task.Type, "attempts", task.Attempts, "last_error", task.LastError)
+	if err := q.backend.Bury(ctx, task); err != nil {
+		slog.ErrorContext(ctx, "queue: bury failed", "task_id", task.ID, "error", err)
+	}
+}
+
+// backoff doubles per attempt and applies jitter in [50%, 100%] of the delay
+// so that tasks failing together do not retry together.
+func (q *Queue) backoff(attempts int) time.Duration {
+	delay := q.opts.BaseBackoff
+	for i := 1; i < attempts && delay < q.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > q.opts.MaxBackoff {
+		delay = q.opts.MaxBackoff
+	}
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
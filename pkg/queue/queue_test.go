@@ -0,0 +1,175 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestQueue(backend Backend) *Queue {
+	return New(backend, Options{
+		Workers:      1,
+		Visibility:   time.Minute,
+		PollInterval: 5 * time.Millisecond,
+		BaseBackoff:  10 * time.Millisecond,
+		MaxBackoff:   20 * time.Millisecond,
+	})
+}
+
+// eventually polls cond for up to two seconds.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func stats(t *testing.T, backend Backend) Stats {
+	t.Helper()
+	s, err := backend.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestFailedTaskIsRetried(t *testing.T) {
+	backend := NewMemoryBackend()
+	q := newTestQueue(backend)
+	var calls atomic.Int32
+	q.Register("job", func(ctx context.Context, payload json.RawMessage) error {
+		if calls.Add(1) == 1 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	q.Start()
+	defer q.Stop(context.Background())
+
+	if _, err := q.Enqueue(context.Background(), "job", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, func() bool { return calls.Load() == 2 })
+	eventually(t, func() bool { s := stats(t, backend); return s.Ready+s.Delayed+s.InFlight == 0 })
+	if s := stats(t, backend); s.Dead != 0 {
+		t.Errorf("dead = %d, want 0", s.Dead)
+	}
+}
+
+func TestTaskIsBuriedAfterMaxAttempts(t *testing.T) {
+	backend := NewMemoryBackend()
+	q := newTestQueue(backend)
+	var calls atomic.Int32
+	q.Register("job", func(ctx context.Context, payload json.RawMessage) error {
+		calls.Add(1)
+		return errors.New("still failing")
+	})
+	q.Start()
+	defer q.Stop(context.Background())
+
+	q.Enqueue(context.Background(), "job", nil, WithMaxAttempts(3))
+
+	eventually(t, func() bool { return stats(t, backend).Dead == 1 })
+	dead, _ := q.DeadLetters(context.Background(), 10)
+	if len(dead) != 1 || dead[0].Attempts != 3 || dead[0].LastError != "still failing" {
+		t.Fatalf("dead letters = %+v", dead)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("handler ran %d times, want 3", calls.Load())
+	}
+}
+
+func TestPermanentErrorIsNotRetried(t *testing.T) {
+	backend := NewMemoryBackend()
+	q := newTestQueue(backend)
+	var calls atomic.Int32
+	q.Register("job", func(ctx context.Context, payload json.RawMessage) error {
+		calls.Add(1)
+		return Permanent(errors.New("bad payload"))
+	})
+	q.Start()
+	defer q.Stop(context.Background())
+
+	q.Enqueue(context.Background(), "job", nil)
+
+	eventually(t, func() bool { return stats(t, backend).Dead == 1 })
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+}
+
+func TestUnackedTaskReappearsAfterVisibilityTimeout(t *testing.T) {
+	backend := NewMemoryBackend()
+	ctx := context.Background()
+	backend.Enqueue(ctx, &Task{ID: "a", Type: "job"}, time.Now())
+
+	first, _ := backend.Dequeue(ctx, 30*time.Millisecond)
+	if first == nil || first.Attempts != 1 {
+		t.Fatalf("first dequeue = %+v", first)
+	}
+	if again, _ := backend.Dequeue(ctx, 30*time.Millisecond); again != nil {
+		t.Fatal("task visible while in flight")
+	}
+	if s := stats(t, backend); s.InFlight != 1 {
+		t.Errorf("stats = %+v, want 1 in flight", s)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	second, _ := backend.Dequeue(ctx, 30*time.Millisecond)
+	if second == nil || second.ID != "a" || second.Attempts != 2 {
+		t.Fatalf("second dequeue = %+v, want task a on attempt 2", second)
+	}
+}
+
+func TestStatsCountsDelayedTasksSeparately(t *testing.T) {
+	backend := NewMemoryBackend()
+	q := newTestQueue(backend)
+	q.Enqueue(context.Background(), "job", nil)
+	q.Enqueue(context.Background(), "job", nil, WithDelay(time.Hour))
+
+	if s := stats(t, backend); s.Ready != 1 || s.Delayed != 1 {
+		t.Errorf("stats = %+v, want 1 ready and 1 delayed", s)
+	}
+}
+
+func TestForcedStopReturnsAtDeadlineAndGivesAttemptBack(t *testing.T) {
+	backend := NewMemoryBackend()
+	q := newTestQueue(backend)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	q.Register("job", func(ctx context.Context, payload json.RawMessage) error {
+		close(started)
+		<-ctx.Done()
+		<-release // a handler that is slow to notice cancellation
+		return ctx.Err()
+	})
+	q.Start()
+	q.Enqueue(context.Background(), "job", nil)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	begin := time.Now()
+	if err := q.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop = %v, want deadline exceeded", err)
+	}
+	if waited := time.Since(begin); waited > 500*time.Millisecond {
+		t.Fatalf("Stop waited %s past its deadline", waited)
+	}
+
+	release <- struct{}{}
+	eventually(t, func() bool { return stats(t, backend).Ready == 1 })
+	task, _ := backend.Dequeue(context.Background(), time.Minute)
+	if task == nil || task.Attempts != 1 {
+		t.Fatalf("task after handback = %+v, want attempt 1", task)
+	}
+}
@@ -0,0 +1,186 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/pkg/redis"
+)
+
+// Task bodies live in a hash; the ready and in-flight sets only hold ids,
+// scored by the time the task becomes visible again. Attempts are counted in
+// their own hash so the body never has to be decoded inside Redis.
+const enqueueScript = `
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[1])
+return 1
+`
+
+const dequeueScript = `
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(expired) do
+  redis.call('ZREM', KEYS[2], id)
+  redis.call('ZADD', KEYS[1], ARGV[1], id)
+end
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #ids == 0 then
+  return false
+end
+local id = ids[1]
+local body = redis.call('HGET', KEYS[3], id)
+redis.call('ZREM', KEYS[1], id)
+if not body then
+  return false
+end
+local attempts = redis.call('HINCRBY', KEYS[4], id, 1)
+redis.call('ZADD', KEYS[2], ARGV[2], id)
+return {body, attempts}
+`
+
+const ackScript = `
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HDEL', KEYS[2], ARGV[1])
+redis.call('HDEL', KEYS[3], ARGV[1])
+return 1
+`
+
+const retryScript = `
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[1])
+redis.call('HSET', KEYS[4], ARGV[1], ARGV[4])
+return 1
+`
+
+const buryScript = `
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('LPUSH', KEYS[3], ARGV[1])
+redis.call('HDEL', KEYS[4], ARGV[1])
+return 1
+`
+
+type RedisBackend struct {
+	client   *redis.Client
+	tasks    string
+	ready    string
+	inFlight string
+	dead     string
+	attempts string
+}
+
+func NewRedisBackend(client *redis.Client, name string) *RedisBackend {
+	prefix := "queue:" + name + ":"
+	return &RedisBackend{
+		client:   client,
+		tasks:    prefix + "tasks",
+		ready:    prefix + "ready",
+		inFlight: prefix + "inflight",
+		dead:     prefix + "dead",
+		attempts: prefix + "attempts",
+	}
+}
+
+func (b *RedisBackend) Enqueue(ctx context.Context, task *Task, at time.Time) error {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	_, err = b.client.Eval(ctx, enqueueScript, []string{b.tasks, b.ready}, task.ID, body, at.UnixMilli())
+	return err
+}
+
+func (b *RedisBackend) Dequeue(ctx context.Context, visibility time.Duration) (*Task, error) {
+	now := time.Now()
+	result, err := b.client.Eval(ctx, dequeueScript, []string{b.ready, b.inFlight, b.tasks, b.attempts},
+		now.UnixMilli(), now.Add(visibility).UnixMilli())
+	if err != nil {
+		if redis.IsNil(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return nil, fmt.Errorf("unexpected dequeue result %v", result)
+	}
+	body, _ := values[0].(string)
+	attempts, _ := values[1].(int64)
+
+	var task Task
+	if err := json.Unmarshal([]byte(body), &task); err != nil {
+		return nil, err
+	}
+	task.Attempts = int(attempts)
+	return &task, nil
+}
+
+func (b *RedisBackend) Ack(ctx context.Context, task *Task) error {
+	_, err := b.client.Eval(ctx, ackScript, []string{b.inFlight, b.tasks, b.attempts}, task.ID)
+	return err
+}
+
+func (b *RedisBackend) Retry(ctx context.Context, task *Task, at time.Time) error {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	_, err = b.client.Eval(ctx, retryScript, []string{b.inFlight, b.tasks, b.ready, b.attempts}, task.ID, body, at.UnixMilli(), task.Attempts)
+	return err
+}
+
+func (b *RedisBackend) Bury(ctx context.Context, task *Task) error {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	_, err = b.client.Eval(ctx, buryScript, []string{b.inFlight, b.tasks, b.dead, b.attempts}, task.ID, body)
+	return err
+}
+
+func (b *RedisBackend) DeadLetters(ctx context.Context, limit int) ([]Task, error) {
+	ids, err := b.client.LRange(ctx, b.dead, 0, int64(limit)-1)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]Task, 0, len(ids))
+	for _, id := range ids {
+		body, err := b.client.HGet(ctx, b.tasks, id)
+		if err != nil {
+			if redis.IsNil(err) {
+				continue
+			}
+			return nil, err
+		}
+		var task Task
+		if err := json.Unmarshal([]byte(body), &task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+func (b *RedisBackend) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+	var err error
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	if stats.Ready, err = b.client.ZCount(ctx, b.ready, "-inf", now); err != nil {
+		return stats, err
+	}
+	if stats.Delayed, err = b.client.ZCount(ctx, b.ready, "("+now, "+inf"); err != nil {
+		return stats, err
+	}
+	if stats.InFlight, err = b.client.ZCard(ctx, b.inFlight); err != nil {
+		return stats, err
+	}
+	if stats.Dead, err = b.client.LLen(ctx, b.dead); err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
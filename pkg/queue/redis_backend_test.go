@@ -0,0 +1,39 @@
+package queue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedisBuryDropsTheAttemptCount(t *testing.T) {
+	server := miniredis.RunT(t)
+	backend := NewRedisBackend(redis.NewRedisClient(server.Addr(), "", 0), "jobs")
+	ctx := context.Background()
+
+	if err := backend.Enqueue(ctx, &Task{ID: "t1", Type: "job"}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	task, err := backend.Dequeue(ctx, time.Minute)
+	if err != nil || task == nil || task.Attempts != 1 {
+		t.Fatalf("Dequeue = %+v, %v, want the task on its first attempt", task, err)
+	}
+	if got := server.HGet("queue:jobs:attempts", "t1"); got != "1" {
+		t.Fatalf("attempts while in flight = %q, want 1", got)
+	}
+
+	task.LastError = "bad payload"
+	if err := backend.Bury(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists("queue:jobs:attempts") {
+		t.Fatalf("attempts hash = %q, want the buried task's entry removed", server.HGet("queue:jobs:attempts", "t1"))
+	}
+	dead, err := backend.DeadLetters(ctx, 10)
+	if err != nil || len(dead) != 1 || dead[0].LastError != "bad payload" {
+		t.Fatalf("DeadLetters = %+v, %v, want the buried task", dead, err)
+	}
+}
@@ -2,14 +2,19 @@ package queue
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"sync"
 )
 
+var ErrQueueFull = errors.New("job queue full")
+
 type Job interface {
 	Process() error
 }
 
+// WorkerPool is the in-memory pool for fire-and-forget jobs. Jobs are lost on
+// restart; work that must survive one belongs on a Queue.
 type WorkerPool struct {
 	workers  int
 	jobQueue chan Job
@@ -42,7 +47,7 @@ func (wp *WorkerPool) worker(id int) {
 		select {
 		case job := <-wp.jobQueue:
 			if err := job.Process(); err != nil {
-				log.Printf("Worker %d: job processing failed: %v", id, err)
+				slog.ErrorContext(wp.ctx, "worker pool: job failed", "worker", id, "error", err)
 			}
 		case <-wp.ctx.Done():
 			return
@@ -50,11 +55,20 @@ func (wp *WorkerPool) worker(id int) {
 	}
 }
 
+// Submit queues job, dropping it with a warning when the pool is full.
 func (wp *WorkerPool) Submit(job Job) {
+	wp.TrySubmit(job)
+}
+
+// TrySubmit queues job like Submit, and returns ErrQueueFull when it had to
+// drop it.
+func (wp *WorkerPool) TrySubmit(job Job) error {
 	select {
 	case wp.jobQueue <- job:
+		return nil
 	default:
-		log.Println("Job queue full, dropping job")
+		slog.Warn("worker pool: queue full, dropping job")
+		return ErrQueueFull
 	}
 }
 
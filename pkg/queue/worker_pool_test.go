@@ -0,0 +1,26 @@
+package queue
+
+import (
+	"errors"
+	"testing"
+)
+
+type noopJob struct{}
+
+func (noopJob) Process() error { return nil }
+
+func TestTrySubmitReportsAFullPool(t *testing.T) {
+	pool := NewWorkerPool(1, 1)
+
+	if err := pool.TrySubmit(noopJob{}); err != nil {
+		t.Fatalf("first job: %v", err)
+	}
+	if err := pool.TrySubmit(noopJob{}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("second job: err = %v, want ErrQueueFull", err)
+	}
+	// Submit keeps its original signature and drops the job quietly.
+	pool.Submit(noopJob{})
+
+	pool.Start()
+	pool.Stop()
+}
@@ -17,6 +17,7 @@ func NewRedisClient(addr, password string, db int) *Client {
 		Password: password,
 		DB:       db,
 	})
+	rdb.AddHook(tracingHook{})
 
 	return &Client{client: rdb}
 }
@@ -25,6 +26,10 @@ func (c *Client) Ping(ctx context.Context) error {
 	return c.client.Ping(ctx).Err()
 }
 
+func (c *Client) Close() error {
+	return c.client.Close()
+}
+
 func (c *Client) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
 	return c.client.Set(ctx, key, value, expiration).Err()
 }
@@ -55,3 +60,41 @@ func (c *Client) IncrementWithExpiry(ctx context.Context, key string, expiry tim
 	}
 	return incr.Result()
 }
+
+func (c *Client) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return redis.NewScript(script).Run(ctx, c.client, keys, args...).Result()
+}
+
+func (c *Client) HGet(ctx context.Context, key, field string) (string, error) {
+	return c.client.HGet(ctx, key, field).Result()
+}
+
+func (c *Client) ZCard(ctx context.Context, key string) (int64, error) {
+	return c.client.ZCard(ctx, key).Result()
+}
+
+func (c *Client) ZCount(ctx context.Context, key, min, max string) (int64, error) {
+	return c.client.ZCount(ctx, key, min, max).Result()
+}
+
+func (c *Client) LLen(ctx context.Context, key string) (int64, error) {
+	return c.client.LLen(ctx, key).Result()
+}
+
+func (c *Client) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return c.client.LRange(ctx, key, start, stop).Result()
+}
+
+func IsNil(err error) bool {
+	return err == redis.Nil
+}
+
+func (c *Client) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, key, value, expiration).Result()
+}
+
+// TTL returns the remaining time to live of key, or a value <= 0 if the key
+// does not exist or has no expiry.
+func (c *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return c.client.PTTL(ctx, key).Result()
+}
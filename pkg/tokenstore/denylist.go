@@ -0,0 +1,66 @@
+package tokenstore
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/919Umesh/gold_go/pkg/utils"
+)
+
+// Denylist records access tokens that must stop working before they expire.
+// Single tokens are keyed by jti; "log out everywhere" stores a per-user
+// cut-off so every token issued before it is rejected.
+type Denylist struct {
+	client *redis.Client
+}
+
+func NewDenylist(client *redis.Client) *Denylist {
+	return &Denylist{client: client}
+}
+
+func (d *Denylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if jti == "" || ttl <= 0 {
+		return nil
+	}
+	return d.client.Set(ctx, jtiKey(jti), "1", ttl)
+}
+
+// RevokeUser rejects every token of the user issued up to and including the
+// second of before. iat is in whole seconds, so a token issued later in that
+// same second is rejected too and its client has to sign in again.
+func (d *Denylist) RevokeUser(ctx context.Context, userID uint, before time.Time, ttl time.Duration) error {
+	return d.client.Set(ctx, userKey(userID), strconv.FormatInt(before.Unix(), 10), ttl)
+}
+
+func (d *Denylist) IsRevoked(ctx context.Context, claims *utils.JWTClaims) (bool, error) {
+	if _, err := d.client.Get(ctx, jtiKey(claims.ID)); err == nil {
+		return true, nil
+	} else if !redis.IsNil(err) {
+		return false, err
+	}
+
+	value, err := d.client.Get(ctx, userKey(claims.UserID))
+	if err != nil {
+		if redis.IsNil(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	cutoff, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || claims.IssuedAt == nil {
+		return false, err
+	}
+	return claims.IssuedAt.Unix() <= cutoff, nil
+}
+
+func jtiKey(jti string) string {
+	return "auth:denylist:" + jti
+}
+
+func userKey(userID uint) string {
+	return "auth:revoked_before:" + strconv.FormatUint(uint64(userID), 10)
+}
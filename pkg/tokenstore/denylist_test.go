@@ -0,0 +1,77 @@
+package tokenstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/919Umesh/gold_go/pkg/redis"
+	"github.com/919Umesh/gold_go/pkg/utils"
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newDenylist(t *testing.T) (*Denylist, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	return NewDenylist(redis.NewRedisClient(server.Addr(), "", 0)), server
+}
+
+// issue signs and parses claims issued at, so the test sees iat with the
+// precision a real token carries.
+func issue(t *testing.T, at time.Time) *utils.JWTClaims {
+	t.Helper()
+	signed, err := utils.SignToken(utils.NewClaims(1, at, time.Hour), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := utils.ParseToken(signed, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return claims
+}
+
+func TestRevokeUserCoversTheWholeSecondOfTheCutoff(t *testing.T) {
+	denylist, _ := newDenylist(t)
+	ctx := context.Background()
+	second := time.Now().Truncate(time.Second)
+
+	if err := denylist.RevokeUser(ctx, 1, second.Add(400*time.Millisecond), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		issuedAt time.Time
+		revoked  bool
+	}{
+		{second.Add(-time.Second), true},
+		{second.Add(100 * time.Millisecond), true},
+		{second.Add(900 * time.Millisecond), true},
+		{second.Add(time.Second), false},
+	}
+	for _, c := range cases {
+		revoked, err := denylist.IsRevoked(ctx, issue(t, c.issuedAt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if revoked != c.revoked {
+			t.Errorf("token issued %s after the second: revoked = %v, want %v", c.issuedAt.Sub(second), revoked, c.revoked)
+		}
+	}
+}
+
+func TestRevokeRejectsSingleToken(t *testing.T) {
+	denylist, _ := newDenylist(t)
+	ctx := context.Background()
+	claims := issue(t, time.Now())
+
+	if err := denylist.Revoke(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		t.Fatal(err)
+	}
+	if revoked, _ := denylist.IsRevoked(ctx, claims); !revoked {
+		t.Error("revoked token accepted")
+	}
+	if revoked, _ := denylist.IsRevoked(ctx, issue(t, time.Now())); revoked {
+		t.Error("unrelated token rejected")
+	}
+}
@@ -0,0 +1,53 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// The RFC 6238 SHA-1 seed "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestCodeAtMatchesRFCVector(t *testing.T) {
+	code, err := CodeAt(rfcSecret, Step(time.Unix(59, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "287082" {
+		t.Fatalf("code = %s, want 287082", code)
+	}
+}
+
+func TestValidateAcceptsCodesWithinSkew(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	current := Step(now)
+
+	for offset := int64(-3); offset <= 3; offset++ {
+		code, err := CodeAt(rfcSecret, current+offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := Validate(rfcSecret, code, now, 1)
+		want := offset >= -1 && offset <= 1
+		if ok != want {
+			t.Errorf("code from %+d steps: accepted = %v, want %v", offset, ok, want)
+		}
+		if ok && step != current+offset {
+			t.Errorf("code from %+d steps matched step %d, want %d", offset, step, current+offset)
+		}
+	}
+}
+
+func TestValidateRejectsMalformedCodes(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	code, _ := CodeAt(rfcSecret, Step(now))
+
+	for _, candidate := range []string{"", code[:5], code + "0", "abcdef"} {
+		if _, ok := Validate(rfcSecret, candidate, now, 1); ok {
+			t.Errorf("code %q accepted", candidate)
+		}
+	}
+	if _, ok := Validate(rfcSecret, " "+code+" ", now, 0); !ok {
+		t.Error("code with surrounding spaces rejected")
+	}
+}
@@ -0,0 +1,208 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/919Umesh/gold_go/pkg/uow/uowtest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+)
+
+// record installs a provider that exports every span synchronously to the
+// returned exporter, and restores the global provider when the test ends.
+func record(t *testing.T, sampleRatio float64) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	provider := NewProvider(sdktrace.NewSimpleSpanProcessor(exporter), "gold-test", sampleRatio)
+
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+		provider.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	values := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+func only(t *testing.T, exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+	var found []tracetest.SpanStub
+	for _, span := range exporter.GetSpans() {
+		if span.Name == name {
+			found = append(found, span)
+		}
+	}
+	if len(found) != 1 {
+		t.Fatalf("%d %q spans in %v, want one", len(found), name, exporter.GetSpans())
+	}
+	return found[0]
+}
+
+func TestGormPluginTracesStatementsUnderTheCallerSpan(t *testing.T) {
+	exporter := record(t, 1)
+	gormDB, _ := uowtest.Open(t, func(query string, args []driver.NamedValue) (uowtest.Result, error) {
+		return uowtest.Result{Columns: []string{"id"}, Rows: [][]driver.Value{{int64(1)}}}, nil
+	})
+	if err := gormDB.Use(GormPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := Tracer().Start(context.Background(), "wallet.buy")
+	var ids []int64
+	if err := gormDB.WithContext(ctx).Table("wallets").Where("user_id = ?", "secret@example.com").Pluck("id", &ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	span := only(t, exporter, "gorm.query")
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("gorm.query parent = %s, want the wallet.buy span %s", span.Parent.SpanID(), parent.SpanContext().SpanID())
+	}
+	values := attributes(span)
+	if got := values["db.statement"].AsString(); got != `SELECT "id" FROM "wallets" WHERE user_id = $1` {
+		t.Fatalf("db.statement = %q, want the SQL with placeholders only", got)
+	}
+	if got := values["db.sql.table"].AsString(); got != "wallets" {
+		t.Fatalf("db.sql.table = %q", got)
+	}
+}
+
+func TestGormPluginRecordsFailuresButNotMissingRows(t *testing.T) {
+	exporter := record(t, 1)
+	failing := errors.New("connection reset")
+	gormDB, _ := uowtest.Open(t, func(query string, args []driver.NamedValue) (uowtest.Result, error) {
+		if query == `DELETE FROM "wallets" WHERE id = $1` {
+			return uowtest.Result{}, failing
+		}
+		return uowtest.Result{Columns: []string{"id"}}, nil
+	})
+	if err := gormDB.Use(GormPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wallet struct{ ID uint }
+	if err := gormDB.Table("wallets").First(&wallet).Error; !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("First = %v, want record not found", err)
+	}
+	if err := gormDB.Exec(`DELETE FROM "wallets" WHERE id = ?`, 1).Error; !errors.Is(err, failing) {
+		t.Fatalf("Exec = %v, want the driver error", err)
+	}
+
+	if span := only(t, exporter, "gorm.query"); span.Status.Code != codes.Unset {
+		t.Fatalf("gorm.query status = %v, want unset for a missing row", span.Status)
+	}
+	span := only(t, exporter, "gorm.raw")
+	if span.Status.Code != codes.Error || span.Status.Description != failing.Error() {
+		t.Fatalf("gorm.raw status = %v, want the driver error", span.Status)
+	}
+}
+
+func TestTransportPropagatesTheTraceAndRecordsServerErrors(t *testing.T) {
+	exporter := record(t, 1)
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Get("traceparent"))
+		if r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: Transport(nil)}
+
+	ctx, parent := Tracer().Start(context.Background(), "webhook.attempt")
+	for _, path := range []string{"/up", "/down"} {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+path, nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	var requests []tracetest.SpanStub
+	for _, span := range spans {
+		if span.Name == "HTTP POST" {
+			requests = append(requests, span)
+		}
+	}
+	if len(requests) != 2 {
+		t.Fatalf("spans = %v, want two HTTP POST spans", spans)
+	}
+	for i, span := range requests {
+		if span.SpanContext.TraceID() != parent.SpanContext().TraceID() {
+			t.Fatalf("request %d is in trace %s, want %s", i, span.SpanContext.TraceID(), parent.SpanContext().TraceID())
+		}
+		want := "00-" + span.SpanContext.TraceID().String() + "-" + span.SpanContext.SpanID().String() + "-01"
+		if received[i] != want {
+			t.Fatalf("request %d traceparent = %q, want %q", i, received[i], want)
+		}
+	}
+	if requests[0].Status.Code != codes.Unset {
+		t.Fatalf("200 response status = %v, want unset", requests[0].Status)
+	}
+	if got := attributes(requests[1])["http.response.status_code"].AsInt64(); got != http.StatusBadGateway || requests[1].Status.Code != codes.Error {
+		t.Fatalf("502 response: status code %d, span status %v, want an error", got, requests[1].Status)
+	}
+}
+
+func TestInjectAndExtractContinueTheTrace(t *testing.T) {
+	exporter := record(t, 1)
+
+	ctx, enqueue := Tracer().Start(context.Background(), "queue.enqueue")
+	carrier := Inject(ctx)
+	enqueue.End()
+	if carrier["traceparent"] == "" {
+		t.Fatalf("carrier = %v, want a traceparent", carrier)
+	}
+
+	_, process := Tracer().Start(Extract(context.Background(), carrier), "queue.process")
+	process.End()
+
+	span := only(t, exporter, "queue.process")
+	if span.Parent.SpanID() != enqueue.SpanContext().SpanID() || !span.Parent.IsRemote() {
+		t.Fatalf("queue.process parent = %+v, want the remote queue.enqueue span", span.Parent)
+	}
+	if Inject(context.Background()) != nil {
+		t.Fatal("Inject without a span returned a carrier")
+	}
+}
+
+func TestSamplerFollowsTheParentDecision(t *testing.T) {
+	exporter := record(t, 0)
+
+	_, root := Tracer().Start(context.Background(), "unsampled")
+	root.End()
+
+	sampled := Extract(context.Background(), map[string]string{
+		"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	})
+	_, child := Tracer().Start(sampled, "sampled")
+	child.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "sampled" {
+		t.Fatalf("spans = %v, want only the child of the sampled parent", spans)
+	}
+}
@@ -0,0 +1,120 @@
+package uow
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/919Umesh/gold_go/pkg/tracing"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// Tx is the database handle of one unit of work. Repositories bind to it
+// through their WithTx method so that every write lands in the same
+// transaction.
+type Tx struct {
+	db *gorm.DB
+}
+
+func (t Tx) DB() *gorm.DB {
+	return t.db
+}
+
+type Options struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+type UnitOfWork struct {
+	db          *gorm.DB
+	maxAttempts int
+	backoff     time.Duration
+}
+
+type txKey struct{}
+
+func New(db *gorm.DB, opts Options) *UnitOfWork {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 20 * time.Millisecond
+	}
+	return &UnitOfWork{db: db, maxAttempts: opts.MaxAttempts, backoff: opts.Backoff}
+}
+
+// Do runs fn in a database transaction and commits when it returns nil.
+// Called again with the context fn received, it opens a savepoint inside the
+// running transaction instead, so a failing step can be rolled back on its
+// own. The outermost call retries the whole closure on serialization
+// failures and deadlocks, so fn must not have side effects outside the
+// database.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context, tx Tx) error) error {
+	if outer, ok := ctx.Value(txKey{}).(Tx); ok {
+		return outer.db.Transaction(func(db *gorm.DB) error {
+			tx := Tx{db: db}
+			return fn(context.WithValue(ctx, txKey{}, tx), tx)
+		})
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := u.transaction(ctx, attempt, fn)
+		if err == nil || attempt >= u.maxAttempts || !Retryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(u.backoff * time.Duration(attempt)):
+		}
+	}
+}
+
+// transaction is gorm's Transaction with the commit traced on its own, so a
+// slow commit can be told apart from a slow statement or lock wait.
+func (u *UnitOfWork) transaction(ctx context.Context, attempt int, fn func(ctx context.Context, tx Tx) error) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "uow.transaction", trace.WithAttributes(attribute.Int("uow.attempt", attempt)))
+	defer func() { tracing.End(span, err) }()
+
+	db := u.db.WithContext(ctx).Begin()
+	if db.Error != nil {
+		return db.Error
+	}
+	done := false
+	defer func() {
+		// Also runs while a panic unwinds.
+		if !done {
+			db.Rollback()
+		}
+	}()
+
+	tx := Tx{db: db}
+	if err := fn(context.WithValue(ctx, txKey{}, tx), tx); err != nil {
+		return err
+	}
+
+	_, commitSpan := tracing.Tracer().Start(ctx, "uow.commit")
+	err = db.Commit().Error
+	tracing.End(commitSpan, err)
+	done = true
+	return err
+}
+
+// Retryable reports whether err is a serialization failure or a deadlock,
+// which Postgres resolves by aborting one of the transactions involved.
+func Retryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == codeSerializationFailure || pgErr.Code == codeDeadlockDetected
+}
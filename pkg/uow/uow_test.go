@@ -0,0 +1,230 @@
+package uow
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/919Umesh/gold_go/pkg/uow/uowtest"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var errStep = errors.New("step failed")
+
+// failOn makes every statement containing marker fail with err.
+func failOn(marker string, err error) uowtest.Handler {
+	return func(query string, args []driver.NamedValue) (uowtest.Result, error) {
+		if strings.Contains(query, marker) {
+			return uowtest.Result{}, err
+		}
+		return uowtest.Result{RowsAffected: 1}, nil
+	}
+}
+
+// transactionControl keeps BEGIN, COMMIT, ROLLBACK and savepoint statements,
+// with savepoint names dropped.
+func transactionControl(statements []string) []string {
+	var kept []string
+	for _, statement := range statements {
+		switch {
+		case statement == "BEGIN", statement == "COMMIT", statement == "ROLLBACK":
+			kept = append(kept, statement)
+		case strings.HasPrefix(statement, "ROLLBACK TO SAVEPOINT "):
+			kept = append(kept, "ROLLBACK TO SAVEPOINT")
+		case strings.HasPrefix(statement, "SAVEPOINT "):
+			kept = append(kept, "SAVEPOINT")
+		default:
+			kept = append(kept, "EXEC")
+		}
+	}
+	return kept
+}
+
+func expectStatements(t *testing.T, db *uowtest.DB, want ...string) {
+	t.Helper()
+	if got := transactionControl(db.Statements()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("statements = %v, want %v", got, want)
+	}
+}
+
+func TestDoCommitsAllSteps(t *testing.T) {
+	gormDB, db := uowtest.Open(t, nil)
+
+	err := New(gormDB, Options{}).Do(context.Background(), func(ctx context.Context, tx Tx) error {
+		if err := tx.DB().Exec("UPDATE wallets SET locked = true").Error; err != nil {
+			return err
+		}
+		return tx.DB().Exec("INSERT INTO wallet_events DEFAULT VALUES").Error
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatements(t, db, "BEGIN", "EXEC", "EXEC", "COMMIT")
+}
+
+func TestDoRollsBackEveryStepWhenOneFails(t *testing.T) {
+	steps := []string{"UPDATE wallets", "INSERT INTO transactions", "INSERT INTO wallet_events"}
+
+	for _, failing := range steps {
+		gormDB, db := uowtest.Open(t, failOn(failing, errStep))
+
+		err := New(gormDB, Options{}).Do(context.Background(), func(ctx context.Context, tx Tx) error {
+			for _, step := range steps {
+				if err := tx.DB().Exec(step + " DEFAULT VALUES").Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if !errors.Is(err, errStep) {
+			t.Fatalf("%s: err = %v, want the step error", failing, err)
+		}
+		if db.Count("COMMIT") != 0 || db.Count("ROLLBACK") != 1 {
+			t.Fatalf("%s failing: statements = %v, want one rollback and no commit", failing, db.Statements())
+		}
+	}
+}
+
+func TestDoRollsBackOnPanic(t *testing.T) {
+	gormDB, db := uowtest.Open(t, nil)
+
+	func() {
+		defer func() { recover() }()
+		New(gormDB, Options{}).Do(context.Background(), func(ctx context.Context, tx Tx) error {
+			tx.DB().Exec("UPDATE wallets SET locked = true")
+			panic("boom")
+		})
+	}()
+	expectStatements(t, db, "BEGIN", "EXEC", "ROLLBACK")
+}
+
+func TestNestedDoRollsBackToItsSavepoint(t *testing.T) {
+	gormDB, db := uowtest.Open(t, failOn("INSERT INTO aml_alerts", errStep))
+	work := New(gormDB, Options{})
+
+	err := work.Do(context.Background(), func(ctx context.Context, tx Tx) error {
+		if err := tx.DB().Exec("UPDATE wallets SET locked = true").Error; err != nil {
+			return err
+		}
+		// The optional step fails on its own; the outer work carries on.
+		inner := work.Do(ctx, func(ctx context.Context, tx Tx) error {
+			return tx.DB().Exec("INSERT INTO aml_alerts DEFAULT VALUES").Error
+		})
+		if !errors.Is(inner, errStep) {
+			t.Errorf("inner err = %v, want the step error", inner)
+		}
+		return tx.DB().Exec("INSERT INTO wallet_events DEFAULT VALUES").Error
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatements(t, db, "BEGIN", "EXEC", "SAVEPOINT", "EXEC", "ROLLBACK TO SAVEPOINT", "EXEC", "COMMIT")
+}
+
+func TestNestedFailureReturnedByOuterRollsBackEverything(t *testing.T) {
+	gormDB, db := uowtest.Open(t, failOn("INSERT INTO wallet_events", errStep))
+	work := New(gormDB, Options{})
+
+	err := work.Do(context.Background(), func(ctx context.Context, tx Tx) error {
+		if err := tx.DB().Exec("UPDATE wallets SET locked = true").Error; err != nil {
+			return err
+		}
+		return work.Do(ctx, func(ctx context.Context, tx Tx) error {
+			return tx.DB().Exec("INSERT INTO wallet_events DEFAULT VALUES").Error
+		})
+	})
+	if !errors.Is(err, errStep) {
+		t.Fatalf("err = %v, want the step error", err)
+	}
+	expectStatements(t, db, "BEGIN", "EXEC", "SAVEPOINT", "EXEC", "ROLLBACK TO SAVEPOINT", "ROLLBACK")
+}
+
+func TestDoRetriesSerializationFailuresAndDeadlocks(t *testing.T) {
+	for _, code := range []string{codeSerializationFailure, codeDeadlockDetected} {
+		failures := 1
+		gormDB, db := uowtest.Open(t, func(query string, args []driver.NamedValue) (uowtest.Result, error) {
+			if strings.HasPrefix(query, "UPDATE") && failures > 0 {
+				failures--
+				return uowtest.Result{}, &pgconn.PgError{Code: code}
+			}
+			return uowtest.Result{RowsAffected: 1}, nil
+		})
+
+		attempts := 0
+		err := New(gormDB, Options{MaxAttempts: 3, Backoff: 1}).Do(context.Background(), func(ctx context.Context, tx Tx) error {
+			attempts++
+			if err := tx.DB().Exec("UPDATE wallets SET gold_grams = 1").Error; err != nil {
+				return err
+			}
+			return tx.DB().Exec("INSERT INTO transactions DEFAULT VALUES").Error
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", code, err)
+		}
+		if attempts != 2 {
+			t.Fatalf("%s: attempts = %d, want 2", code, attempts)
+		}
+		expectStatements(t, db, "BEGIN", "EXEC", "ROLLBACK", "BEGIN", "EXEC", "EXEC", "COMMIT")
+	}
+}
+
+func TestDoGivesUpAfterMaxAttempts(t *testing.T) {
+	gormDB, db := uowtest.Open(t, failOn("UPDATE", &pgconn.PgError{Code: codeSerializationFailure}))
+
+	attempts := 0
+	err := New(gormDB, Options{MaxAttempts: 3, Backoff: 1}).Do(context.Background(), func(ctx context.Context, tx Tx) error {
+		attempts++
+		return tx.DB().Exec("UPDATE wallets SET gold_grams = 1").Error
+	})
+	if !Retryable(err) {
+		t.Fatalf("err = %v, want the serialization failure", err)
+	}
+	if attempts != 3 || db.Count("ROLLBACK") != 3 || db.Count("COMMIT") != 0 {
+		t.Fatalf("attempts = %d, statements = %v", attempts, db.Statements())
+	}
+}
+
+func TestDoDoesNotRetryOtherErrors(t *testing.T) {
+	gormDB, _ := uowtest.Open(t, failOn("UPDATE", &pgconn.PgError{Code: "23505"}))
+
+	attempts := 0
+	New(gormDB, Options{MaxAttempts: 3, Backoff: 1}).Do(context.Background(), func(ctx context.Context, tx Tx) error {
+		attempts++
+		return tx.DB().Exec("UPDATE wallets SET gold_grams = 1").Error
+	})
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestNestedDoIsNotRetriedOnItsOwn(t *testing.T) {
+	failures := 1
+	gormDB, _ := uowtest.Open(t, func(query string, args []driver.NamedValue) (uowtest.Result, error) {
+		if strings.HasPrefix(query, "INSERT") && failures > 0 {
+			failures--
+			return uowtest.Result{}, &pgconn.PgError{Code: codeDeadlockDetected}
+		}
+		return uowtest.Result{RowsAffected: 1}, nil
+	})
+	work := New(gormDB, Options{MaxAttempts: 3, Backoff: 1})
+
+	outer, inner := 0, 0
+	err := work.Do(context.Background(), func(ctx context.Context, tx Tx) error {
+		outer++
+		return work.Do(ctx, func(ctx context.Context, tx Tx) error {
+			inner++
+			return tx.DB().Exec("INSERT INTO transactions DEFAULT VALUES").Error
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A deadlock aborts the whole transaction, so the retry restarts the
+	// outer closure rather than the savepoint.
+	if outer != 2 || inner != 2 {
+		t.Fatalf("outer = %d, inner = %d, want both run twice", outer, inner)
+	}
+}
@@ -0,0 +1,186 @@
+// Package uowtest is a database that records the statements it is sent, so
+// tests can see what a unit of work commits or rolls back without a running
+// Postgres.
+package uowtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Result is the answer to one statement. Queries return Rows under Columns;
+// other statements report RowsAffected.
+type Result struct {
+	Columns      []string
+	Rows         [][]driver.Value
+	RowsAffected int64
+}
+
+// Handler answers every statement except transaction control and
+// savepoints. Without a handler queries return no rows and other statements
+// affect one row.
+type Handler func(query string, args []driver.NamedValue) (Result, error)
+
+type DB struct {
+	mu         sync.Mutex
+	handler    Handler
+	statements []string
+}
+
+// Open returns a gorm handle backed by a recording DB.
+func Open(t testing.TB, handler Handler) (*gorm.DB, *DB) {
+	t.Helper()
+	db := &DB{handler: handler}
+	sqlDB := sql.OpenDB(connector{db: db})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Discard,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gormDB, db
+}
+
+// Statements returns every statement seen so far, transaction control
+// included ("BEGIN", "COMMIT", "ROLLBACK", "SAVEPOINT ...").
+func (d *DB) Statements() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.statements...)
+}
+
+// Count returns how many statements start with prefix.
+func (d *DB) Count(prefix string) int {
+	n := 0
+	for _, statement := range d.Statements() {
+		if strings.HasPrefix(statement, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (d *DB) record(statement string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.statements = append(d.statements, statement)
+}
+
+func (d *DB) run(query string, args []driver.NamedValue) (Result, error) {
+	d.record(query)
+	if strings.HasPrefix(query, "SAVEPOINT ") || strings.HasPrefix(query, "ROLLBACK TO SAVEPOINT ") ||
+		strings.HasPrefix(query, "RELEASE SAVEPOINT ") || d.handler == nil {
+		return Result{RowsAffected: 1}, nil
+	}
+	return d.handler(query, args)
+}
+
+type connector struct {
+	db *DB
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{db: c.db}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return openRefused{}
+}
+
+type openRefused struct{}
+
+func (openRefused) Open(name string) (driver.Conn, error) {
+	return nil, errors.New("uowtest: use Open")
+}
+
+type conn struct {
+	db *DB
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("uowtest: prepared statements are not supported")
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.db.record("BEGIN")
+	return tx{db: c.db}, nil
+}
+
+// CheckNamedValue passes every argument through unchanged; nothing is
+// encoded for a real server.
+func (c *conn) CheckNamedValue(value *driver.NamedValue) error {
+	return nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	result, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(result.RowsAffected), nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := c.db.run(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{columns: result.Columns, values: result.Rows}, nil
+}
+
+type tx struct {
+	db *DB
+}
+
+func (t tx) Commit() error {
+	t.db.record("COMMIT")
+	return nil
+}
+
+func (t tx) Rollback() error {
+	t.db.record("ROLLBACK")
+	return nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
@@ -5,34 +5,53 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 var (
 	ErrInvalidToken = errors.New("invalid token")
 )
 
+// PurposeMFA marks the short-lived token handed out between the password
+// and the second factor; it is not accepted as an access token.
+const PurposeMFA = "mfa"
+
 type JWTClaims struct {
-	UserID uint `json:"user_id"`
+	UserID   uint   `json:"user_id"`
+	Purpose  string `json:"purpose,omitempty"`
+	StepUpAt int64  `json:"step_up_at,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, secret string) (string, error) {
-	claims := JWTClaims{
+func NewClaims(userID uint, now time.Time, ttl time.Duration) *JWTClaims {
+	return &JWTClaims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(72 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
+}
 
+func SignToken(claims *JWTClaims, secret string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secret))
 }
 
+func GenerateToken(userID uint, secret string, ttl time.Duration) (string, *JWTClaims, error) {
+	claims := NewClaims(userID, time.Now(), ttl)
+	signed, err := SignToken(claims, secret)
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
+}
+
 func ParseToken(tokenString, secret string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
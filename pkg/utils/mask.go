@@ -0,0 +1,22 @@
+package utils
+
+import "strings"
+
+// MaskEmail keeps the first character of the local part and the domain:
+// "jane@example.com" becomes "j***@example.com".
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return MaskTail(email, 0)
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// MaskTail replaces everything but the last visible characters with '*'.
+func MaskTail(value string, visible int) string {
+	runes := []rune(value)
+	if len(runes) <= visible {
+		return strings.Repeat("*", len(runes))
+	}
+	return strings.Repeat("*", len(runes)-visible) + string(runes[len(runes)-visible:])
+}